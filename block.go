@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     block.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"errors"
+	"strings"
+)
+
+// DefaultTabWidth is the tab width of a [Block] returned by [NewBlock].
+const DefaultTabWidth = 8
+
+// ErrBlockNewline is returned by [GapBuffer.ReplaceBlock] and
+// [GapBuffer.PasteBlock] if the text to insert contains a newline character,
+// which would break the lines of the block.
+var ErrBlockNewline = errors.New("block text contains a newline")
+
+// Block is a rectangular selection, the display columns `StartCol` to `EndCol`
+// (exclusive) of the lines `FirstLine` to `LastLine` (inclusive). Line
+// numbering starts from 1 like [GapBuffer.Line], display columns are the
+// number of columns from the start of the line.
+//
+// Every unicode rune is one display column wide, except tabs, which are
+// expanded to the next multiple of `TabWidth`. A rune belongs to the block if
+// the display column it starts at is inside the block.
+type Block struct {
+	// The first line of the block.
+	FirstLine int
+
+	// The first display column of the block.
+	StartCol int
+
+	// The last line of the block, inclusive.
+	LastLine int
+
+	// The display column after the block, exclusive.
+	EndCol int
+
+	// The number of display columns between tab stops, tabs are one column
+	// wide if this is less than 1.
+	TabWidth int
+}
+
+// NewBlock returns the block with the corners (`line1`, `col1`) and (`line2`,
+// `col2`), in any order, and the tab width [DefaultTabWidth].
+func NewBlock(line1 int, col1 int, line2 int, col2 int) Block {
+	return Block{
+		FirstLine: min(line1, line2),
+		StartCol:  min(col1, col2),
+		LastLine:  max(line1, line2),
+		EndCol:    max(col1, col2),
+		TabWidth:  DefaultTabWidth,
+	}
+}
+
+// BlockText returns the text of the block, one string per line of the block
+// without newline characters. Lines shorter than the block are not padded.
+// Only existing lines are returned.
+//
+// See also [GapBuffer.DeleteBlock], [GapBuffer.PasteBlock].
+func (g *GapBuffer) BlockText(b Block) []string {
+	first, count := g.blockLines(b)
+	texts := make([]string, 0, count)
+
+	if count == 0 {
+		return texts
+	}
+
+	start := g.lines.lineStart(first)
+
+	for idx := first; idx < first+count; idx++ {
+		text := g.lineWithoutNewline(idx, start)
+		from, _ := displayColOffset(text, b.StartCol, b.TabWidth)
+		to, _ := displayColOffset(text, b.EndCol, b.TabWidth)
+		texts = append(texts, text[from:max(from, to)])
+		start += g.lines.lineLength(idx)
+	}
+
+	return texts
+}
+
+// DeleteBlock deletes the text of the block in every line. The cursor is
+// moved to the start of the block in its first line.
+//
+// See also [GapBuffer.BlockText], [GapBuffer.ReplaceBlock].
+func (g *GapBuffer) DeleteBlock(b Block) {
+	first, count := g.blockLines(b)
+	g.replaceBlock(b, first, count, func(int) string { return "" })
+}
+
+// ReplaceBlock replaces the text of the block in every line by `str`, like
+// typing in a block selection. Lines shorter than the start of the block are
+// padded with spaces. An empty block - with the same start and end column -
+// inserts `str` at the same column in every line. The cursor is moved to the
+// end of the inserted text in the first line.
+//
+// `str` is handled according to the [InvalidUTF8Policy]. Returns
+// [ErrBlockNewline] if `str` contains a newline character and [ErrInvalidUTF8]
+// if it is rejected by the [InvalidUTF8Policy], nothing is changed in this
+// case.
+//
+// See also [GapBuffer.DeleteBlock], [GapBuffer.PasteBlock].
+func (g *GapBuffer) ReplaceBlock(b Block, str string) error {
+	str, err := g.blockText(str)
+	if err != nil {
+		return err
+	}
+
+	first, count := g.blockLines(b)
+	g.replaceBlock(b, first, count, func(int) string { return str })
+
+	return nil
+}
+
+// PasteBlock replaces the text of the block by the given lines, pasting them
+// as a block: the first string is inserted at the start column of the first
+// line of the block, the second at the same column of the next line and so
+// on. Lines shorter than the start of the block are padded with spaces, lines
+// are appended to the text if needed. The cursor is moved to the end of the
+// text inserted in the first line.
+//
+// The strings are lines without newline characters, like the ones returned by
+// [GapBuffer.BlockText], and are handled according to the
+// [InvalidUTF8Policy]. Returns [ErrBlockNewline] if a string contains a
+// newline character and [ErrInvalidUTF8] if a string is rejected by the
+// [InvalidUTF8Policy], nothing is changed in this case.
+//
+// See also [GapBuffer.ReplaceBlock], [GapBuffer.DeleteBlock].
+func (g *GapBuffer) PasteBlock(b Block, lines []string) error {
+	texts := make([]string, len(lines))
+
+	for idx, line := range lines {
+		text, err := g.blockText(line)
+		if err != nil {
+			return err
+		}
+
+		texts[idx] = text
+	}
+
+	g.beginOp()
+	defer g.endOp()
+
+	g.DeleteBlock(b)
+
+	b.EndCol = b.StartCol
+	g.replaceBlock(b, max(b.FirstLine-1, 0), len(texts), func(idx int) string { return texts[idx] })
+
+	return nil
+}
+
+// blockText returns the text to insert in a line of a block after applying the
+// [InvalidUTF8Policy]. Returns [ErrBlockNewline] if the text contains a
+// newline character and [ErrInvalidUTF8] if it is rejected by the
+// [InvalidUTF8Policy].
+func (g *GapBuffer) blockText(str string) (string, error) {
+	if strings.ContainsRune(str, '\n') {
+		return "", ErrBlockNewline
+	}
+
+	str, ok := g.applyUTF8Policy(str)
+	if !ok {
+		return "", ErrInvalidUTF8
+	}
+
+	return str, nil
+}
+
+// blockLines returns the index of the first line of the block, starting from
+// 0, and the number of existing lines of the block.
+func (g *GapBuffer) blockLines(b Block) (first int, count int) {
+	g.indexLine(b.LastLine - 1)
+
+	first = max(b.FirstLine-1, 0)
+	last := min(b.LastLine, g.lines.lineCount())
+
+	return first, max(last-first, 0)
+}
+
+// replaceBlock replaces the columns of the block in `count` lines starting at
+// the line with index `first` by `text(n)`, where `n` is the index of the line
+// relative to `first`. Missing lines are appended to the text. The
+// [InvalidUTF8Policy] must already have been applied to the texts.
+//
+// The lines are changed from the first to the last one, so the gap moves
+// through the text once.
+func (g *GapBuffer) replaceBlock(b Block, first int, count int, text func(n int) string) {
+	if count == 0 {
+		return
+	}
+
+	g.beginOp()
+	defer g.endOp()
+
+	g.indexLine(first + count - 1)
+
+	startCol, endCol := max(b.StartCol, 0), max(b.EndCol, b.StartCol, 0)
+	cursor := -1
+	start := 0
+
+	if first < g.lines.lineCount() {
+		start = g.lines.lineStart(first)
+	}
+
+	for idx := first; idx < first+count; idx++ {
+		if idx >= g.lines.lineCount() {
+			g.setCursor(g.StringLength())
+			g.Insert(strings.Repeat("\n", idx-g.lines.lineCount()+1))
+			start = g.StringLength()
+		}
+
+		line := g.lineWithoutNewline(idx, start)
+		from, col := displayColOffset(line, startCol, b.TabWidth)
+		to, _ := displayColOffset(line, endCol, b.TabWidth)
+		str := text(idx - first)
+
+		if str != "" && col < startCol {
+			str = strings.Repeat(" ", startCol-col) + str
+		}
+
+		if from != to || str != "" {
+			g.replaceRange(start+from, start+to, str)
+		}
+
+		if cursor < 0 {
+			cursor = start + from + len(str)
+		}
+
+		start += g.lines.lineLength(idx)
+	}
+
+	g.MoveTo(cursor)
+}
+
+// lineWithoutNewline returns the text of the line with the given index,
+// starting from 0, which starts at the byte offset `start`, without the
+// newline character.
+func (g *GapBuffer) lineWithoutNewline(idx int, start int) string {
+	length := g.lines.lineLength(idx)
+
+	if idx < g.lines.lineCount()-1 {
+		length--
+	}
+
+	return g.slice(start, start+length)
+}
+
+// displayColOffset returns the byte offset of the first rune in the line that
+// starts at or after the display column `col` and the display column of this
+// offset. If the line is shorter, the length of the line and its display width
+// are returned.
+func displayColOffset(line string, col int, tabWidth int) (offset int, displayCol int) {
+	for idx, r := range line {
+		if displayCol >= col {
+			return idx, displayCol
+		}
+
+		if r == '\t' && tabWidth > 1 {
+			displayCol += tabWidth - displayCol%tabWidth
+		} else {
+			displayCol++
+		}
+	}
+
+	return len(line), displayCol
+}
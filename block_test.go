@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     block_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"strings"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const blockText = "name  | value\nfoo   | 1\nx\nbarbaz| 42\n"
+
+func TestNewBlock(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, gapbuffer.Block{FirstLine: 1, StartCol: 2, LastLine: 3, EndCol: 5, TabWidth: 8},
+		gapbuffer.NewBlock(3, 2, 1, 5))
+}
+
+func TestBlockText(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(blockText)
+
+	assert.Equal(t, []string{"| v", "| 1", "", "| 4"}, gb.BlockText(gapbuffer.NewBlock(1, 6, 4, 9)))
+	assert.Equal(t, []string{"name", "foo ", "x"}, gb.BlockText(gapbuffer.NewBlock(3, 0, 1, 4)))
+	assert.Equal(t, []string{"barbaz| 4", ""}, gb.BlockText(gapbuffer.NewBlock(4, 0, 10, 9)), "Clamped lines")
+	assert.Empty(t, gb.BlockText(gapbuffer.NewBlock(10, 0, 12, 9)), "No lines")
+}
+
+func TestBlockTextTabs(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("a\tb\n\tc\näöü\td")
+
+	assert.Equal(t, []string{"b", "c", "d"}, gb.BlockText(gapbuffer.NewBlock(1, 8, 3, 9)))
+	assert.Equal(t, []string{"\tb", "c", "öü\td"}, gb.BlockText(gapbuffer.NewBlock(1, 1, 3, 9)),
+		"Tabs starting in the block")
+
+	block := gapbuffer.NewBlock(1, 1, 3, 3)
+	block.TabWidth = 0
+	assert.Equal(t, []string{"\tb", "c", "öü"}, gb.BlockText(block), "Tabs are one column")
+}
+
+func TestDeleteBlock(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(blockText)
+	gb.DeleteBlock(gapbuffer.NewBlock(1, 4, 4, 6))
+
+	assert.Equal(t, "name| value\nfoo | 1\nx\nbarb| 42\n", gb.String())
+	assert.Equal(t, 4, gb.Offset(), "Cursor at the start of the block")
+	assert.Equal(t, 5, gb.LineCount())
+}
+
+func TestReplaceBlock(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(blockText)
+	require.NoError(t, gb.ReplaceBlock(gapbuffer.NewBlock(1, 6, 4, 7), "│"))
+
+	assert.Equal(t, "name  │ value\nfoo   │ 1\nx     │\nbarbaz│ 42\n", gb.String())
+	assert.Equal(t, 1, gb.Line())
+	assert.Equal(t, len("name  │"), gb.Col(), "Cursor after the inserted text")
+
+	require.NoError(t, gb.ReplaceBlock(gapbuffer.NewBlock(2, 0, 3, 0), "> "))
+	assert.Equal(t, "name  │ value\n> foo   │ 1\n> x     │\nbarbaz│ 42\n", gb.String(), "Insert")
+
+	require.NoError(t, gb.ReplaceBlock(gapbuffer.NewBlock(1, 20, 2, 20), ""))
+	assert.Equal(t, "name  │ value\n> foo   │ 1\n> x     │\nbarbaz│ 42\n", gb.String(), "Nothing to pad")
+}
+
+func TestPasteBlock(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(blockText)
+	values := gb.BlockText(gapbuffer.NewBlock(1, 6, 4, 100))
+	gb.DeleteBlock(gapbuffer.NewBlock(1, 6, 4, 100))
+	assert.Equal(t, "name  \nfoo   \nx\nbarbaz\n", gb.String())
+
+	require.NoError(t, gb.PasteBlock(gapbuffer.NewBlock(1, 0, 1, 0), values))
+	assert.Equal(t, "| valuename  \n| 1foo   \nx\n| 42barbaz\n", gb.String())
+	assert.Equal(t, len("| value"), gb.Offset())
+
+	gb = gapbuffer.NewStr("a\nb")
+	require.NoError(t, gb.PasteBlock(gapbuffer.NewBlock(2, 3, 2, 3), []string{"1", "2", "3"}))
+	assert.Equal(t, "a\nb  1\n   2\n   3", gb.String(), "Appended lines")
+
+	gb = gapbuffer.NewStr("a\n")
+	require.NoError(t, gb.PasteBlock(gapbuffer.NewBlock(4, 1, 4, 1), []string{"x"}))
+	assert.Equal(t, "a\n\n\n x", gb.String(), "Appended empty lines")
+}
+
+func TestBlockNewline(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(blockText)
+
+	err := gb.ReplaceBlock(gapbuffer.NewBlock(1, 0, 2, 0), "a\nb")
+	require.ErrorIs(t, err, gapbuffer.ErrBlockNewline)
+
+	err = gb.PasteBlock(gapbuffer.NewBlock(1, 0, 1, 0), []string{"a", "b\n"})
+	require.ErrorIs(t, err, gapbuffer.ErrBlockNewline)
+
+	assert.Equal(t, blockText, gb.String(), "Nothing changed")
+}
+
+func TestReplaceBlockLarge(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(strings.Repeat("column one\tcolumn two\n", 10_000))
+	require.NoError(t, gb.ReplaceBlock(gapbuffer.NewBlock(1, 16, 10_000, 22), "2nd"))
+
+	assert.Equal(t, strings.Repeat("column one\t2nd two\n", 10_000), gb.String())
+	assert.Equal(t, 1, gb.Line())
+}
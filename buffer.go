@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     buffer.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+// Buffer is the text container of an editor with a cursor, implemented by
+// [GapBuffer], [PieceTable] and [Rope]. Pick the implementation per file: a
+// gap buffer is fast for typing at the cursor, a piece table does not copy the
+// original text, which is better for huge files, and a rope edits and looks up
+// lines anywhere in very large texts in O(log n).
+//
+// See [GapBuffer] for the documentation of the methods.
+type Buffer interface {
+	// Return the text as a string.
+	String() string
+
+	// Return the text to the left and to the right of the cursor.
+	StringPair() (left string, right string)
+
+	// Return the length of the text in bytes.
+	StringLength() int
+
+	// Return the byte column of the cursor.
+	Col() int
+
+	// Return the rune column of the cursor.
+	RuneCol() int
+
+	// Return the length in bytes of the line of the cursor.
+	LineLength() int
+
+	// Return the line number of the cursor, starting from 1.
+	Line() int
+
+	// Return the line and byte column of the cursor.
+	LineCol() (line int, col int)
+
+	// Return the line and rune column of the cursor.
+	LineRuneCol() (line int, runeCol int)
+
+	// Return the byte offset of the cursor.
+	Offset() int
+
+	// Return the number of lines.
+	LineCount() int
+
+	// Delete the rune to the left of the cursor.
+	LeftDel()
+
+	// Delete the rune to the right of the cursor.
+	RightDel()
+
+	// Move the cursor one rune to the left.
+	LeftMv()
+
+	// Move the cursor one rune to the right.
+	RightMv()
+
+	// Move the cursor up one line.
+	UpMv()
+
+	// Move the cursor down one line.
+	DownMv()
+
+	// Insert the string at the cursor.
+	Insert(str string)
+
+	// Move the cursor to the byte offset.
+	MoveTo(offset int)
+
+	// Delete the text between the byte offsets.
+	DeleteRange(from int, to int)
+
+	// Replace the text between the byte offsets.
+	ReplaceRange(from int, to int, str string)
+}
+
+var (
+	_ Buffer = (*GapBuffer)(nil)
+	_ Buffer = (*PieceTable)(nil)
+	_ Buffer = (*Rope)(nil)
+)
@@ -0,0 +1,217 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     checked.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+var (
+	// ErrAtStart is returned if the cursor can't move or delete to the left or
+	// up, because it is at the start of the text or in the first line.
+	ErrAtStart = errors.New("cursor is at the start of the text")
+
+	// ErrAtEnd is returned if the cursor can't move or delete to the right or
+	// down, because it is at the end of the text or in the last line.
+	ErrAtEnd = errors.New("cursor is at the end of the text")
+
+	// ErrOutOfRange is returned if an offset is not inside the text or a range
+	// ends before it starts.
+	ErrOutOfRange = errors.New("offset is out of range")
+
+	// ErrInvalidUTF8 is returned if a string to insert is not valid UTF-8 or an
+	// offset is in the middle of a unicode rune.
+	ErrInvalidUTF8 = errors.New("invalid UTF-8")
+)
+
+// The methods in this file are the checked variants of the movement and
+// editing methods of the gap buffer. Instead of silently doing nothing at the
+// start or the end of the text, or clamping invalid offsets, they return an
+// error and don't change the gap buffer.
+
+// Move the cursor one unicode rune to the left like [GapBuffer.LeftMv].
+// Returns [ErrAtStart] if the cursor is at the start of the text.
+func (g *GapBuffer) LeftMvErr() error {
+	if g.cursor < 1 {
+		return ErrAtStart
+	}
+
+	g.LeftMv()
+
+	return nil
+}
+
+// Move the cursor one unicode rune to the right like [GapBuffer.RightMv].
+// Returns [ErrAtEnd] if the cursor is at the end of the text.
+func (g *GapBuffer) RightMvErr() error {
+	if g.cursor >= g.StringLength() {
+		return ErrAtEnd
+	}
+
+	g.RightMv()
+
+	return nil
+}
+
+// Move the cursor up one line like [GapBuffer.UpMv]. Returns [ErrAtStart] if
+// the cursor is in the first line.
+func (g *GapBuffer) UpMvErr() error {
+	if g.lines.curLine() == 1 {
+		return ErrAtStart
+	}
+
+	g.UpMv()
+
+	return nil
+}
+
+// Move the cursor down one line like [GapBuffer.DownMv]. Returns [ErrAtEnd] if
+// the cursor is in the last line.
+func (g *GapBuffer) DownMvErr() error {
+	if g.Line() == g.LineCount() {
+		return ErrAtEnd
+	}
+
+	g.DownMv()
+
+	return nil
+}
+
+// Delete the unicode rune to the left of the cursor like [GapBuffer.LeftDel].
+// Returns [ErrAtStart] if the cursor is at the start of the text.
+func (g *GapBuffer) LeftDelErr() error {
+	if g.cursor < 1 {
+		return ErrAtStart
+	}
+
+	g.LeftDel()
+
+	return nil
+}
+
+// Delete the unicode rune to the right of the cursor like
+// [GapBuffer.RightDel]. Returns [ErrAtEnd] if the cursor is at the end of the
+// text.
+func (g *GapBuffer) RightDelErr() error {
+	if g.cursor >= g.StringLength() {
+		return ErrAtEnd
+	}
+
+	g.RightDel()
+
+	return nil
+}
+
+// Insert the string at the cursor like [GapBuffer.Insert]. Returns
+// [ErrInvalidUTF8] if the string is not valid UTF-8, unless the
+// [InvalidUTF8Policy] is [ReplaceInvalidUTF8].
+func (g *GapBuffer) InsertErr(str string) error {
+	if !g.insertable(str) {
+		return ErrInvalidUTF8
+	}
+
+	g.Insert(str)
+
+	return nil
+}
+
+// Move the cursor to the given byte offset like [GapBuffer.MoveTo]. Returns
+// [ErrOutOfRange] if the offset is not inside the text and [ErrInvalidUTF8] if
+// it is in the middle of a unicode rune.
+func (g *GapBuffer) MoveToErr(offset int) error {
+	if err := g.checkOffset(offset); err != nil {
+		return err
+	}
+
+	g.MoveTo(offset)
+
+	return nil
+}
+
+// Delete the text between the byte offsets `from` (inclusive) and `to`
+// (exclusive) like [GapBuffer.DeleteRange]. Returns [ErrOutOfRange] if an
+// offset is not inside the text or `to` is less than `from`, and
+// [ErrInvalidUTF8] if an offset is in the middle of a unicode rune.
+func (g *GapBuffer) DeleteRangeErr(from int, to int) error {
+	if err := g.checkRange(from, to); err != nil {
+		return err
+	}
+
+	g.DeleteRange(from, to)
+
+	return nil
+}
+
+// Replace the text between the byte offsets `from` (inclusive) and `to`
+// (exclusive) with the given string like [GapBuffer.ReplaceRange]. Returns the
+// errors of [GapBuffer.DeleteRangeErr] and [GapBuffer.InsertErr].
+func (g *GapBuffer) ReplaceRangeErr(from int, to int, str string) error {
+	if err := g.checkRange(from, to); err != nil {
+		return err
+	}
+
+	if !g.insertable(str) {
+		return ErrInvalidUTF8
+	}
+
+	g.ReplaceRange(from, to, str)
+
+	return nil
+}
+
+// insertable returns false if the string is not valid UTF-8 and invalid UTF-8
+// isn't replaced, see [InvalidUTF8Policy].
+func (g *GapBuffer) insertable(str string) bool {
+	return g.utf8Policy == ReplaceInvalidUTF8 || utf8.ValidString(str)
+}
+
+// checkRange returns an error if one of the offsets is invalid, see
+// [GapBuffer.checkOffset], or `to` is less than `from`.
+func (g *GapBuffer) checkRange(from int, to int) error {
+	if to < from {
+		return ErrOutOfRange
+	}
+
+	if err := g.checkOffset(from); err != nil {
+		return err
+	}
+
+	return g.checkOffset(to)
+}
+
+// checkOffset returns [ErrOutOfRange] if the offset is not inside the text and
+// [ErrInvalidUTF8] if it is in the middle of a valid unicode rune.
+func (g *GapBuffer) checkOffset(offset int) error {
+	if offset < 0 || offset > g.StringLength() {
+		return ErrOutOfRange
+	}
+
+	if offset == 0 || offset == g.StringLength() {
+		return nil
+	}
+
+	// The start of the rune containing the offset, if it isn't an invalid
+	// byte.
+	start := offset
+	for start > max(offset-utf8.UTFMax+1, 0) && !utf8.RuneStart(g.byteAt(start)) {
+		start--
+	}
+
+	if start == offset {
+		return nil
+	}
+
+	if r, size := g.runeAt(start); start+size > offset && (r != utf8.RuneError || size > 1) {
+		return ErrInvalidUTF8
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     checked_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMvErr(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("ab\nä")
+	assert.ErrorIs(t, gb.RightMvErr(), gapbuffer.ErrAtEnd)
+	assert.ErrorIs(t, gb.DownMvErr(), gapbuffer.ErrAtEnd)
+	require.NoError(t, gb.UpMvErr())
+	assert.ErrorIs(t, gb.UpMvErr(), gapbuffer.ErrAtStart)
+
+	moves := 0
+	for gb.LeftMvErr() == nil {
+		moves++
+	}
+
+	assert.Equal(t, 1, moves)
+	assert.Equal(t, 0, gb.Offset())
+
+	for gb.RightMvErr() == nil {
+		moves++
+	}
+
+	assert.Equal(t, 5, moves)
+	assert.Equal(t, len("ab\nä"), gb.Offset())
+}
+
+func TestDelErr(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("äb")
+	assert.ErrorIs(t, gb.RightDelErr(), gapbuffer.ErrAtEnd)
+	require.NoError(t, gb.LeftDelErr())
+	require.NoError(t, gb.LeftDelErr())
+	assert.ErrorIs(t, gb.LeftDelErr(), gapbuffer.ErrAtStart)
+	assert.Equal(t, "", gb.String())
+}
+
+func TestInsertErr(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("a")
+	assert.ErrorIs(t, gb.InsertErr("b\xffc"), gapbuffer.ErrInvalidUTF8)
+	assert.Equal(t, "a", gb.String(), "Nothing is inserted")
+	require.NoError(t, gb.InsertErr("ü"))
+	assert.Equal(t, "aü", gb.String())
+}
+
+func TestMoveToErr(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("aü😀\xffb")
+
+	tests := []struct {
+		offset int
+		err    error
+	}{
+		{offset: -1, err: gapbuffer.ErrOutOfRange},
+		{offset: 0, err: nil},
+		{offset: 2, err: gapbuffer.ErrInvalidUTF8},
+		{offset: 3, err: nil},
+		{offset: 5, err: gapbuffer.ErrInvalidUTF8},
+		{offset: 6, err: gapbuffer.ErrInvalidUTF8},
+		{offset: 7, err: nil},
+		{offset: 8, err: nil},
+		{offset: 9, err: nil},
+		{offset: 10, err: gapbuffer.ErrOutOfRange},
+	}
+
+	for _, tc := range tests {
+		if tc.err == nil {
+			require.NoError(t, gb.MoveToErr(tc.offset), "Offset %d", tc.offset)
+			assert.Equal(t, tc.offset, gb.Offset())
+		} else {
+			assert.ErrorIs(t, gb.MoveToErr(tc.offset), tc.err, "Offset %d", tc.offset)
+		}
+	}
+}
+
+func TestRangeErr(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("äöü")
+	assert.ErrorIs(t, gb.DeleteRangeErr(4, 2), gapbuffer.ErrOutOfRange)
+	assert.ErrorIs(t, gb.DeleteRangeErr(0, 7), gapbuffer.ErrOutOfRange)
+	assert.ErrorIs(t, gb.DeleteRangeErr(1, 4), gapbuffer.ErrInvalidUTF8)
+	assert.ErrorIs(t, gb.ReplaceRangeErr(2, 4, "\xc3"), gapbuffer.ErrInvalidUTF8)
+	assert.Equal(t, "äöü", gb.String())
+
+	require.NoError(t, gb.DeleteRangeErr(2, 4))
+	require.NoError(t, gb.ReplaceRangeErr(0, 2, "a"))
+	assert.Equal(t, "aü", gb.String())
+}
@@ -0,0 +1,372 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     client.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package collab
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/Release-Candidate/go-gap-buffer/ot"
+)
+
+var (
+	// ErrServer is the error returned by the client if the server sent an
+	// error message, the message of the server is appended.
+	ErrServer = errors.New("server error")
+
+	// ErrProtocol is returned if the server sent an unexpected message.
+	ErrProtocol = errors.New("protocol error")
+)
+
+// Client keeps a local [gapbuffer.GapBuffer] in sync with a document of a
+// [Server] and tracks the cursors of the other clients editing the document.
+//
+// All methods are safe to call from multiple goroutines, the messages of the
+// server are handled in a goroutine of the client.
+type Client struct {
+	// Guards all fields and writes to the connection.
+	mu sync.Mutex
+
+	// The connection to the server.
+	conn net.Conn
+
+	// The encoder writing to the connection.
+	enc *json.Encoder
+
+	// The ID of the client, assigned by the server.
+	id int
+
+	// The local copy of the document and the OT state.
+	ot *ot.Client
+
+	// The cursors of the other clients, in the local gap buffer.
+	cursors map[int]Cursor
+
+	// True if the local cursor has moved since it has last been sent.
+	cursorMoved bool
+
+	// The first error of the connection or the last error sent by the server.
+	err error
+
+	// Closed when the connection has been closed.
+	done chan struct{}
+}
+
+// Dial connects to the server at the given address and joins the document
+// with the given name as user `name`. The network is "tcp" or "unix", see
+// [net.Dial].
+func Dial(network string, address string, doc string, name string) (*Client, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newClient(conn, doc, name)
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// newClient joins the document on the connection and starts the goroutine
+// handling the messages of the server.
+func newClient(conn net.Conn, doc string, name string) (*Client, error) {
+	enc := json.NewEncoder(conn)
+	if err := enc.Encode(Message{Type: TypeJoin, Doc: doc, Name: name}); err != nil { //nolint:exhaustruct // join
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(nil, maxMessageSize)
+
+	msg, err := readMessage(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Type != TypeSnapshot {
+		return nil, ErrProtocol
+	}
+
+	c := &Client{
+		mu:          sync.Mutex{},
+		conn:        conn,
+		enc:         enc,
+		id:          msg.Client,
+		ot:          ot.NewClient(gapbuffer.NewStr(msg.Text), msg.Revision),
+		cursors:     map[int]Cursor{},
+		cursorMoved: false,
+		err:         nil,
+		done:        make(chan struct{}),
+	}
+
+	for _, cursor := range msg.Cursors {
+		c.cursors[cursor.Client] = cursor
+	}
+
+	go c.readLoop(scanner)
+
+	return c, nil
+}
+
+// Close closes the connection to the server.
+func (c *Client) Close() error {
+	err := c.conn.Close()
+	<-c.done
+
+	return err
+}
+
+// Return the ID of the client, assigned by the server.
+func (c *Client) ID() int {
+	return c.id
+}
+
+// Return the text of the local copy of the document.
+func (c *Client) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ot.Buffer().String()
+}
+
+// Return the byte offset of the local cursor.
+func (c *Client) Offset() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ot.Buffer().Offset()
+}
+
+// Return the revision of the server's document the client has seen.
+func (c *Client) Revision() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ot.Revision()
+}
+
+// Return true if the server has acknowledged all local edits.
+func (c *Client) Synced() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return !c.ot.HasPending()
+}
+
+// Return the first error of the connection or the last error message of the
+// server, nil if there hasn't been an error.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+// Return the cursors of the other clients, with the offsets in the local copy
+// of the document, sorted by client ID.
+func (c *Client) Cursors() []Cursor {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cursors := make([]Cursor, 0, len(c.cursors))
+	for _, cursor := range c.cursors {
+		cursors = append(cursors, cursor)
+	}
+
+	sort.Slice(cursors, func(i, j int) bool { return cursors[i].Client < cursors[j].Client })
+
+	return cursors
+}
+
+// Read calls `f` with the local gap buffer, while no message of the server can
+// change it.
+//
+// Warning: `f` must not change the text of the gap buffer, use
+// [Client.Replace] and [Client.MoveTo].
+func (c *Client) Read(f func(buf *gapbuffer.GapBuffer)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f(c.ot.Buffer())
+}
+
+// Replace replaces the bytes between `from` (inclusive) and `to` (exclusive)
+// with the given text, like [gapbuffer.GapBuffer.ReplaceRange], and sends the
+// change to the server.
+func (c *Client) Replace(from int, to int, text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.replace(from, to, text)
+}
+
+// Insert inserts the text at the local cursor, like
+// [gapbuffer.GapBuffer.Insert], and sends the change to the server.
+func (c *Client) Insert(text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	offset := c.ot.Buffer().Offset()
+
+	return c.replace(offset, offset, text)
+}
+
+// replace does the work of [Client.Replace], the lock must be held.
+func (c *Client) replace(from int, to int, text string) error {
+	buf := c.ot.Buffer()
+	length := buf.StringLength()
+	from, to = min(max(from, 0), length), min(max(to, 0), length)
+
+	if to < from {
+		from, to = to, from
+	}
+
+	op := ot.Replace(length, from, to, text)
+	if err := c.ot.ApplyLocal(op); err != nil {
+		return err
+	}
+
+	c.transformCursors(op)
+	c.cursorMoved = true
+
+	return c.flush()
+}
+
+// MoveTo moves the local cursor, like [gapbuffer.GapBuffer.MoveTo], and sends
+// the new position to the server.
+func (c *Client) MoveTo(offset int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ot.Buffer().MoveTo(offset)
+	c.cursorMoved = true
+
+	return c.flush()
+}
+
+// flush sends the buffered local operation to the server, if no operation is
+// waiting for its acknowledgement. Sends the cursor if there is no local
+// operation pending.
+func (c *Client) flush() error {
+	if revision, op, ok := c.ot.Send(); ok {
+		return c.enc.Encode(Message{Type: TypeOp, Revision: revision, Op: op}) //nolint:exhaustruct // op
+	}
+
+	if c.ot.HasPending() || !c.cursorMoved {
+		return nil
+	}
+
+	c.cursorMoved = false
+
+	return c.enc.Encode(Message{ //nolint:exhaustruct // cursor
+		Type:     TypeCursor,
+		Revision: c.ot.Revision(),
+		Offset:   c.ot.Buffer().Offset(),
+	})
+}
+
+// transformCursors moves the cursors of the other clients along with the
+// change of the local document by the operation.
+func (c *Client) transformCursors(op *ot.Operation) {
+	for id, cursor := range c.cursors {
+		cursor.Offset = op.TransformIndex(cursor.Offset)
+		c.cursors[id] = cursor
+	}
+}
+
+// readLoop handles the messages of the server until the connection is closed.
+func (c *Client) readLoop(scanner *bufio.Scanner) {
+	defer close(c.done)
+
+	for {
+		msg, err := readMessage(scanner)
+		if err != nil {
+			c.mu.Lock()
+			if c.err == nil {
+				c.err = err
+			}
+			c.mu.Unlock()
+
+			return
+		}
+
+		c.mu.Lock()
+		if err = c.handle(msg); err != nil {
+			c.err = err
+		}
+		c.mu.Unlock()
+	}
+}
+
+// handle handles a single message of the server.
+func (c *Client) handle(msg Message) error {
+	switch msg.Type {
+	case TypeAck:
+		if err := c.ot.Ack(); err != nil {
+			return err
+		}
+
+		return c.flush()
+
+	case TypeOp:
+		if msg.Op == nil {
+			return ErrProtocol
+		}
+
+		op, err := c.ot.ApplyRemote(msg.Op)
+		if err != nil {
+			return err
+		}
+
+		c.transformCursors(op)
+
+	case TypeCursor:
+		c.cursors[msg.Client] = Cursor{Client: msg.Client, Name: msg.Name, Offset: c.ot.TransformIndex(msg.Offset)}
+
+	case TypeLeave:
+		delete(c.cursors, msg.Client)
+
+	case TypeError:
+		return fmt.Errorf("%w: %s", ErrServer, msg.Error)
+
+	default:
+		return ErrProtocol
+	}
+
+	return nil
+}
+
+// readMessage reads the next message from the scanner.
+func readMessage(scanner *bufio.Scanner) (Message, error) {
+	var msg Message
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return msg, err
+		}
+
+		return msg, io.EOF
+	}
+
+	err := json.Unmarshal(scanner.Bytes(), &msg)
+
+	return msg, err
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     collab_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package collab_test
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Release-Candidate/go-gap-buffer/collab"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	waitFor = 5 * time.Second
+	tick    = 5 * time.Millisecond
+)
+
+// startServer starts a server on the network and returns its address.
+func startServer(t *testing.T, network string, address string) (*collab.Server, string) {
+	t.Helper()
+
+	l, err := net.Listen(network, address)
+	require.NoError(t, err)
+
+	srv := collab.NewServer()
+
+	go func() { _ = srv.Serve(l) }()
+
+	t.Cleanup(func() { _ = srv.Close() })
+
+	return srv, l.Addr().String()
+}
+
+func dial(t *testing.T, network string, address string, name string) *collab.Client {
+	t.Helper()
+
+	client, err := collab.Dial(network, address, "doc", name)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client
+}
+
+// waitSynced waits until all clients have the text of the server's document.
+func waitSynced(t *testing.T, srv *collab.Server, clients ...*collab.Client) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		text, revision, _ := srv.Document("doc")
+
+		for _, client := range clients {
+			if !client.Synced() || client.Revision() != revision || client.String() != text {
+				return false
+			}
+		}
+
+		return true
+	}, waitFor, tick)
+}
+
+func TestJoinExistingDocument(t *testing.T) {
+	t.Parallel()
+
+	srv, addr := startServer(t, "tcp", "127.0.0.1:0")
+	require.NoError(t, srv.AddDocument("doc", "Hello World!"))
+	require.ErrorIs(t, srv.AddDocument("doc", ""), collab.ErrDocumentExists)
+
+	alice := dial(t, "tcp", addr, "Alice")
+	assert.Equal(t, "Hello World!", alice.String())
+	assert.Equal(t, 0, alice.Revision())
+}
+
+func TestConcurrentEditsTCP(t *testing.T) {
+	t.Parallel()
+
+	srv, addr := startServer(t, "tcp", "127.0.0.1:0")
+	clients := []*collab.Client{
+		dial(t, "tcp", addr, "Alice"),
+		dial(t, "tcp", addr, "Bob"),
+		dial(t, "tcp", addr, "Carol"),
+	}
+	rnd := rand.New(rand.NewSource(29)) //nolint:gosec // deterministic tests
+
+	for i := 0; i < 300; i++ {
+		client := clients[rnd.Intn(len(clients))]
+		length := len(client.String())
+
+		if length > 0 && rnd.Intn(3) == 0 {
+			from := rnd.Intn(length)
+			require.NoError(t, client.Replace(from, from+1, ""))
+		} else {
+			require.NoError(t, client.MoveTo(rnd.Intn(length+1)))
+			require.NoError(t, client.Insert(string(rune('a'+rnd.Intn(26)))))
+		}
+	}
+
+	waitSynced(t, srv, clients...)
+
+	for _, client := range clients {
+		require.NoError(t, client.Err())
+	}
+}
+
+func TestRemoteCursorsUnix(t *testing.T) {
+	t.Parallel()
+
+	socket := filepath.Join(t.TempDir(), "collab.sock")
+	srv, addr := startServer(t, "unix", socket)
+	require.NoError(t, srv.AddDocument("doc", "Hello World!"))
+
+	alice := dial(t, "unix", addr, "Alice")
+	bob := dial(t, "unix", addr, "Bob")
+
+	require.NoError(t, alice.MoveTo(6))
+	require.Eventually(t, func() bool { return len(bob.Cursors()) == 1 }, waitFor, tick)
+	assert.Equal(t, []collab.Cursor{{Client: alice.ID(), Name: "Alice", Offset: 6}}, bob.Cursors())
+
+	require.NoError(t, bob.Replace(0, 0, ">> "))
+	waitSynced(t, srv, alice, bob)
+
+	assert.Equal(t, 9, alice.Offset(), "Alice's cursor moved along")
+	assert.Equal(t, 9, bob.Cursors()[0].Offset, "Bob sees Alice's cursor")
+
+	carol := dial(t, "unix", addr, "Carol")
+	expected := []collab.Cursor{
+		{Client: alice.ID(), Name: "Alice", Offset: 9},
+		{Client: bob.ID(), Name: "Bob", Offset: 3},
+	}
+	require.Eventually(t, func() bool { return assert.ObjectsAreEqual(expected, carol.Cursors()) }, waitFor, tick)
+
+	require.NoError(t, alice.Close())
+	require.Eventually(t, func() bool { return len(bob.Cursors()) == 0 }, waitFor, tick)
+}
+
+// A client that doesn't read its messages must not block the others.
+func TestStalledClient(t *testing.T) {
+	t.Parallel()
+
+	srv, addr := startServer(t, "tcp", "127.0.0.1:0")
+
+	stalled, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = stalled.Close() })
+	require.NoError(t, json.NewEncoder(stalled).Encode(collab.Message{ //nolint:exhaustruct // join
+		Type: collab.TypeJoin,
+		Doc:  "doc",
+		Name: "Stalled",
+	}))
+
+	alice := dial(t, "tcp", addr, "Alice")
+	bob := dial(t, "tcp", addr, "Bob")
+	chunk := strings.Repeat("x", 512*1024)
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, alice.Insert(chunk))
+	}
+
+	waitSynced(t, srv, alice, bob)
+}
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     protocol.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+// Package collab implements a small server hosting documents for collaborative
+// editing and a client keeping a local [gapbuffer.GapBuffer] in sync with a
+// document of the server. The edits are [ot.Operation]s, see package ot.
+//
+// Server and clients talk over a stream connection - TCP or a Unix domain
+// socket - using line delimited JSON: every message is a single JSON object
+// terminated by a newline character '\n'. Every message has a "type" field,
+// the other fields depend on the type. Offsets are byte offsets in the
+// document, operations are encoded like [ot.Operation.MarshalJSON] does.
+//
+// Client to server:
+//
+//	{"type":"join","doc":"notes.txt","name":"Alice"}
+//
+// Must be the first message, joins the document with the given name. The
+// document is created empty, if it doesn't exist yet. The server answers with
+// a "snapshot".
+//
+//	{"type":"op","revision":3,"op":[5,"xy",-1,6]}
+//
+// An operation based on the given revision of the document. The server
+// transforms the operation against all operations since that revision,
+// answers with an "ack" and broadcasts the transformed operation to all other
+// clients. A client must not send another operation before the "ack".
+//
+//	{"type":"cursor","revision":4,"offset":7}
+//
+// The position of the client's cursor in the document at the given revision.
+// The server broadcasts the cursor to all other clients. A client must not
+// send a cursor while an operation of it is waiting for its "ack".
+//
+// Server to client:
+//
+//	{"type":"snapshot","client":2,"revision":4,"text":"Hello","cursors":[{"client":1,"name":"Bob","offset":3}]}
+//
+// The answer to "join": the ID of the client, the current revision and text of
+// the document and the cursors of the other clients.
+//
+//	{"type":"ack","revision":5}
+//
+// The operation of the client has been applied, the document has the given
+// revision now.
+//
+//	{"type":"op","client":1,"revision":6,"op":[7,-3]}
+//
+// The operation of another client, which yields the given revision of the
+// document.
+//
+//	{"type":"cursor","client":1,"name":"Bob","revision":6,"offset":7}
+//
+// The cursor of another client in the given revision of the document.
+//
+//	{"type":"leave","client":1}
+//
+// The client with the given ID has left the document.
+//
+//	{"type":"error","error":"invalid revision"}
+//
+// The last message of the client has been rejected.
+package collab
+
+import (
+	"github.com/Release-Candidate/go-gap-buffer/ot"
+)
+
+// The types of messages.
+const (
+	TypeJoin     = "join"
+	TypeOp       = "op"
+	TypeCursor   = "cursor"
+	TypeSnapshot = "snapshot"
+	TypeAck      = "ack"
+	TypeLeave    = "leave"
+	TypeError    = "error"
+)
+
+// The maximum size in bytes of a single message.
+const maxMessageSize = 64 * 1024 * 1024
+
+// Message is a message of the protocol, see the package documentation for the
+// fields used by each type of message.
+type Message struct {
+	Type     string        `json:"type"`
+	Doc      string        `json:"doc,omitempty"`
+	Name     string        `json:"name,omitempty"`
+	Client   int           `json:"client,omitempty"`
+	Revision int           `json:"revision,omitempty"`
+	Op       *ot.Operation `json:"op,omitempty"`
+	Offset   int           `json:"offset,omitempty"`
+	Text     string        `json:"text,omitempty"`
+	Cursors  []Cursor      `json:"cursors,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Cursor is the cursor position of a client.
+type Cursor struct {
+	// The ID of the client.
+	Client int `json:"client"`
+
+	// The name of the client.
+	Name string `json:"name"`
+
+	// The byte offset of the cursor in the document.
+	Offset int `json:"offset"`
+}
@@ -0,0 +1,397 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     server.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package collab
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Release-Candidate/go-gap-buffer/ot"
+)
+
+var (
+	// ErrDocumentExists is returned by [Server.AddDocument] if a document with
+	// the same name already exists.
+	ErrDocumentExists = errors.New("document already exists")
+
+	// ErrServerClosed is returned by [Server.Serve] after [Server.Close] has
+	// been called.
+	ErrServerClosed = errors.New("server closed")
+
+	// ErrNotJoined is sent to a client whose first message is not a join.
+	ErrNotJoined = errors.New("the first message must be a join")
+
+	// ErrUnknownType is sent to a client that sends a message of unknown type.
+	ErrUnknownType = errors.New("unknown message type")
+)
+
+// The time a write of a message to a client may take, before the client is
+// disconnected.
+const writeTimeout = 10 * time.Second
+
+// The number of messages queued for a client, a client that doesn't read fast
+// enough to keep its queue from filling up is disconnected.
+const outboxSize = 1024
+
+// Server hosts documents and broadcasts the changes of each document to all
+// clients that joined it. A single server can serve any number of listeners.
+type Server struct {
+	// Guards all fields, the documents and their members. Messages to clients
+	// are only queued while holding it, see [member.enqueue].
+	mu sync.Mutex
+
+	// The documents by name.
+	docs map[string]*document
+
+	// The ID of the last client that joined.
+	lastID int
+
+	// The listeners that are being served.
+	listeners []net.Listener
+
+	// The connections of all clients.
+	conns map[net.Conn]struct{}
+
+	// True after [Server.Close] has been called.
+	closed bool
+}
+
+// document is a document of the server and the clients that joined it.
+type document struct {
+	// The text and history of the document.
+	srv *ot.Server
+
+	// The clients that joined the document by ID.
+	members map[int]*member
+}
+
+// member is a client connection, which joins a document with its first
+// message.
+type member struct {
+	// The ID of the client.
+	id int
+
+	// The name of the client.
+	name string
+
+	// The connection to the client.
+	conn net.Conn
+
+	// The messages to write to the client, see [member.write].
+	out chan Message
+
+	// The position of the client's cursor in the current revision of the
+	// document, if the client has sent one.
+	cursor    int
+	hasCursor bool
+}
+
+// NewServer returns a new server without any document.
+func NewServer() *Server {
+	return &Server{
+		mu:        sync.Mutex{},
+		docs:      map[string]*document{},
+		lastID:    0,
+		listeners: nil,
+		conns:     map[net.Conn]struct{}{},
+		closed:    false,
+	}
+}
+
+// AddDocument adds a document with the given name and text to the server.
+//
+// Returns [ErrDocumentExists] if a document with the name already exists.
+func (s *Server) AddDocument(name string, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.docs[name]; ok {
+		return ErrDocumentExists
+	}
+
+	s.docs[name] = &document{srv: ot.NewServer(text), members: map[int]*member{}}
+
+	return nil
+}
+
+// Document returns the current text and revision of the document with the
+// given name. Returns false, if the document does not exist.
+func (s *Server) Document(name string) (text string, revision int, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.docs[name]
+	if !ok {
+		return "", 0, false
+	}
+
+	return doc.srv.String(), doc.srv.Revision(), true
+}
+
+// Serve accepts connections on the listener and serves each one in its own
+// goroutine. Serve blocks until the listener fails or [Server.Close] is
+// called, it always returns a non-nil error.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+
+		return ErrServerClosed
+	}
+
+	s.listeners = append(s.listeners, l)
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+
+			if s.closed {
+				return ErrServerClosed
+			}
+
+			return err
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		go s.handle(conn)
+	}
+}
+
+// Close closes all listeners and all client connections.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+
+	var err error
+
+	for _, l := range s.listeners {
+		err = errors.Join(err, l.Close())
+	}
+
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+
+	return err
+}
+
+// handle serves a single client connection. The messages to the client are
+// written by a goroutine of its own, so a slow client doesn't block others.
+func (s *Server) handle(conn net.Conn) {
+	self := &member{
+		id:        0,
+		name:      "",
+		conn:      conn,
+		out:       make(chan Message, outboxSize),
+		cursor:    0,
+		hasCursor: false,
+	}
+	written := make(chan struct{})
+
+	go func() {
+		self.write()
+		close(written)
+	}()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+
+		close(self.out)
+		<-written
+
+		_ = conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(nil, maxMessageSize)
+
+	var msg Message
+	if !scanner.Scan() {
+		return
+	}
+
+	if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil || msg.Type != TypeJoin {
+		self.sendError(ErrNotJoined)
+
+		return
+	}
+
+	doc := s.join(msg, self)
+	defer s.leave(doc, self)
+
+	for scanner.Scan() {
+		msg = Message{} //nolint:exhaustruct // reset the message
+
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			self.sendError(err)
+
+			continue
+		}
+
+		s.receive(doc, self, msg)
+	}
+}
+
+// join adds the client to the document named in the join message and sends
+// the snapshot of the document to the client.
+func (s *Server) join(msg Message, self *member) *document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.docs[msg.Doc]
+	if !ok {
+		doc = &document{srv: ot.NewServer(""), members: map[int]*member{}}
+		s.docs[msg.Doc] = doc
+	}
+
+	s.lastID++
+	self.id, self.name = s.lastID, msg.Name
+	cursors := make([]Cursor, 0, len(doc.members))
+
+	for _, other := range doc.members {
+		if other.hasCursor {
+			cursors = append(cursors, Cursor{Client: other.id, Name: other.name, Offset: other.cursor})
+		}
+	}
+
+	doc.members[self.id] = self
+
+	self.enqueue(Message{ //nolint:exhaustruct // only the snapshot fields
+		Type:     TypeSnapshot,
+		Client:   self.id,
+		Revision: doc.srv.Revision(),
+		Text:     doc.srv.String(),
+		Cursors:  cursors,
+	})
+
+	return doc
+}
+
+// leave removes the client from the document and tells all other clients.
+func (s *Server) leave(doc *document, self *member) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(doc.members, self.id)
+	s.broadcast(doc, self, Message{Type: TypeLeave, Client: self.id}) //nolint:exhaustruct // only the leave fields
+}
+
+// receive handles a message of a client that joined the document.
+func (s *Server) receive(doc *document, self *member, msg Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch msg.Type {
+	case TypeOp:
+		if msg.Op == nil {
+			self.sendError(ot.ErrLengthMismatch)
+
+			return
+		}
+
+		op, err := doc.srv.Receive(msg.Revision, msg.Op)
+		if err != nil {
+			self.sendError(err)
+
+			return
+		}
+
+		for _, m := range doc.members {
+			m.cursor = op.TransformIndex(m.cursor)
+		}
+
+		self.enqueue(Message{Type: TypeAck, Revision: doc.srv.Revision()}) //nolint:exhaustruct // ack
+		s.broadcast(doc, self, Message{                                    //nolint:exhaustruct // op
+			Type:     TypeOp,
+			Client:   self.id,
+			Revision: doc.srv.Revision(),
+			Op:       op,
+		})
+
+	case TypeCursor:
+		ops, err := doc.srv.History(msg.Revision)
+		if err != nil {
+			self.sendError(err)
+
+			return
+		}
+
+		offset := msg.Offset
+		for _, op := range ops {
+			offset = op.TransformIndex(offset)
+		}
+
+		self.cursor = min(max(offset, 0), len(doc.srv.String()))
+		self.hasCursor = true
+
+		s.broadcast(doc, self, Message{ //nolint:exhaustruct // cursor
+			Type:     TypeCursor,
+			Client:   self.id,
+			Name:     self.name,
+			Revision: doc.srv.Revision(),
+			Offset:   self.cursor,
+		})
+
+	default:
+		self.sendError(ErrUnknownType)
+	}
+}
+
+// broadcast queues the message for all clients of the document but `self`.
+func (s *Server) broadcast(doc *document, self *member, msg Message) {
+	for id, m := range doc.members {
+		if id != self.id {
+			m.enqueue(msg)
+		}
+	}
+}
+
+// enqueue queues the message to be written to the client, without blocking.
+// A client whose queue is full is disconnected.
+func (m *member) enqueue(msg Message) {
+	select {
+	case m.out <- msg:
+	default:
+		_ = m.conn.Close()
+	}
+}
+
+// sendError queues an error message for the client.
+func (m *member) sendError(err error) {
+	m.enqueue(Message{Type: TypeError, Error: err.Error()}) //nolint:exhaustruct // error
+}
+
+// write writes the queued messages to the connection until the queue is
+// closed. A client that can't be written to is disconnected.
+func (m *member) write() {
+	enc := json.NewEncoder(m.conn)
+
+	for msg := range m.out {
+		_ = m.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+
+		if err := enc.Encode(msg); err != nil {
+			_ = m.conn.Close()
+		}
+	}
+}
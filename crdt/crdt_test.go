@@ -0,0 +1,355 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     crdt_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package crdt_test
+
+import (
+	"math/rand"
+	"testing"
+	"unicode/utf8"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/Release-Candidate/go-gap-buffer/crdt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The runes used to generate random texts.
+var alphabet = []rune("abc xyz\nä😀")
+
+func randomText(rnd *rand.Rand, maxLen int) string {
+	runes := make([]rune, 1+rnd.Intn(maxLen))
+	for idx := range runes {
+		runes[idx] = alphabet[rnd.Intn(len(alphabet))]
+	}
+
+	return string(runes)
+}
+
+// randomOffset returns a random byte offset at the start of a rune in `text`.
+func randomOffset(rnd *rand.Rand, text string) int {
+	n := rnd.Intn(utf8.RuneCountInString(text) + 1)
+	offset := 0
+
+	for ; n > 0; n-- {
+		_, d := utf8.DecodeRuneInString(text[offset:])
+		offset += d
+	}
+
+	return offset
+}
+
+// randomEdit does a random insertion or deletion at the replica.
+func randomEdit(t *testing.T, rnd *rand.Rand, replica *crdt.Replica) *crdt.Update {
+	t.Helper()
+
+	text := replica.Buffer().String()
+	from, to := randomOffset(rnd, text), randomOffset(rnd, text)
+
+	var (
+		upd *crdt.Update
+		err error
+	)
+
+	if rnd.Intn(3) == 0 {
+		upd, err = replica.Delete(from, to)
+	} else {
+		upd, err = replica.Insert(from, randomText(rnd, 3))
+	}
+
+	require.NoError(t, err)
+
+	return upd
+}
+
+// roundTrip encodes and decodes the update.
+func roundTrip(t *testing.T, upd *crdt.Update) crdt.Update {
+	t.Helper()
+
+	data, err := upd.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded crdt.Update
+	require.NoError(t, decoded.UnmarshalBinary(data))
+
+	return decoded
+}
+
+func TestConcurrentInsertsAtSamePosition(t *testing.T) {
+	t.Parallel()
+
+	alice := crdt.NewReplica(1, "ac")
+	bob := crdt.NewReplica(2, "ac")
+
+	updA, err := alice.Insert(1, "x")
+	require.NoError(t, err)
+	updB, err := bob.Insert(1, "b")
+	require.NoError(t, err)
+
+	require.NoError(t, alice.Apply(*updB))
+	require.NoError(t, bob.Apply(*updA))
+
+	assert.Equal(t, "abxc", alice.Buffer().String())
+	assert.Equal(t, "abxc", bob.Buffer().String())
+}
+
+func TestRemoteInsertKeepsCursor(t *testing.T) {
+	t.Parallel()
+
+	alice := crdt.NewReplica(1, "Hello World!")
+	bob := crdt.NewReplica(2, "Hello World!")
+	bob.Buffer().MoveTo(6)
+
+	upd, err := alice.Insert(0, ">> ")
+	require.NoError(t, err)
+	require.NoError(t, bob.Apply(*upd))
+
+	l, r := bob.Buffer().StringPair()
+	assert.Equal(t, ">> Hello ", l)
+	assert.Equal(t, "World!", r)
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	t.Parallel()
+
+	alice := crdt.NewReplica(1, "a\xe2\x82b")
+	bob := crdt.NewReplica(2, "a\xe2\x82b")
+
+	upd1, err := alice.Insert(1, "x\xffy")
+	require.NoError(t, err)
+	assert.Equal(t, "ax\xffy\xe2\x82b", alice.Buffer().String())
+
+	upd2, err := alice.Delete(3, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "ax\xff\xe2\x82b", alice.Buffer().String())
+
+	upd3, err := alice.Insert(alice.Buffer().StringLength(), "!")
+	require.NoError(t, err)
+	assert.Equal(t, "ax\xff\xe2\x82b!", alice.Buffer().String())
+
+	for _, upd := range []*crdt.Update{upd1, upd2, upd3} {
+		data, err := upd.MarshalBinary()
+		require.NoError(t, err)
+
+		var decoded crdt.Update
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		require.NoError(t, bob.Apply(decoded))
+	}
+
+	assert.Equal(t, alice.Buffer().String(), bob.Buffer().String())
+}
+
+func TestCausalDelivery(t *testing.T) {
+	t.Parallel()
+
+	alice := crdt.NewReplica(1, "")
+	bob := crdt.NewReplica(2, "")
+
+	upd1, err := alice.Insert(0, "Hello")
+	require.NoError(t, err)
+	upd2, err := alice.Delete(0, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, bob.Apply(*upd2))
+	assert.Equal(t, 1, bob.Pending(), "Waiting for the insertion")
+	assert.Equal(t, "", bob.Buffer().String())
+
+	require.NoError(t, bob.Apply(*upd1))
+	require.NoError(t, bob.Apply(*upd1))
+	assert.Equal(t, 0, bob.Pending(), "Nothing pending")
+	assert.Equal(t, "ello", bob.Buffer().String())
+}
+
+func TestEncodingIsDeterministic(t *testing.T) {
+	t.Parallel()
+
+	upd := crdt.Update{
+		Kind:    crdt.DeleteUpdate,
+		Site:    3,
+		Seq:     7,
+		Deps:    crdt.VersionVector{5: 1, 1: 300, 3: 6},
+		ID:      crdt.ID{Clock: 0, Site: 0},
+		Origin:  crdt.ID{Clock: 0, Site: 0},
+		Text:    "",
+		Targets: []crdt.ID{{Clock: 12, Site: 1}, {Clock: 1 << 40, Site: 5}},
+	}
+
+	data1, err := upd.MarshalBinary()
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		data2, err := upd.MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, data1, data2)
+	}
+
+	assert.Equal(t, upd, roundTrip(t, &upd))
+
+	var decoded crdt.Update
+	require.ErrorIs(t, decoded.UnmarshalBinary(data1[:len(data1)-1]), crdt.ErrInvalidUpdate)
+	require.ErrorIs(t, decoded.UnmarshalBinary(append(data1, 0)), crdt.ErrInvalidUpdate)
+}
+
+func TestReplicasConverge(t *testing.T) {
+	t.Parallel()
+
+	for seed := int64(0); seed < 30; seed++ {
+		rnd := rand.New(rand.NewSource(seed)) //nolint:gosec // deterministic tests
+		text := randomText(rnd, 20)
+		replicas := make([]*crdt.Replica, 3+rnd.Intn(6))
+
+		for idx := range replicas {
+			replicas[idx] = crdt.NewReplica(crdt.SiteID(idx+1), text)
+			for peer := range replicas {
+				replicas[idx].AddPeer(crdt.SiteID(peer + 1))
+			}
+		}
+
+		// Every replica receives every update, in random order and some of them
+		// twice.
+		inboxes := make([][]crdt.Update, len(replicas))
+
+		for step := 0; step < 400; step++ {
+			idx := rnd.Intn(len(replicas))
+
+			switch rnd.Intn(3) {
+			case 0:
+				if upd := randomEdit(t, rnd, replicas[idx]); upd != nil {
+					for other := range replicas {
+						if other != idx {
+							inboxes[other] = append(inboxes[other], roundTrip(t, upd))
+						}
+					}
+				}
+			case 1:
+				replicas[idx].GC()
+			default:
+				if len(inboxes[idx]) == 0 {
+					continue
+				}
+
+				pick := rnd.Intn(len(inboxes[idx]))
+				require.NoError(t, replicas[idx].Apply(inboxes[idx][pick]))
+
+				if rnd.Intn(5) > 0 {
+					inboxes[idx] = append(inboxes[idx][:pick], inboxes[idx][pick+1:]...)
+				}
+			}
+		}
+
+		for idx, replica := range replicas {
+			for _, upd := range inboxes[idx] {
+				require.NoError(t, replica.Apply(upd))
+			}
+
+			require.Equal(t, 0, replica.Pending(), "Seed %d, replica %d", seed, idx)
+		}
+
+		for idx, replica := range replicas {
+			require.Equal(t, replicas[0].Buffer().String(), replica.Buffer().String(), "Seed %d, replica %d", seed, idx)
+		}
+	}
+}
+
+func TestGCRemovesStableTombstones(t *testing.T) {
+	t.Parallel()
+
+	alice := crdt.NewReplica(1, "Hello World!")
+	bob := crdt.NewReplica(2, "Hello World!")
+	alice.AddPeer(2)
+	bob.AddPeer(1)
+
+	del, err := alice.Delete(5, 11)
+	require.NoError(t, err)
+	assert.Equal(t, 0, alice.GC(), "Bob hasn't seen the deletion")
+
+	require.NoError(t, bob.Apply(*del))
+
+	ins, err := bob.Insert(5, ", Bob")
+	require.NoError(t, err)
+	require.NoError(t, alice.Apply(*ins))
+
+	assert.Equal(t, 6, alice.GC(), "Bob has seen the deletion")
+	assert.Equal(t, 0, alice.Tombstones(), "Tombstones")
+	assert.Equal(t, "Hello, Bob!", alice.Buffer().String())
+	assert.Equal(t, "Hello, Bob!", bob.Buffer().String())
+
+	upd, err := alice.Insert(11, "?")
+	require.NoError(t, err)
+	require.NoError(t, bob.Apply(*upd))
+	assert.Equal(t, "Hello, Bob!?", bob.Buffer().String())
+}
+
+func TestRejectInvalidUTF8(t *testing.T) {
+	t.Parallel()
+
+	alice := crdt.NewReplica(1, "ab")
+	bob := crdt.NewReplica(2, "ab")
+	alice.AddPeer(2)
+	bob.AddPeer(1)
+	bob.Buffer().SetInvalidUTF8Policy(gapbuffer.RejectInvalidUTF8)
+	bob.Buffer().MoveTo(2)
+
+	upd1, err := alice.Insert(1, "\xfc")
+	require.NoError(t, err)
+	upd2, err := alice.Insert(2, "x")
+	require.NoError(t, err)
+	upd3, err := alice.Delete(0, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, bob.Apply(*upd3))
+	require.NoError(t, bob.Apply(*upd2))
+	assert.Equal(t, 2, bob.Pending(), "Waiting for the insertion")
+
+	require.ErrorIs(t, bob.Apply(*upd1), gapbuffer.ErrInvalidUTF8)
+	assert.Equal(t, 0, bob.Pending(), "Rejected insertion integrated")
+	assert.Equal(t, alice.Version(), bob.Version())
+	assert.Equal(t, "xb", bob.Buffer().String(), "Without the rejected text")
+	assert.Equal(t, 2, bob.Buffer().Offset(), "Cursor")
+	assert.Equal(t, 2, bob.Tombstones(), "Deleted and rejected")
+	require.NoError(t, bob.Apply(*upd1), "Duplicate")
+
+	upd4, err := alice.Delete(1, 2)
+	require.NoError(t, err)
+	require.NoError(t, bob.Apply(*upd4))
+	assert.Equal(t, "b", bob.Buffer().String())
+
+	upd5, err := bob.Insert(0, "y")
+	require.NoError(t, err)
+	require.NoError(t, alice.Apply(*upd5))
+	bob.GC()
+	assert.Equal(t, 1, bob.Tombstones(), "Rejected text is never removed")
+
+	upd6, err := alice.Insert(2, "z")
+	require.NoError(t, err)
+	require.NoError(t, bob.Apply(*upd6), "Insertion after the rejected text")
+	assert.Equal(t, "yzb", bob.Buffer().String())
+	assert.Equal(t, "y\xfczb", alice.Buffer().String())
+
+	_, err = bob.Insert(1, "\xfc")
+	require.ErrorIs(t, err, gapbuffer.ErrInvalidUTF8)
+	assert.Equal(t, "yzb", bob.Buffer().String())
+}
+
+func TestReplaceInvalidUTF8(t *testing.T) {
+	t.Parallel()
+
+	alice := crdt.NewReplica(1, "ab")
+	bob := crdt.NewReplica(2, "ab")
+	bob.Buffer().SetInvalidUTF8Policy(gapbuffer.ReplaceInvalidUTF8)
+
+	upd, err := alice.Insert(1, "\xfc")
+	require.NoError(t, err)
+	require.ErrorIs(t, bob.Apply(*upd), gapbuffer.ErrInvalidUTF8)
+	assert.Equal(t, "ab", bob.Buffer().String())
+
+	_, err = bob.Insert(1, "\xfc")
+	require.ErrorIs(t, err, gapbuffer.ErrInvalidUTF8)
+	assert.Equal(t, "ab", bob.Buffer().String())
+}
@@ -0,0 +1,570 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     replica.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+// Package crdt implements a replicated growable array (RGA), a sequence CRDT
+// (conflict-free replicated data type) for peer-to-peer collaborative editing.
+// Each [Replica] keeps the text of the document in a [gapbuffer.GapBuffer], the
+// CRDT only translates remote [Update]s to local edits of the gap buffer.
+//
+// Every unicode rune of the document, and every byte of invalid UTF-8, is an
+// element with a unique [ID]. An insertion references the element it is
+// inserted after, its origin. Concurrent insertions after the same origin are
+// ordered by their IDs, the greater ID first. Deleted elements are kept as
+// tombstones, so they can still be referenced by concurrent insertions, until
+// [Replica.GC] removes them.
+//
+// Updates are delivered causally: an update whose dependencies haven't been
+// integrated yet is kept pending until they are. Updates can be delivered in
+// any order and more than once.
+//
+// Warning: this implementation keeps the elements in a slice and searches it
+// linearly, it is not meant for big documents.
+package crdt
+
+import (
+	"errors"
+	"unicode/utf8"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+)
+
+var (
+	// ErrInvalidOffset is returned if an offset is not at the start of a rune
+	// in the document.
+	ErrInvalidOffset = errors.New("offset is not at a rune in the document")
+
+	// ErrUnknownOrigin is returned if the origin of a delivered insertion does
+	// not exist.
+	ErrUnknownOrigin = errors.New("unknown origin of insertion")
+)
+
+// element is a single unicode rune of the document.
+type element struct {
+	// The unique ID of the element.
+	id ID
+
+	// The sequence number of the update that inserted the element.
+	seq uint64
+
+	// The rune as UTF-8.
+	text string
+
+	// True if the element has been deleted and is a tombstone.
+	deleted bool
+
+	// The site and sequence number of the update that deleted the element.
+	delSite SiteID
+	delSeq  uint64
+
+	// True if the insertion of the element has been rejected because of
+	// invalid UTF-8. It is a tombstone which is never removed, as the element
+	// is visible at other replicas.
+	rejected bool
+}
+
+// Replica is a replica of a document at a single site.
+type Replica struct {
+	// The site ID of the replica.
+	site SiteID
+
+	// The Lamport clock, the greatest clock value of all known elements.
+	clock uint64
+
+	// The updates integrated by this replica.
+	version VersionVector
+
+	// The updates each other site had integrated when it generated its last
+	// update delivered to this replica.
+	known map[SiteID]VersionVector
+
+	// All elements of the document, including tombstones.
+	elems []element
+
+	// Delivered updates waiting for their dependencies.
+	pending []Update
+
+	// The visible text of the document.
+	buf *gapbuffer.GapBuffer
+}
+
+// NewReplica returns a new replica with the given site ID. All replicas of a
+// document must be created with the same initial text, the runes of the
+// initial text are owned by the reserved site 0.
+func NewReplica(site SiteID, text string) *Replica {
+	r := &Replica{
+		site:    site,
+		clock:   0,
+		version: VersionVector{},
+		known:   map[SiteID]VersionVector{},
+		elems:   make([]element, 0, len(text)),
+		pending: nil,
+		buf:     gapbuffer.NewStr(text),
+	}
+
+	for _, char := range splitRunes(text) {
+		r.clock++
+		r.elems = append(r.elems, element{
+			id:       ID{Clock: r.clock, Site: 0},
+			seq:      0,
+			text:     char,
+			deleted:  false,
+			delSite:  0,
+			delSeq:   0,
+			rejected: false,
+		})
+	}
+
+	return r
+}
+
+// Return the site ID of the replica.
+func (r *Replica) Site() SiteID {
+	return r.site
+}
+
+// Return the gap buffer holding the text of the document.
+//
+// Warning: do not change the text of the gap buffer directly, use
+// [Replica.Insert] and [Replica.Delete]. Moving the cursor is fine.
+func (r *Replica) Buffer() *gapbuffer.GapBuffer {
+	return r.buf
+}
+
+// Return a copy of the version vector of the updates integrated by the replica.
+func (r *Replica) Version() VersionVector {
+	return r.version.clone()
+}
+
+// Return the number of delivered updates waiting for their dependencies.
+func (r *Replica) Pending() int {
+	return len(r.pending)
+}
+
+// Return the number of tombstones, deleted elements kept for concurrent
+// insertions.
+func (r *Replica) Tombstones() int {
+	n := 0
+
+	for idx := range r.elems {
+		if r.elems[idx].deleted {
+			n++
+		}
+	}
+
+	return n
+}
+
+// AddPeer adds a site to the sites taking part in the editing session. Sites
+// are added automatically when the first update of them is delivered, but
+// [Replica.GC] must know all sites, including those which haven't sent any
+// update yet.
+func (r *Replica) AddPeer(site SiteID) {
+	if _, ok := r.known[site]; !ok && site != r.site {
+		r.known[site] = VersionVector{}
+	}
+}
+
+// Insert inserts the text at the given byte offset of the document and returns
+// the update to deliver to all other replicas. The cursor of the gap buffer is
+// moved to the end of the inserted text.
+//
+// Returns nil if the text is empty, [ErrInvalidOffset] if the offset is not at
+// the start of a rune and [gapbuffer.ErrInvalidUTF8] if the text is not valid
+// UTF-8 and the [gapbuffer.InvalidUTF8Policy] of the gap buffer isn't
+// [gapbuffer.KeepInvalidUTF8]. Replacing invalid UTF-8 would change the text
+// to another one than that of the other replicas.
+func (r *Replica) Insert(offset int, text string) (*Update, error) {
+	idx, err := r.elemIndex(offset)
+	if err != nil || text == "" {
+		return nil, err
+	}
+
+	origin := ID{Clock: 0, Site: 0}
+
+	// Use the visible element to the left as the origin, skipping tombstones.
+	for idx--; idx >= 0; idx-- {
+		if !r.elems[idx].deleted {
+			origin = r.elems[idx].id
+
+			break
+		}
+	}
+
+	upd := r.newUpdate(InsertUpdate)
+	upd.ID = ID{Clock: r.clock + 1, Site: r.site}
+	upd.Origin = origin
+	upd.Text = text
+
+	if err = r.integrate(upd, true); err != nil {
+		return nil, err
+	}
+
+	return &upd, nil
+}
+
+// Delete deletes the text between the byte offsets `from` (inclusive) and `to`
+// (exclusive) and returns the update to deliver to all other replicas. The
+// cursor of the gap buffer is moved to `from`.
+//
+// Returns nil if the range is empty and [ErrInvalidOffset] if an offset is not
+// at the start of a rune.
+func (r *Replica) Delete(from int, to int) (*Update, error) {
+	if to < from {
+		from, to = to, from
+	}
+
+	fromIdx, err := r.elemIndex(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toIdx, err := r.elemIndex(to)
+	if err != nil || from == to {
+		return nil, err
+	}
+
+	upd := r.newUpdate(DeleteUpdate)
+
+	for idx := fromIdx; idx < toIdx; idx++ {
+		if !r.elems[idx].deleted {
+			upd.Targets = append(upd.Targets, r.elems[idx].id)
+		}
+	}
+
+	if err = r.integrate(upd, true); err != nil {
+		return nil, err
+	}
+
+	return &upd, nil
+}
+
+// Apply delivers an update of another replica. The update is integrated as
+// soon as all its dependencies have been integrated, until then it is kept
+// pending. Updates that have already been integrated are ignored. The cursor
+// of the gap buffer is kept at its position in the text.
+//
+// Returns [ErrUnknownOrigin] if the origin of an insertion does not exist, the
+// update is kept pending then.
+//
+// Returns [gapbuffer.ErrInvalidUTF8] if the text of an insertion is not valid
+// UTF-8 and the [gapbuffer.InvalidUTF8Policy] of the gap buffer isn't
+// [gapbuffer.KeepInvalidUTF8]. The insertion is integrated as deleted text,
+// which is never removed by [Replica.GC]: the text of the gap buffer lacks the
+// inserted text, but later updates which depend on the insertion are
+// integrated as usual. The text of the replica differs from the text of
+// replicas which kept the invalid UTF-8 from then on.
+func (r *Replica) Apply(upd Update) error {
+	if r.version.Includes(upd.Site, upd.Seq) {
+		return nil
+	}
+
+	r.AddPeer(upd.Site)
+	r.pending = append(r.pending, upd)
+
+	var rejected error
+
+	for progress := true; progress; {
+		progress = false
+
+		for idx := 0; idx < len(r.pending); idx++ {
+			cur := r.pending[idx]
+
+			switch {
+			case r.version.Includes(cur.Site, cur.Seq):
+				// A duplicate.
+			case r.deliverable(cur):
+				err := r.integrate(cur, false)
+
+				switch {
+				case errors.Is(err, gapbuffer.ErrInvalidUTF8):
+					rejected = err
+				case err != nil:
+					return err
+				}
+			default:
+				continue
+			}
+
+			r.pending = append(r.pending[:idx], r.pending[idx+1:]...)
+			idx--
+			progress = true
+		}
+	}
+
+	return rejected
+}
+
+// GC removes tombstones that can't be referenced by any update that hasn't
+// been integrated yet and returns the number of removed tombstones.
+//
+// A tombstone is removed, if all known sites have integrated its deletion and
+// the insertion of the element to its right. This is only safe if all sites
+// editing the document are known, see [Replica.AddPeer].
+func (r *Replica) GC() int {
+	removed := 0
+
+	for idx := len(r.elems) - 1; idx >= 0; idx-- {
+		elem := &r.elems[idx]
+		if !elem.deleted || elem.rejected || !r.stable(elem.delSite, elem.delSeq) {
+			continue
+		}
+
+		if idx+1 < len(r.elems) && !r.stable(r.elems[idx+1].id.Site, r.elems[idx+1].seq) {
+			continue
+		}
+
+		r.elems = append(r.elems[:idx], r.elems[idx+1:]...)
+		removed++
+	}
+
+	return removed
+}
+
+// stable returns true if the update with the given site and sequence number
+// has been integrated by this and all other known sites.
+func (r *Replica) stable(site SiteID, seq uint64) bool {
+	if !r.version.Includes(site, seq) {
+		return false
+	}
+
+	for _, version := range r.known {
+		if !version.Includes(site, seq) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newUpdate returns a new local update of the given kind.
+func (r *Replica) newUpdate(kind UpdateKind) Update {
+	return Update{
+		Kind:    kind,
+		Site:    r.site,
+		Seq:     r.version[r.site] + 1,
+		Deps:    r.version.clone(),
+		ID:      ID{Clock: 0, Site: 0},
+		Origin:  ID{Clock: 0, Site: 0},
+		Text:    "",
+		Targets: nil,
+	}
+}
+
+// deliverable returns true if all dependencies of the update have been
+// integrated.
+func (r *Replica) deliverable(upd Update) bool {
+	if r.version[upd.Site] != upd.Seq-1 {
+		return false
+	}
+
+	for site, seq := range upd.Deps {
+		if site != upd.Site && !r.version.Includes(site, seq) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// integrate integrates the update into the elements and the gap buffer. If the
+// update is local, the cursor ends at the change, else it keeps its position.
+//
+// A remote insertion of invalid UTF-8 which isn't kept by the
+// [gapbuffer.InvalidUTF8Policy] is integrated without changing the gap buffer
+// and returns [gapbuffer.ErrInvalidUTF8], a local one is not integrated.
+func (r *Replica) integrate(upd Update, local bool) error {
+	cursor := r.buf.Offset()
+
+	var rejected error
+
+	switch upd.Kind {
+	case InsertUpdate:
+		if r.buf.InvalidUTF8Policy() != gapbuffer.KeepInvalidUTF8 && !utf8.ValidString(upd.Text) {
+			if local {
+				return gapbuffer.ErrInvalidUTF8
+			}
+
+			rejected = gapbuffer.ErrInvalidUTF8
+		}
+
+		offset, err := r.integrateInsert(upd, rejected != nil)
+		if err != nil {
+			return err
+		}
+
+		if rejected != nil {
+			break
+		}
+
+		r.buf.ReplaceRange(offset, offset, upd.Text)
+
+		if !local && offset <= cursor {
+			cursor += len(upd.Text)
+		}
+
+	case DeleteUpdate:
+		for _, target := range upd.Targets {
+			offset, size := r.integrateDelete(upd, target)
+			if size == 0 {
+				continue
+			}
+
+			r.buf.DeleteRange(offset, offset+size)
+
+			if offset < cursor {
+				cursor -= min(size, cursor-offset)
+			}
+		}
+	}
+
+	if !local {
+		r.buf.MoveTo(cursor)
+
+		deps := upd.Deps.clone()
+		deps[upd.Site] = upd.Seq
+		r.known[upd.Site] = deps
+	}
+
+	r.version[upd.Site] = upd.Seq
+
+	return rejected
+}
+
+// integrateInsert inserts the elements of the update and returns the byte
+// offset in the visible text of the first new element. The elements of a
+// rejected insertion are inserted as tombstones.
+func (r *Replica) integrateInsert(upd Update, rejected bool) (int, error) {
+	idx := 0
+
+	if upd.Origin.Clock != 0 {
+		idx = r.findIndex(upd.Origin) + 1
+		if idx == 0 {
+			return 0, ErrUnknownOrigin
+		}
+	}
+
+	newID := upd.ID
+
+	// Skip concurrent insertions after the same origin with a greater ID and
+	// their successors.
+	for idx < len(r.elems) && newID.Less(r.elems[idx].id) {
+		idx++
+	}
+
+	offset := r.visibleOffset(idx)
+	elems := make([]element, 0, len(upd.Text))
+
+	for _, char := range splitRunes(upd.Text) {
+		elems = append(elems, element{
+			id:       newID,
+			seq:      upd.Seq,
+			text:     char,
+			deleted:  rejected,
+			delSite:  0,
+			delSeq:   0,
+			rejected: rejected,
+		})
+		newID.Clock++
+	}
+
+	r.elems = append(r.elems[:idx], append(elems, r.elems[idx:]...)...)
+	r.clock = max(r.clock, newID.Clock-1)
+
+	return offset, nil
+}
+
+// integrateDelete marks the target element as deleted and returns its byte
+// offset in the visible text and its size in bytes. The size is 0 if the
+// element is already deleted or has been removed by [Replica.GC].
+func (r *Replica) integrateDelete(upd Update, target ID) (offset int, size int) {
+	idx := r.findIndex(target)
+	if idx < 0 || r.elems[idx].deleted {
+		return 0, 0
+	}
+
+	elem := &r.elems[idx]
+	elem.deleted = true
+	elem.delSite = upd.Site
+	elem.delSeq = upd.Seq
+
+	return r.visibleOffset(idx), len(elem.text)
+}
+
+// findIndex returns the index of the element with the given ID or -1.
+func (r *Replica) findIndex(id ID) int {
+	for idx := range r.elems {
+		if r.elems[idx].id == id {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// visibleOffset returns the byte offset in the visible text of the element
+// with the given index.
+func (r *Replica) visibleOffset(idx int) int {
+	offset := 0
+
+	for i := 0; i < idx; i++ {
+		if !r.elems[i].deleted {
+			offset += len(r.elems[i].text)
+		}
+	}
+
+	return offset
+}
+
+// elemIndex returns the index of the first element at or after the given byte
+// offset in the visible text. Returns [ErrInvalidOffset] if the offset is not
+// at the start of a rune.
+func (r *Replica) elemIndex(offset int) (int, error) {
+	if offset < 0 {
+		return 0, ErrInvalidOffset
+	}
+
+	pos := 0
+
+	for idx := range r.elems {
+		if r.elems[idx].deleted {
+			continue
+		}
+
+		if pos == offset {
+			return idx, nil
+		}
+
+		if pos > offset {
+			return 0, ErrInvalidOffset
+		}
+
+		pos += len(r.elems[idx].text)
+	}
+
+	if pos != offset {
+		return 0, ErrInvalidOffset
+	}
+
+	return len(r.elems), nil
+}
+
+// splitRunes returns the text split into runes. Every byte of invalid UTF-8
+// is a rune of its own and kept as is, so the byte offsets of the elements
+// match the text of the gap buffer.
+func splitRunes(text string) []string {
+	runes := make([]string, 0, len(text))
+
+	for idx := 0; idx < len(text); {
+		_, size := utf8.DecodeRuneInString(text[idx:])
+		runes = append(runes, text[idx:idx+size])
+		idx += size
+	}
+
+	return runes
+}
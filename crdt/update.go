@@ -0,0 +1,261 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     update.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package crdt
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+// SiteID identifies a replica. Every replica editing a document must have a
+// unique site ID. Site 0 is reserved for the initial text of a document.
+type SiteID uint32
+
+// ID is the unique identifier of a single unicode rune - an element - of the
+// document. It consists of the Lamport clock of the replica that inserted the
+// element and the site ID of that replica.
+type ID struct {
+	Clock uint64
+	Site  SiteID
+}
+
+// Less returns true if the ID `i` is ordered before `other`: first by clock,
+// then by site.
+func (i ID) Less(other ID) bool {
+	if i.Clock != other.Clock {
+		return i.Clock < other.Clock
+	}
+
+	return i.Site < other.Site
+}
+
+// VersionVector maps each site to the number of updates of that site a replica
+// has integrated.
+type VersionVector map[SiteID]uint64
+
+// Includes returns true if the update with the given site and sequence number
+// is part of the version vector.
+func (v VersionVector) Includes(site SiteID, seq uint64) bool {
+	return v[site] >= seq
+}
+
+// clone returns a copy of the version vector.
+func (v VersionVector) clone() VersionVector {
+	c := make(VersionVector, len(v))
+	for site, seq := range v {
+		c[site] = seq
+	}
+
+	return c
+}
+
+// UpdateKind is the kind of an [Update].
+type UpdateKind uint8
+
+const (
+	// InsertUpdate inserts [Update.Text] after the element [Update.Origin].
+	InsertUpdate UpdateKind = iota + 1
+
+	// DeleteUpdate deletes the elements in [Update.Targets].
+	DeleteUpdate
+)
+
+// Update is a change of the document generated by a replica, to be delivered
+// to all other replicas.
+type Update struct {
+	// The kind of the update.
+	Kind UpdateKind
+
+	// The site of the replica that generated the update.
+	Site SiteID
+
+	// The sequence number of the update at its site, starting from 1.
+	Seq uint64
+
+	// The version vector of the generating replica before the update. The
+	// update is delivered only after all of these updates.
+	Deps VersionVector
+
+	// The ID of the first inserted rune, the following runes have the
+	// following clock values. Unused by [DeleteUpdate].
+	ID ID
+
+	// The ID of the element the text is inserted after, the zero ID for the
+	// start of the document. Unused by [DeleteUpdate].
+	Origin ID
+
+	// The inserted text. Unused by [DeleteUpdate].
+	Text string
+
+	// The IDs of the deleted elements. Unused by [InsertUpdate].
+	Targets []ID
+}
+
+// ErrInvalidUpdate is returned when decoding a malformed binary update.
+var ErrInvalidUpdate = errors.New("invalid binary update")
+
+// MarshalBinary encodes the update. The encoding is deterministic, equal
+// updates are always encoded to the same bytes.
+//
+// The layout is a sequence of unsigned varints, strings are prefixed by their
+// length:
+//
+//	kind site seq len(deps) (site seq)...
+//	insert: id.clock id.site origin.clock origin.site len(text) text
+//	delete: len(targets) (clock site)...
+//
+// The dependencies are sorted by site.
+func (u *Update) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, binary.MaxVarintLen64*(8+2*len(u.Deps)+2*len(u.Targets))+len(u.Text)) //nolint:gomnd // fields
+	buf = binary.AppendUvarint(buf, uint64(u.Kind))
+	buf = binary.AppendUvarint(buf, uint64(u.Site))
+	buf = binary.AppendUvarint(buf, u.Seq)
+
+	sites := make([]SiteID, 0, len(u.Deps))
+	for site := range u.Deps {
+		sites = append(sites, site)
+	}
+
+	sort.Slice(sites, func(i, j int) bool { return sites[i] < sites[j] })
+
+	buf = binary.AppendUvarint(buf, uint64(len(sites)))
+	for _, site := range sites {
+		buf = binary.AppendUvarint(buf, uint64(site))
+		buf = binary.AppendUvarint(buf, u.Deps[site])
+	}
+
+	switch u.Kind {
+	case InsertUpdate:
+		buf = appendID(buf, u.ID)
+		buf = appendID(buf, u.Origin)
+		buf = binary.AppendUvarint(buf, uint64(len(u.Text)))
+		buf = append(buf, u.Text...)
+
+	case DeleteUpdate:
+		buf = binary.AppendUvarint(buf, uint64(len(u.Targets)))
+		for _, target := range u.Targets {
+			buf = appendID(buf, target)
+		}
+
+	default:
+		return nil, ErrInvalidUpdate
+	}
+
+	return buf, nil
+}
+
+// UnmarshalBinary decodes an update encoded by [Update.MarshalBinary].
+//
+// Returns [ErrInvalidUpdate] if the data is malformed.
+func (u *Update) UnmarshalBinary(data []byte) error {
+	dec := decoder{data: data, err: nil}
+	res := Update{
+		Kind:    UpdateKind(dec.uvarint()),
+		Site:    SiteID(dec.uvarint()),
+		Seq:     dec.uvarint(),
+		Deps:    VersionVector{},
+		ID:      ID{Clock: 0, Site: 0},
+		Origin:  ID{Clock: 0, Site: 0},
+		Text:    "",
+		Targets: nil,
+	}
+
+	for n := dec.length(); n > 0; n-- {
+		site := SiteID(dec.uvarint())
+		res.Deps[site] = dec.uvarint()
+	}
+
+	switch res.Kind {
+	case InsertUpdate:
+		res.ID = dec.id()
+		res.Origin = dec.id()
+		res.Text = string(dec.bytes(dec.length()))
+
+	case DeleteUpdate:
+		n := dec.length()
+		res.Targets = make([]ID, 0, n)
+
+		for ; n > 0; n-- {
+			res.Targets = append(res.Targets, dec.id())
+		}
+
+	default:
+		return ErrInvalidUpdate
+	}
+
+	if dec.err != nil || len(dec.data) > 0 {
+		return ErrInvalidUpdate
+	}
+
+	*u = res
+
+	return nil
+}
+
+// appendID appends the varint encoding of the ID to the buffer.
+func appendID(buf []byte, id ID) []byte {
+	buf = binary.AppendUvarint(buf, id.Clock)
+
+	return binary.AppendUvarint(buf, uint64(id.Site))
+}
+
+// decoder reads varints from a byte slice and remembers the first error.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+// uvarint reads an unsigned varint.
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+
+	val, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = ErrInvalidUpdate
+
+		return 0
+	}
+
+	d.data = d.data[n:]
+
+	return val
+}
+
+// length reads a length, which can't be larger than the remaining data.
+func (d *decoder) length() int {
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		d.err = ErrInvalidUpdate
+
+		return 0
+	}
+
+	return int(n)
+}
+
+// id reads an ID.
+func (d *decoder) id() ID {
+	return ID{Clock: d.uvarint(), Site: SiteID(d.uvarint())}
+}
+
+// bytes reads `n` bytes.
+func (d *decoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	b := d.data[:n]
+	d.data = d.data[n:]
+
+	return b
+}
@@ -0,0 +1,326 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     cursors.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import "slices"
+
+// cursorSet holds the additional cursors of a gap buffer, see
+// [GapBuffer.AddCursor].
+type cursorSet struct {
+	// The additional cursors sorted by offset, without the cursor of the gap
+	// buffer.
+	cursors []cursorState
+
+	// True while an edit is applied at all cursors, the offsets are updated by
+	// the batch itself.
+	batch bool
+
+	// Removes the [EditFunc] of the cursors from the gap buffer.
+	remove func()
+}
+
+// cursorState is the position of a cursor.
+type cursorState struct {
+	// The byte offset of the cursor.
+	offset int
+
+	// The rune column the cursor wants to hold when going up or down, like
+	// `GapBuffer.wantsCol`.
+	wantsCol int
+
+	// True for the cursor of the gap buffer, the primary cursor.
+	primary bool
+}
+
+// AddCursor adds an additional cursor at the given byte offset, the offset is
+// clamped to the text like [GapBuffer.MoveTo] does. Nothing happens if there
+// already is a cursor at the offset.
+//
+// The cursor of the gap buffer, which is moved by [GapBuffer.LeftMv],
+// [GapBuffer.MoveTo] and so on, is the primary cursor. The additional cursors
+// move along with the text when it is changed, like [Marker]s, and are moved
+// and edit the text with the "All" methods, like [GapBuffer.InsertAll] and
+// [GapBuffer.LeftMvAll]. Cursors at the same offset are merged into one.
+//
+// See also [GapBuffer.RemoveCursor], [GapBuffer.ClearCursors],
+// [GapBuffer.Cursors].
+func (g *GapBuffer) AddCursor(offset int) {
+	offset = g.clamp(offset)
+	if offset == g.cursor {
+		return
+	}
+
+	if g.cursors == nil {
+		g.cursors = &cursorSet{cursors: nil, batch: false, remove: nil}
+		g.cursors.remove = g.AddEditFunc(g.moveCursors)
+	}
+
+	idx, found := slices.BinarySearchFunc(g.cursors.cursors, offset, func(c cursorState, o int) int {
+		return c.offset - o
+	})
+	if found {
+		return
+	}
+
+	// The rune column, without moving the line buffer.
+	lineStart := offset
+	for lineStart > 0 && g.byteAt(lineStart-1) != '\n' {
+		lineStart--
+	}
+
+	g.cursors.cursors = slices.Insert(g.cursors.cursors, idx,
+		cursorState{offset: offset, wantsCol: g.runeCount(lineStart, offset), primary: false})
+}
+
+// RemoveCursor removes the additional cursor at the given byte offset. Returns
+// false, if there is no additional cursor at the offset. The primary cursor
+// can't be removed.
+//
+// See also [GapBuffer.AddCursor], [GapBuffer.ClearCursors].
+func (g *GapBuffer) RemoveCursor(offset int) bool {
+	if g.cursors == nil {
+		return false
+	}
+
+	for idx, c := range g.cursors.cursors {
+		if c.offset == offset {
+			g.cursors.cursors = slices.Delete(g.cursors.cursors, idx, idx+1)
+
+			if len(g.cursors.cursors) == 0 {
+				g.ClearCursors()
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClearCursors removes all additional cursors, only the primary cursor is
+// left.
+//
+// See also [GapBuffer.AddCursor], [GapBuffer.RemoveCursor].
+func (g *GapBuffer) ClearCursors() {
+	if g.cursors == nil {
+		return
+	}
+
+	g.cursors.remove()
+	g.cursors = nil
+}
+
+// Return the byte offsets of all cursors, including the primary cursor, in
+// ascending order.
+//
+// See also [GapBuffer.AddCursor], [GapBuffer.Offset].
+func (g *GapBuffer) Cursors() []int {
+	all := g.allCursors()
+	offsets := make([]int, 0, len(all))
+
+	for _, c := range all {
+		offsets = append(offsets, c.offset)
+	}
+
+	return offsets
+}
+
+// InsertAll inserts the string at every cursor, see [GapBuffer.Insert]. Each
+// cursor is moved to the end of its inserted text.
+//
+// The text is changed in one pass from the first to the last cursor, so the
+// gap moves through the text once.
+//
+// See also [GapBuffer.AddCursor], [GapBuffer.LeftDelAll],
+// [GapBuffer.RightDelAll].
+func (g *GapBuffer) InsertAll(str string) {
+	g.eachCursor(func() { g.Insert(str) })
+}
+
+// LeftDelAll deletes the unicode rune to the left of every cursor, see
+// [GapBuffer.LeftDel].
+//
+// See also [GapBuffer.InsertAll], [GapBuffer.RightDelAll].
+func (g *GapBuffer) LeftDelAll() {
+	g.eachCursor(g.LeftDel)
+}
+
+// RightDelAll deletes the unicode rune to the right of every cursor, see
+// [GapBuffer.RightDel].
+//
+// See also [GapBuffer.InsertAll], [GapBuffer.LeftDelAll].
+func (g *GapBuffer) RightDelAll() {
+	g.eachCursor(g.RightDel)
+}
+
+// LeftMvAll moves every cursor one unicode rune to the left, see
+// [GapBuffer.LeftMv].
+//
+// See also [GapBuffer.RightMvAll], [GapBuffer.UpMvAll],
+// [GapBuffer.DownMvAll].
+func (g *GapBuffer) LeftMvAll() {
+	g.eachCursor(g.LeftMv)
+}
+
+// RightMvAll moves every cursor one unicode rune to the right, see
+// [GapBuffer.RightMv].
+//
+// See also [GapBuffer.LeftMvAll], [GapBuffer.UpMvAll],
+// [GapBuffer.DownMvAll].
+func (g *GapBuffer) RightMvAll() {
+	g.eachCursor(g.RightMv)
+}
+
+// UpMvAll moves every cursor up one line, see [GapBuffer.UpMv]. Every cursor
+// tries to hold its own column.
+//
+// See also [GapBuffer.DownMvAll], [GapBuffer.LeftMvAll],
+// [GapBuffer.RightMvAll].
+func (g *GapBuffer) UpMvAll() {
+	g.eachCursor(g.UpMv)
+}
+
+// DownMvAll moves every cursor down one line, see [GapBuffer.DownMv]. Every
+// cursor tries to hold its own column.
+//
+// See also [GapBuffer.UpMvAll], [GapBuffer.LeftMvAll],
+// [GapBuffer.RightMvAll].
+func (g *GapBuffer) DownMvAll() {
+	g.eachCursor(g.DownMv)
+}
+
+// allCursors returns all cursors including the primary one, sorted by offset.
+// An additional cursor at the offset of the primary cursor is dropped.
+func (g *GapBuffer) allCursors() []cursorState {
+	primary := cursorState{offset: g.cursor, wantsCol: g.wantsCol, primary: true}
+
+	if g.cursors == nil {
+		return []cursorState{primary}
+	}
+
+	all := make([]cursorState, 0, len(g.cursors.cursors)+1)
+	inserted := false
+
+	for _, c := range g.cursors.cursors {
+		if !inserted && c.offset >= primary.offset {
+			all = append(all, primary)
+			inserted = true
+		}
+
+		if c.offset != primary.offset {
+			all = append(all, c)
+		}
+	}
+
+	if !inserted {
+		all = append(all, primary)
+	}
+
+	return all
+}
+
+// eachCursor calls `f` - which edits the text at or moves the primary cursor -
+// for every cursor, from the first to the last one. The gap and the line
+// buffer are only moved forward from one cursor to the next. The edits at all
+// cursors are a single operation, see [GapBuffer.beginOp].
+func (g *GapBuffer) eachCursor(f func()) {
+	g.beginOp()
+	defer g.endOp()
+
+	all := g.allCursors()
+
+	if g.cursors != nil {
+		g.cursors.batch = true
+	}
+
+	// The change of the length of the text by the cursors before the current
+	// one.
+	delta := 0
+	length := g.StringLength()
+
+	for idx := range all {
+		c := &all[idx]
+
+		g.setCursor(c.offset + delta)
+
+		// Edits at the cursors before may have moved the cursor to the right
+		// of the column it wants to hold.
+		g.wantsCol = max(c.wantsCol, g.RuneCol())
+		f()
+
+		delta += g.StringLength() - length
+		length = g.StringLength()
+		c.offset, c.wantsCol = g.cursor, g.wantsCol
+	}
+
+	g.setAllCursors(all)
+}
+
+// setAllCursors sets the cursors to the given ones, which may be out of order,
+// and merges cursors at the same offset.
+func (g *GapBuffer) setAllCursors(all []cursorState) {
+	slices.SortStableFunc(all, func(a cursorState, b cursorState) int {
+		return a.offset - b.offset
+	})
+
+	var primary cursorState
+
+	extra := make([]cursorState, 0, len(all))
+
+	for idx := 0; idx < len(all); {
+		c := all[idx]
+
+		for idx++; idx < len(all) && all[idx].offset == c.offset; idx++ {
+			c.primary = c.primary || all[idx].primary
+		}
+
+		if c.primary {
+			primary = c
+		} else {
+			extra = append(extra, c)
+		}
+	}
+
+	g.setCursor(primary.offset)
+	g.wantsCol = primary.wantsCol
+
+	if g.cursors == nil {
+		return
+	}
+
+	g.cursors.batch = false
+	g.cursors.cursors = extra
+
+	if len(extra) == 0 {
+		g.ClearCursors()
+	}
+}
+
+// moveCursors moves the additional cursors along with the edit, it is the
+// [EditFunc] of the cursors. Cursors at the same offset are merged.
+func (g *GapBuffer) moveCursors(e Edit) {
+	if g.cursors.batch {
+		return
+	}
+
+	cursors := g.cursors.cursors[:0]
+
+	for _, c := range g.cursors.cursors {
+		if c.offset > e.Offset {
+			c.offset = max(c.offset-len(e.Deleted), e.Offset) + len(e.Inserted)
+		}
+
+		if len(cursors) == 0 || cursors[len(cursors)-1].offset != c.offset {
+			cursors = append(cursors, c)
+		}
+	}
+
+	g.cursors.cursors = cursors
+}
@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     cursors_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursors(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("one\ntwo\nthree")
+	assert.Equal(t, []int{13}, gb.Cursors(), "Only the primary cursor")
+
+	gb.AddCursor(4)
+	gb.AddCursor(0)
+	gb.AddCursor(4)
+	gb.AddCursor(13)
+	gb.AddCursor(100)
+	assert.Equal(t, []int{0, 4, 13}, gb.Cursors(), "No duplicates")
+
+	assert.True(t, gb.RemoveCursor(0))
+	assert.False(t, gb.RemoveCursor(0), "Already removed")
+	assert.False(t, gb.RemoveCursor(13), "The primary cursor")
+	assert.Equal(t, []int{4, 13}, gb.Cursors())
+
+	gb.MoveTo(4)
+	assert.Equal(t, []int{4}, gb.Cursors(), "Merged with the primary cursor")
+
+	gb.AddCursor(8)
+	gb.ClearCursors()
+	assert.Equal(t, []int{4}, gb.Cursors())
+}
+
+func TestInsertAll(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("one\ntwo\nthree")
+	gb.MoveTo(4)
+	gb.AddCursor(0)
+	gb.AddCursor(8)
+
+	gb.InsertAll("- ")
+	assert.Equal(t, "- one\n- two\n- three", gb.String())
+	assert.Equal(t, []int{2, 8, 14}, gb.Cursors())
+	assert.Equal(t, 8, gb.Offset(), "Primary cursor")
+
+	gb.InsertAll("ä\n")
+	assert.Equal(t, "- ä\none\n- ä\ntwo\n- ä\nthree", gb.String())
+	assert.Equal(t, []int{5, 14, 23}, gb.Cursors())
+	assert.Equal(t, 4, gb.Line(), "Line of the primary cursor")
+	assert.Equal(t, 0, gb.Col(), "Column of the primary cursor")
+}
+
+func TestLeftDelAll(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("aä\nbb\ncc")
+	gb.AddCursor(3)
+	gb.AddCursor(6)
+
+	gb.LeftDelAll()
+	assert.Equal(t, "a\nb\nc", gb.String())
+	assert.Equal(t, []int{1, 3, 5}, gb.Cursors())
+
+	gb.LeftDelAll()
+	assert.Equal(t, "\n\n", gb.String())
+	assert.Equal(t, []int{0, 1, 2}, gb.Cursors())
+
+	gb.LeftDelAll()
+	assert.Equal(t, "", gb.String())
+	assert.Equal(t, []int{0}, gb.Cursors(), "Merged cursors")
+
+	gb.InsertAll("x")
+	assert.Equal(t, "x", gb.String(), "Only one cursor left")
+}
+
+func TestRightDelAll(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("ab\ncd\nef")
+	gb.MoveTo(0)
+	gb.AddCursor(3)
+	gb.AddCursor(2)
+
+	gb.RightDelAll()
+	assert.Equal(t, "bd\nef", gb.String())
+	assert.Equal(t, []int{0, 1}, gb.Cursors(), "Merged cursors")
+}
+
+// Deleting the newline at the first cursor moves the second one to the right
+// in the same line, it must want to hold its new column.
+func TestEditAllWantedColumn(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("0123456789\nab\ncd")
+	gb.MoveTo(13)
+	gb.AddCursor(15)
+
+	gb.InsertAll("x")
+	assert.Equal(t, "0123456789\nabx\ncxd", gb.String())
+
+	gb.RightDelAll()
+	assert.Equal(t, "0123456789\nabxcx", gb.String())
+	assert.Equal(t, []int{14, 16}, gb.Cursors())
+
+	gb.UpMvAll()
+	assert.Equal(t, []int{3, 5}, gb.Cursors())
+}
+
+func TestMoveAll(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("long line\nab\nanother line\n")
+	gb.MoveTo(7)
+	gb.AddCursor(11)
+
+	gb.DownMvAll()
+	assert.Equal(t, []int{12, 14}, gb.Cursors())
+
+	gb.DownMvAll()
+	assert.Equal(t, []int{20, 26}, gb.Cursors(), "Each cursor holds its column")
+	assert.Equal(t, 20, gb.Offset())
+
+	gb.UpMvAll()
+	gb.UpMvAll()
+	assert.Equal(t, []int{7, 11}, gb.Cursors(), "Back to the wanted columns")
+
+	gb.LeftMvAll()
+	gb.LeftMvAll()
+	assert.Equal(t, []int{5, 9}, gb.Cursors())
+
+	gb.RightMvAll()
+	assert.Equal(t, []int{6, 10}, gb.Cursors())
+	assert.Equal(t, 6, gb.Offset())
+}
+
+func TestCursorsFollowEdits(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("one two three")
+	gb.AddCursor(4)
+	gb.AddCursor(8)
+
+	gb.MoveTo(0)
+	gb.Insert(">> ")
+	assert.Equal(t, []int{3, 7, 11}, gb.Cursors())
+
+	gb.DeleteRange(6, 12)
+	assert.Equal(t, ">> onehree", gb.String())
+	assert.Equal(t, []int{6}, gb.Cursors(), "Merged by the deletion")
+}
+
+func TestCursorsRandom(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(37)) //nolint:gosec // test
+
+	for i := 0; i < 100; i++ {
+		text := strings.Join(randomLines(rnd), "")
+		gb := gapbuffer.NewStr(text)
+		gb.MoveTo(rnd.Intn(len(text) + 1))
+
+		for j := rnd.Intn(10); j > 0; j-- {
+			gb.AddCursor(rnd.Intn(len(text) + 1))
+		}
+
+		offsets := gb.Cursors()
+		require.True(t, slices.IsSorted(offsets))
+
+		str := []string{"x", "\n", "yz\n"}[rnd.Intn(3)]
+		gb.InsertAll(str)
+
+		// Insert at every cursor from the last to the first.
+		expected := text
+		for idx := len(offsets) - 1; idx >= 0; idx-- {
+			expected = expected[:offsets[idx]] + str + expected[offsets[idx]:]
+		}
+
+		require.Equal(t, expected, gb.String())
+
+		for idx, offset := range gb.Cursors() {
+			require.Equal(t, offsets[idx]+(idx+1)*len(str), offset)
+		}
+
+		left, _ := gb.StringPair()
+		require.Equal(t, strings.Count(left, "\n")+1, gb.Line(), "Line of the primary cursor")
+	}
+}
@@ -0,0 +1,549 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     diff.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DiffOp is the kind of a line of a diff.
+type DiffOp int
+
+const (
+	// DiffEqual is a line of context, contained in both texts.
+	DiffEqual DiffOp = iota
+
+	// DiffDelete is a line only contained in the old text.
+	DiffDelete
+
+	// DiffInsert is a line only contained in the new text.
+	DiffInsert
+)
+
+// DiffAlgorithm is the algorithm used to compute a diff.
+type DiffAlgorithm int
+
+const (
+	// DiffMyers is Eugene W. Myers' O(ND) algorithm, which yields a minimal
+	// diff.
+	DiffMyers DiffAlgorithm = iota
+
+	// DiffHistogram is the histogram algorithm of Git, which anchors the diff
+	// at lines that occur rarely, like braces do not. Yields more readable
+	// diffs of source code.
+	DiffHistogram
+)
+
+// DiffOptions are the options of a diff.
+type DiffOptions struct {
+	// The algorithm to compute the diff with.
+	Algorithm DiffAlgorithm
+
+	// The number of unchanged lines before and after each change. Changes with
+	// less than two times `Context` lines between them are in the same hunk.
+	Context int
+}
+
+// DefaultDiffOptions are the options of `diff -u`, Myers' algorithm and 3 lines
+// of context.
+var DefaultDiffOptions = DiffOptions{Algorithm: DiffMyers, Context: 3} //nolint:gochecknoglobals // default
+
+// DiffLine is a single line of a [Hunk].
+type DiffLine struct {
+	// The kind of the line.
+	Op DiffOp
+
+	// The text of the line, including the final newline character, if the
+	// line has one.
+	Text string
+}
+
+// Hunk is a group of changed lines with their context.
+//
+// The line numbers start from 1, like in the header `@@ -1,3 +1,4 @@` of a
+// unified diff. If a hunk contains no lines of a text, the line number is the
+// number of the line before the hunk, 0 at the start of the text.
+type Hunk struct {
+	// The number of the first line of the hunk in the old text.
+	OldStart int
+
+	// The number of lines of the hunk in the old text.
+	OldLines int
+
+	// The number of the first line of the hunk in the new text.
+	NewStart int
+
+	// The number of lines of the hunk in the new text.
+	NewLines int
+
+	// The lines of the hunk.
+	Lines []DiffLine
+}
+
+// Return the hunk in unified diff format, the header and the lines.
+//
+// See also [UnifiedDiff].
+func (h Hunk) String() string {
+	var builder strings.Builder
+
+	h.write(&builder)
+
+	return builder.String()
+}
+
+// Diff returns the hunks of the line by line diff of the text of `other` - the
+// old text - and the text of the gap buffer - the new text.
+//
+// See also [GapBuffer.DiffString], [GapBuffer.DiffSaved], [UnifiedDiff].
+func (g *GapBuffer) Diff(other *GapBuffer, opts DiffOptions) []Hunk {
+	return diffLines(other.lineStrings(), g.lineStrings(), opts)
+}
+
+// DiffString returns the hunks of the line by line diff of `old` and the text
+// of the gap buffer.
+//
+// See also [GapBuffer.Diff], [GapBuffer.DiffSaved], [UnifiedDiff].
+func (g *GapBuffer) DiffString(old string, opts DiffOptions) []Hunk {
+	return diffLines(splitLines(old), g.lineStrings(), opts)
+}
+
+// DiffSaved returns the hunks of the line by line diff of the saved baseline
+// and the text of the gap buffer, the changes since the last save.
+//
+// See also [GapBuffer.MarkSaved], [GapBuffer.Diff], [UnifiedDiff].
+func (g *GapBuffer) DiffSaved(opts DiffOptions) []Hunk {
+	return g.DiffString(g.saved, opts)
+}
+
+// DiffStrings returns the hunks of the line by line diff of the two strings.
+//
+// See also [GapBuffer.Diff], [UnifiedDiff].
+func DiffStrings(oldText string, newText string, opts DiffOptions) []Hunk {
+	return diffLines(splitLines(oldText), splitLines(newText), opts)
+}
+
+// UnifiedDiff returns the hunks as unified diff, like `diff -u`, with the given
+// names of the old and new file in the header. Returns the empty string if
+// there are no hunks.
+//
+// Lines without a final newline character are followed by the line
+// `\ No newline at end of file`.
+func UnifiedDiff(oldName string, newName string, hunks []Hunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+
+	builder.WriteString("--- " + oldName + "\n")
+	builder.WriteString("+++ " + newName + "\n")
+
+	for _, h := range hunks {
+		h.write(&builder)
+	}
+
+	return builder.String()
+}
+
+// write writes the hunk in unified diff format to the builder.
+func (h Hunk) write(builder *strings.Builder) {
+	builder.WriteString("@@ -" + hunkRange(h.OldStart, h.OldLines))
+	builder.WriteString(" +" + hunkRange(h.NewStart, h.NewLines) + " @@\n")
+
+	for _, line := range h.Lines {
+		switch line.Op {
+		case DiffEqual:
+			builder.WriteByte(' ')
+		case DiffDelete:
+			builder.WriteByte('-')
+		case DiffInsert:
+			builder.WriteByte('+')
+		}
+
+		builder.WriteString(line.Text)
+
+		if !strings.HasSuffix(line.Text, "\n") {
+			builder.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange returns the line range of a hunk header, the number of lines is
+// omitted if it is 1.
+func hunkRange(start int, lines int) string {
+	if lines == 1 {
+		return strconv.Itoa(start)
+	}
+
+	return strconv.Itoa(start) + "," + strconv.Itoa(lines)
+}
+
+// lineStrings returns the lines of the gap buffer using the line index, each
+// line including its newline character. Text ending in a newline does not
+// have an empty last line.
+func (g *GapBuffer) lineStrings() []string {
+	g.index(g.StringLength())
+
+	count := g.lines.lineCount()
+	lines := make([]string, 0, count)
+	start := 0
+
+	for idx := 0; idx < count; idx++ {
+		end := start + g.lines.lineLength(idx)
+		if end > start {
+			lines = append(lines, g.slice(start, end))
+		}
+
+		start = end
+	}
+
+	return lines
+}
+
+// splitLines splits the string into lines, each line including its newline
+// character. Text ending in a newline does not have an empty last line.
+func splitLines(str string) []string {
+	if str == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(str, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// diffLines returns the hunks of the diff of the two lists of lines.
+func diffLines(oldLines []string, newLines []string, opts DiffOptions) []Hunk {
+	// Compare lines as integers.
+	ids := make(map[string]int, len(oldLines))
+	a := internLines(ids, oldLines)
+	b := internLines(ids, newLines)
+
+	var ops []DiffOp
+
+	switch opts.Algorithm {
+	case DiffHistogram:
+		ops = histogramDiff(a, b, make([]DiffOp, 0, len(a)+len(b)))
+	default:
+		ops = myersDiff(a, b, make([]DiffOp, 0, len(a)+len(b)))
+	}
+
+	return makeHunks(ops, oldLines, newLines, max(opts.Context, 0))
+}
+
+// internLines returns the ID of each line, equal lines have the same ID.
+func internLines(ids map[string]int, lines []string) []int {
+	res := make([]int, len(lines))
+
+	for idx, line := range lines {
+		id, ok := ids[line]
+		if !ok {
+			id = len(ids)
+			ids[line] = id
+		}
+
+		res[idx] = id
+	}
+
+	return res
+}
+
+// makeHunks groups the diff operations into hunks with `context` lines of
+// context.
+func makeHunks(ops []DiffOp, oldLines []string, newLines []string, context int) []Hunk {
+	var hunks []Hunk
+
+	// The index into `ops` and the corresponding indices of the lines.
+	pos, oldIdx, newIdx := 0, 0, 0
+	advance := func(to int) {
+		for ; pos < to; pos++ {
+			if ops[pos] != DiffInsert {
+				oldIdx++
+			}
+
+			if ops[pos] != DiffDelete {
+				newIdx++
+			}
+		}
+	}
+
+	for {
+		change := pos
+		for change < len(ops) && ops[change] == DiffEqual {
+			change++
+		}
+
+		if change == len(ops) {
+			return hunks
+		}
+
+		// Extend the hunk over all changes separated by at most two times the
+		// context.
+		end := change
+
+		for {
+			for end < len(ops) && ops[end] != DiffEqual {
+				end++
+			}
+
+			next := end
+			for next < len(ops) && ops[next] == DiffEqual {
+				next++
+			}
+
+			if next == len(ops) || next-end > 2*context {
+				break
+			}
+
+			end = next
+		}
+
+		advance(max(change-context, pos))
+
+		hunk := Hunk{OldStart: oldIdx, OldLines: 0, NewStart: newIdx, NewLines: 0, Lines: nil}
+		stop := min(end+context, len(ops))
+
+		for ; pos < stop; pos++ {
+			switch ops[pos] {
+			case DiffEqual:
+				hunk.Lines = append(hunk.Lines, DiffLine{Op: DiffEqual, Text: oldLines[oldIdx]})
+				hunk.OldLines++
+				hunk.NewLines++
+				oldIdx++
+				newIdx++
+			case DiffDelete:
+				hunk.Lines = append(hunk.Lines, DiffLine{Op: DiffDelete, Text: oldLines[oldIdx]})
+				hunk.OldLines++
+				oldIdx++
+			case DiffInsert:
+				hunk.Lines = append(hunk.Lines, DiffLine{Op: DiffInsert, Text: newLines[newIdx]})
+				hunk.NewLines++
+				newIdx++
+			}
+		}
+
+		if hunk.OldLines > 0 {
+			hunk.OldStart++
+		}
+
+		if hunk.NewLines > 0 {
+			hunk.NewStart++
+		}
+
+		hunks = append(hunks, hunk)
+	}
+}
+
+// myersDiff appends the shortest edit script from `a` to `b` to `ops`, using
+// the linear space variant of Myers' algorithm: the middle snake of the edit
+// script splits it into two smaller ones, which are searched recursively. This
+// needs memory proportional to the number of lines, not to the square of the
+// number of differences.
+func myersDiff(a []int, b []int, ops []DiffOp) []DiffOp {
+	// Common prefixes and suffixes are cheap to find.
+	prefix, suffix := commonAffixes(a, b)
+	ops = appendEqual(ops, prefix)
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		ops = appendOps(ops, DiffInsert, len(b))
+	case len(b) == 0:
+		ops = appendOps(ops, DiffDelete, len(a))
+	default:
+		// Without common prefix and suffix, the edit script has at least 2
+		// edits, so both halves are shorter.
+		x, y, u, v := myersMiddleSnake(a, b)
+		ops = myersDiff(a[:x], b[:y], ops)
+		ops = appendEqual(ops, u-x)
+		ops = myersDiff(a[u:], b[v:], ops)
+	}
+
+	return appendEqual(ops, suffix)
+}
+
+// myersMiddleSnake returns the middle snake of the shortest edit script from
+// `a` to `b`, the equal lines from (`x`, `y`) to (`u`, `v`) in the middle of
+// the edit script. The edit script is searched from the start and from the end
+// at the same time, until the two searches overlap.
+func myersMiddleSnake(a []int, b []int) (x int, y int, u int, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+
+	// The furthest reaching x of the diagonals of the forward search and the
+	// furthest reaching distance from the end of the backward search.
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x1 int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x1 = forward[offset+k+1]
+			} else {
+				x1 = forward[offset+k-1] + 1
+			}
+
+			y1 := x1 - k
+			startX, startY := x1, y1
+
+			for x1 < n && y1 < m && a[x1] == b[y1] {
+				x1++
+				y1++
+			}
+
+			forward[offset+k] = x1
+
+			if k2 := delta - k; odd && k2 >= -(d-1) && k2 <= d-1 && x1 >= n-backward[offset+k2] {
+				return startX, startY, x1, y1
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x2 int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x2 = backward[offset+k+1]
+			} else {
+				x2 = backward[offset+k-1] + 1
+			}
+
+			y2 := x2 - k
+			endX, endY := x2, y2
+
+			for x2 < n && y2 < m && a[n-x2-1] == b[m-y2-1] {
+				x2++
+				y2++
+			}
+
+			backward[offset+k] = x2
+
+			if k1 := delta - k; !odd && k1 >= -d && k1 <= d && forward[offset+k1] >= n-x2 {
+				return n - x2, m - y2, n - endX, m - endY
+			}
+		}
+	}
+
+	// Not reached, the searches overlap after at most `maxD` steps.
+	return n, m, n, m
+}
+
+// The maximum number of occurrences of a line to be used as anchor by
+// [histogramDiff], like Git.
+const maxHistogramChain = 64
+
+// histogramDiff appends the edit script from `a` to `b` to `ops`, using the
+// histogram algorithm: the longest common run of lines containing the line
+// occurring the fewest times is kept, the lines before and after it are
+// diffed recursively. Falls back to Myers' algorithm, if there is no such line.
+func histogramDiff(a []int, b []int, ops []DiffOp) []DiffOp {
+	prefix, suffix := commonAffixes(a, b)
+	ops = appendEqual(ops, prefix)
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		for range b {
+			ops = append(ops, DiffInsert)
+		}
+
+		return appendEqual(ops, suffix)
+
+	case len(b) == 0:
+		for range a {
+			ops = append(ops, DiffDelete)
+		}
+
+		return appendEqual(ops, suffix)
+	}
+
+	positions := make(map[int][]int, len(a))
+	for idx, line := range a {
+		positions[line] = append(positions[line], idx)
+	}
+
+	bestCount := maxHistogramChain + 1
+	bestA, bestB, bestLen := 0, 0, 0
+
+	for j := 0; j < len(b); {
+		next := j + 1
+		occurrences := positions[b[j]]
+
+		if len(occurrences) > 0 && len(occurrences) <= bestCount {
+			for _, i := range occurrences {
+				start, end := 0, 1
+				count := len(occurrences)
+
+				for i-start > 0 && j-start > 0 && a[i-start-1] == b[j-start-1] {
+					start++
+					count = min(count, len(positions[a[i-start]]))
+				}
+
+				for i+end < len(a) && j+end < len(b) && a[i+end] == b[j+end] {
+					count = min(count, len(positions[a[i+end]]))
+					end++
+				}
+
+				if start+end > bestLen || count < bestCount {
+					bestA, bestB, bestLen, bestCount = i-start, j-start, start+end, count
+				}
+
+				next = max(next, j+end)
+			}
+		}
+
+		j = next
+	}
+
+	if bestLen == 0 {
+		return appendEqual(myersDiff(a, b, ops), suffix)
+	}
+
+	ops = histogramDiff(a[:bestA], b[:bestB], ops)
+	ops = appendEqual(ops, bestLen)
+	ops = histogramDiff(a[bestA+bestLen:], b[bestB+bestLen:], ops)
+
+	return appendEqual(ops, suffix)
+}
+
+// commonAffixes returns the length of the common prefix and the common suffix
+// of `a` and `b`, which do not overlap.
+func commonAffixes(a []int, b []int) (prefix int, suffix int) {
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-suffix-1] == b[len(b)-suffix-1] {
+		suffix++
+	}
+
+	return prefix, suffix
+}
+
+// appendEqual appends `n` equal lines to `ops`.
+func appendEqual(ops []DiffOp, n int) []DiffOp {
+	return appendOps(ops, DiffEqual, n)
+}
+
+// appendOps appends `n` times the operation `op` to `ops`.
+func appendOps(ops []DiffOp, op DiffOp, n int) []DiffOp {
+	for ; n > 0; n-- {
+		ops = append(ops, op)
+	}
+
+	return ops
+}
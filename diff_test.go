@@ -0,0 +1,264 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     diff_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffUnified(t *testing.T) {
+	t.Parallel()
+
+	oldText := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+	gb := gapbuffer.NewStr(strings.Replace(oldText, "two", "2", 1) + "eleven")
+
+	exp := `--- a/numbers
++++ b/numbers
+@@ -1,5 +1,5 @@
+ one
+-two
++2
+ three
+ four
+ five
+@@ -8,3 +8,4 @@
+ eight
+ nine
+ ten
++eleven
+\ No newline at end of file
+`
+	hunks := gb.DiffString(oldText, gapbuffer.DefaultDiffOptions)
+	assert.Equal(t, exp, gapbuffer.UnifiedDiff("a/numbers", "b/numbers", hunks))
+
+	// Changes with less than two times context lines between them are in one
+	// hunk.
+	hunks = gb.DiffString(oldText, gapbuffer.DiffOptions{Algorithm: gapbuffer.DiffMyers, Context: 4})
+	require.Len(t, hunks, 1)
+	assert.Equal(t, 10, hunks[0].OldLines, "Old lines")
+	assert.Equal(t, 11, hunks[0].NewLines, "New lines")
+
+	hunks = gb.DiffString(oldText, gapbuffer.DiffOptions{Algorithm: gapbuffer.DiffMyers, Context: 0})
+	require.Len(t, hunks, 2)
+	assert.Equal(t, "@@ -2 +2 @@\n-two\n+2\n", hunks[0].String())
+	assert.Equal(t, "@@ -10,0 +11 @@\n+eleven\n\\ No newline at end of file\n", hunks[1].String())
+
+	assert.Empty(t, gapbuffer.UnifiedDiff("a", "b", gb.DiffString(gb.String(), gapbuffer.DefaultDiffOptions)))
+}
+
+func TestDiffEmpty(t *testing.T) {
+	t.Parallel()
+
+	hunks := gapbuffer.DiffStrings("", "Hello\nWorld\n", gapbuffer.DefaultDiffOptions)
+	assert.Equal(t, "@@ -0,0 +1,2 @@\n+Hello\n+World\n", hunks[0].String())
+
+	hunks = gapbuffer.DiffStrings("Hello\nWorld\n", "", gapbuffer.DefaultDiffOptions)
+	assert.Equal(t, "@@ -1,2 +0,0 @@\n-Hello\n-World\n", hunks[0].String())
+}
+
+func TestDiffSaved(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("func main() {\n}\n")
+	assert.True(t, gb.Modified(), "The baseline is empty")
+
+	gb.MarkSaved()
+	assert.False(t, gb.Modified(), "Saved")
+	assert.Empty(t, gb.DiffSaved(gapbuffer.DefaultDiffOptions))
+
+	gb.UpMv()
+	gb.Insert("\tprintln()\n")
+	assert.True(t, gb.Modified(), "Changed")
+	assert.Equal(t, "func main() {\n}\n", gb.Saved())
+
+	hunks := gb.DiffSaved(gapbuffer.DefaultDiffOptions)
+	assert.Equal(t, "@@ -1,2 +1,3 @@\n func main() {\n+\tprintln()\n }\n", hunks[0].String())
+
+	other := gapbuffer.NewStr(gb.Saved())
+	assert.Equal(t, hunks, gb.Diff(other, gapbuffer.DefaultDiffOptions))
+}
+
+func TestDiffHistogram(t *testing.T) {
+	t.Parallel()
+
+	oldText := "{\n{\nfoo\n}\n}\n"
+	newText := "foo\n{\n{\n{\n}\n"
+
+	hunks := gapbuffer.DiffStrings(oldText, newText,
+		gapbuffer.DiffOptions{Algorithm: gapbuffer.DiffMyers, Context: 0})
+	assert.Equal(t, "@@ -0,0 +1 @@\n+foo\n", hunks[0].String(), "Minimal diff")
+
+	// The histogram diff keeps the unique line.
+	hunks = gapbuffer.DiffStrings(oldText, newText,
+		gapbuffer.DiffOptions{Algorithm: gapbuffer.DiffHistogram, Context: 0})
+	assert.Equal(t, "@@ -1,2 +0,0 @@\n-{\n-{\n", hunks[0].String(), "Anchored at the unique line")
+}
+
+func TestDiffRandom(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(42)) //nolint:gosec // test
+
+	for i := 0; i < 200; i++ {
+		oldLines := randomLines(rnd)
+		newLines := mutateLines(rnd, oldLines)
+		oldText, newText := strings.Join(oldLines, ""), strings.Join(newLines, "")
+
+		for _, algo := range []gapbuffer.DiffAlgorithm{gapbuffer.DiffMyers, gapbuffer.DiffHistogram} {
+			for _, context := range []int{0, 1, 3} {
+				opts := gapbuffer.DiffOptions{Algorithm: algo, Context: context}
+				hunks := gapbuffer.DiffStrings(oldText, newText, opts)
+				require.Equal(t, newText, applyHunks(t, oldText, hunks), "%q -> %q %v", oldText, newText, opts)
+
+				if algo == gapbuffer.DiffMyers {
+					require.Equal(t, minEdits(oldLines, newLines), countEdits(hunks), "%q -> %q", oldText, newText)
+				}
+			}
+		}
+	}
+}
+
+func TestDiffMyersDissimilar(t *testing.T) {
+	t.Parallel()
+
+	var oldText, newText strings.Builder
+
+	for i := 0; i < 3000; i++ {
+		fmt.Fprintf(&oldText, "old %d\n", i)
+		fmt.Fprintf(&newText, "new %d\n", i)
+
+		if i%100 == 0 {
+			fmt.Fprintf(&oldText, "same %d\n", i)
+			fmt.Fprintf(&newText, "same %d\n", i)
+		}
+	}
+
+	hunks := gapbuffer.DiffStrings(oldText.String(), newText.String(), gapbuffer.DefaultDiffOptions)
+	assert.Equal(t, newText.String(), applyHunks(t, oldText.String(), hunks))
+	assert.Equal(t, 6000, countEdits(hunks))
+}
+
+// minEdits returns the number of deleted and inserted lines of the shortest
+// edit script, using the longest common subsequence.
+func minEdits(oldLines []string, newLines []string) int {
+	lcs := make([][]int, len(oldLines)+1)
+	for idx := range lcs {
+		lcs[idx] = make([]int, len(newLines)+1)
+	}
+
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	return len(oldLines) + len(newLines) - 2*lcs[0][0]
+}
+
+// countEdits returns the number of deleted and inserted lines of the hunks.
+func countEdits(hunks []gapbuffer.Hunk) int {
+	edits := 0
+
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			if line.Op != gapbuffer.DiffEqual {
+				edits++
+			}
+		}
+	}
+
+	return edits
+}
+
+// randomLines returns up to 20 lines out of a small set of lines, the last one
+// may be missing its newline.
+func randomLines(rnd *rand.Rand) []string {
+	lines := make([]string, rnd.Intn(20))
+	for idx := range lines {
+		lines[idx] = string(rune('a'+rnd.Intn(5))) + "\n"
+	}
+
+	if len(lines) > 0 && rnd.Intn(3) == 0 {
+		lines[len(lines)-1] = "z"
+	}
+
+	return lines
+}
+
+// mutateLines returns a copy of the lines with some lines deleted, inserted or
+// changed.
+func mutateLines(rnd *rand.Rand, lines []string) []string {
+	res := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		switch rnd.Intn(6) {
+		case 0:
+		case 1:
+			res = append(res, "new\n", line)
+		case 2:
+			res = append(res, string(rune('a'+rnd.Intn(5)))+"\n")
+		default:
+			res = append(res, line)
+		}
+	}
+
+	if len(res) > 0 && rnd.Intn(3) == 0 {
+		res[len(res)-1] = strings.TrimSuffix(res[len(res)-1], "\n")
+	}
+
+	return res
+}
+
+// applyHunks applies the hunks to the old text and returns the new text.
+func applyHunks(t *testing.T, oldText string, hunks []gapbuffer.Hunk) string {
+	t.Helper()
+
+	oldLines := strings.SplitAfter(oldText, "\n")
+	res := make([]string, 0, len(oldLines))
+	idx := 0
+
+	for _, hunk := range hunks {
+		start := hunk.OldStart
+		if hunk.OldLines > 0 {
+			start--
+		}
+
+		require.GreaterOrEqual(t, start, idx, "Hunks are sorted")
+		res = append(res, oldLines[idx:start]...)
+		idx = start
+
+		for _, line := range hunk.Lines {
+			switch line.Op {
+			case gapbuffer.DiffEqual:
+				require.Equal(t, oldLines[idx], line.Text, "Context")
+				res = append(res, line.Text)
+				idx++
+			case gapbuffer.DiffDelete:
+				require.Equal(t, oldLines[idx], line.Text, "Deleted line")
+				idx++
+			case gapbuffer.DiffInsert:
+				res = append(res, line.Text)
+			}
+		}
+	}
+
+	return strings.Join(append(res, oldLines[idx:]...), "")
+}
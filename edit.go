@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     edit.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+// Edit is a single change of the text of a gap buffer: at byte offset `Offset`
+// the text `Deleted` has been replaced by `Inserted`. One of `Deleted` and
+// `Inserted` is always empty, a replacement is reported as two edits, first the
+// deletion, then the insertion.
+//
+// After an edit, the cursor is at `Offset + len(Inserted)`.
+type Edit struct {
+	// The byte offset of the edit.
+	Offset int
+
+	// The deleted text, empty for insertions.
+	Deleted string
+
+	// The inserted text, empty for deletions.
+	Inserted string
+}
+
+// EditFunc is a function that is called with every [Edit] after it has been
+// applied to the gap buffer.
+//
+// Warning: an EditFunc must not change the gap buffer.
+type EditFunc func(e Edit)
+
+// editListener wraps an [EditFunc], to be able to remove it again.
+type editListener struct {
+	f EditFunc
+}
+
+// AddEditFunc adds a function which is called with every change of the text of
+// the gap buffer, in the order the functions have been added. Moving the
+// cursor is not a change of the text.
+//
+// Returns a function that removes the EditFunc again.
+func (g *GapBuffer) AddEditFunc(f EditFunc) (remove func()) {
+	l := &editListener{f: f}
+	g.listeners = append(g.listeners, l)
+
+	return func() {
+		for idx, other := range g.listeners {
+			if other == l {
+				g.listeners = append(g.listeners[:idx:idx], g.listeners[idx+1:]...)
+
+				return
+			}
+		}
+	}
+}
+
+// beginOp starts an operation consisting of several edits, like the deletion
+// and insertion of [GapBuffer.ReplaceRange]. All edits until the matching
+// [GapBuffer.endOp] belong to the same operation, which is a single undo step
+// of a [History]. Operations can be nested, the outermost one counts.
+func (g *GapBuffer) beginOp() {
+	if g.opDepth == 0 {
+		g.op++
+	}
+
+	g.opDepth++
+}
+
+// endOp ends the operation started by the matching [GapBuffer.beginOp].
+func (g *GapBuffer) endOp() {
+	g.opDepth--
+}
+
+// notify calls all edit functions with the edit. An edit outside of an
+// operation is an operation of its own, see [GapBuffer.beginOp].
+func (g *GapBuffer) notify(e Edit) {
+	if g.opDepth == 0 {
+		g.op++
+	}
+
+	for _, l := range g.listeners {
+		l.f(e)
+	}
+}
@@ -0,0 +1,360 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     encoding.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding of a file. The text of a [GapBuffer] is
+// always UTF-8, files in other encodings are converted when loading and
+// saving them.
+//
+// See also [FileEncoding], [DetectEncoding].
+type Encoding int
+
+const (
+	// UTF-8, the default.
+	EncodingUTF8 Encoding = iota
+
+	// UTF-16, little endian.
+	EncodingUTF16LE
+
+	// UTF-16, big endian.
+	EncodingUTF16BE
+
+	// ISO-8859-1, every byte is the unicode code point of the same value.
+	EncodingLatin1
+)
+
+const (
+	// The UTF-8 byte order mark.
+	bomUTF8 = "\xef\xbb\xbf"
+
+	// The UTF-16 little endian byte order mark.
+	bomUTF16LE = "\xff\xfe"
+
+	// The UTF-16 big endian byte order mark.
+	bomUTF16BE = "\xfe\xff"
+
+	// The number of bytes [DetectEncoding] looks at to detect UTF-16 without
+	// a byte order mark.
+	detectSampleSize = 4096
+
+	// The file mode of a file written by [GapBuffer.SaveFile], if the file does
+	// not exist.
+	filePerm = 0o644
+)
+
+// ErrInvalidEncoding is returned if the data of a file is not valid in the
+// encoding it is decoded from, for example UTF-16 with an odd number of bytes
+// or an unpaired surrogate.
+var ErrInvalidEncoding = errors.New("invalid data for the encoding")
+
+// Return the name of the encoding.
+func (e Encoding) String() string {
+	switch e {
+	case EncodingUTF8:
+		return "UTF-8"
+	case EncodingUTF16LE:
+		return "UTF-16LE"
+	case EncodingUTF16BE:
+		return "UTF-16BE"
+	case EncodingLatin1:
+		return "ISO-8859-1"
+	default:
+		return fmt.Sprintf("Encoding(%d)", int(e))
+	}
+}
+
+// FileEncoding is the encoding of a file and whether the file starts with a
+// byte order mark (BOM). Latin-1 has no byte order mark.
+type FileEncoding struct {
+	Encoding Encoding
+	BOM      bool
+}
+
+// UnrepresentableError is returned when saving text in an encoding which
+// can't represent all of its characters, like a "€" in Latin-1 or invalid
+// UTF-8 in UTF-16. Nothing is written if this error is returned.
+type UnrepresentableError struct {
+	// The encoding the text should have been saved in.
+	Encoding Encoding
+
+	// The byte offsets in the text of the characters which can't be
+	// represented, in ascending order.
+	Offsets []int
+}
+
+// Return the error message, containing the number of characters that can't be
+// represented and the offset of the first one.
+func (e *UnrepresentableError) Error() string {
+	return fmt.Sprintf("%d characters can't be represented in %v, the first at byte offset %d",
+		len(e.Offsets), e.Encoding, e.Offsets[0])
+}
+
+// Return the encoding of the given file data. A byte order mark is used if
+// there is one. Else the data is UTF-16 if most of the first 4096 bytes at
+// either the odd or the even positions are zero, UTF-8 if it is valid UTF-8
+// and Latin-1 otherwise.
+//
+// See also [DecodeText], [NewEncoded].
+func DetectEncoding(data []byte) FileEncoding {
+	switch {
+	case bytes.HasPrefix(data, []byte(bomUTF8)):
+		return FileEncoding{Encoding: EncodingUTF8, BOM: true}
+	case bytes.HasPrefix(data, []byte(bomUTF16LE)):
+		return FileEncoding{Encoding: EncodingUTF16LE, BOM: true}
+	case bytes.HasPrefix(data, []byte(bomUTF16BE)):
+		return FileEncoding{Encoding: EncodingUTF16BE, BOM: true}
+	}
+
+	if len(data)%2 == 0 {
+		sample := data[:min(len(data), detectSampleSize)]
+		even, odd := 0, 0
+
+		for idx := 0; idx < len(sample); idx += 2 {
+			if sample[idx] == 0 {
+				even++
+			}
+
+			if sample[idx+1] == 0 {
+				odd++
+			}
+		}
+
+		pairs := len(sample) / 2
+
+		switch {
+		case odd*2 > pairs && even*8 < odd:
+			return FileEncoding{Encoding: EncodingUTF16LE, BOM: false}
+		case even*2 > pairs && odd*8 < even:
+			return FileEncoding{Encoding: EncodingUTF16BE, BOM: false}
+		}
+	}
+
+	if utf8.Valid(data) {
+		return FileEncoding{Encoding: EncodingUTF8, BOM: false}
+	}
+
+	return FileEncoding{Encoding: EncodingLatin1, BOM: false}
+}
+
+// Return the file data in the given encoding converted to UTF-8, without the
+// byte order mark. Invalid UTF-8 is kept unchanged, see [InvalidUTF8Policy].
+// Returns [ErrInvalidEncoding] if UTF-16 data has an odd number of bytes or
+// contains an unpaired surrogate.
+//
+// See also [DetectEncoding], [EncodeText].
+func DecodeText(data []byte, enc FileEncoding) (string, error) {
+	switch enc.Encoding {
+	case EncodingUTF16LE:
+		return decodeUTF16(bytes.TrimPrefix(data, []byte(bomUTF16LE)), binary.LittleEndian)
+	case EncodingUTF16BE:
+		return decodeUTF16(bytes.TrimPrefix(data, []byte(bomUTF16BE)), binary.BigEndian)
+	case EncodingLatin1:
+		runes := make([]rune, len(data))
+		for idx, b := range data {
+			runes[idx] = rune(b)
+		}
+
+		return string(runes), nil
+	default:
+		return string(bytes.TrimPrefix(data, []byte(bomUTF8))), nil
+	}
+}
+
+// Return the UTF-8 text converted to the given encoding, with a byte order
+// mark if `enc.BOM` is true. Returns an [UnrepresentableError] if the text
+// contains characters which can't be represented in the encoding. Invalid
+// UTF-8 can only be saved as UTF-8.
+//
+// See also [DecodeText], [GapBuffer.Encode].
+func EncodeText(str string, enc FileEncoding) ([]byte, error) {
+	var unrepresentable []int
+
+	data := make([]byte, 0, len(str)+len(bomUTF8))
+
+	switch enc.Encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		var order binary.AppendByteOrder = binary.LittleEndian
+		bom := bomUTF16LE
+
+		if enc.Encoding == EncodingUTF16BE {
+			order, bom = binary.BigEndian, bomUTF16BE
+		}
+
+		if enc.BOM {
+			data = append(data, bom...)
+		}
+
+		for offset, r := range str {
+			if r == utf8.RuneError && isInvalidByte(str, offset) {
+				unrepresentable = append(unrepresentable, offset)
+
+				continue
+			}
+
+			for _, unit := range utf16.Encode([]rune{r}) {
+				data = order.AppendUint16(data, unit)
+			}
+		}
+
+	case EncodingLatin1:
+		for offset, r := range str {
+			if r > 0xff || (r == utf8.RuneError && isInvalidByte(str, offset)) {
+				unrepresentable = append(unrepresentable, offset)
+
+				continue
+			}
+
+			data = append(data, byte(r))
+		}
+
+	default:
+		if enc.BOM {
+			data = append(data, bomUTF8...)
+		}
+
+		data = append(data, str...)
+	}
+
+	if unrepresentable != nil {
+		return nil, &UnrepresentableError{Encoding: enc.Encoding, Offsets: unrepresentable}
+	}
+
+	return data, nil
+}
+
+// Construct a new GapBuffer from the data of a file, detecting its encoding
+// with [DetectEncoding] and converting it to UTF-8. The encoding is
+// remembered, so [GapBuffer.Encode] and [GapBuffer.SaveFile] use the same
+// encoding and byte order mark. The cursor position is set to the end of the
+// text, the text is the saved baseline, see [GapBuffer.MarkSaved].
+//
+// Returns the errors of [DecodeText].
+//
+// See also [LoadFile].
+func NewEncoded(data []byte) (*GapBuffer, error) {
+	enc := DetectEncoding(data)
+
+	str, err := DecodeText(data, enc)
+	if err != nil {
+		return nil, err
+	}
+
+	g := NewStr(str)
+	g.encoding = enc
+	g.MarkSaved()
+
+	return g, nil
+}
+
+// Construct a new GapBuffer from the file with the given path, like
+// [NewEncoded].
+//
+// See also [GapBuffer.SaveFile].
+func LoadFile(path string) (*GapBuffer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEncoded(data)
+}
+
+// Return the encoding the text is saved in, the encoding of the loaded file
+// or UTF-8 without byte order mark.
+//
+// See also [GapBuffer.SetEncoding].
+func (g *GapBuffer) Encoding() FileEncoding {
+	return g.encoding
+}
+
+// SetEncoding sets the encoding the text is saved in.
+//
+// See also [GapBuffer.Encoding].
+func (g *GapBuffer) SetEncoding(enc FileEncoding) {
+	g.encoding = enc
+}
+
+// Return the text converted to the encoding of the gap buffer, see
+// [EncodeText].
+//
+// See also [GapBuffer.Encoding], [GapBuffer.SaveFile].
+func (g *GapBuffer) Encode() ([]byte, error) {
+	return EncodeText(g.String(), g.encoding)
+}
+
+// SaveFile writes the text converted to the encoding of the gap buffer to the
+// file with the given path and marks the text as saved, see
+// [GapBuffer.MarkSaved]. Returns an [UnrepresentableError] without writing the
+// file if the text can't be represented in the encoding.
+//
+// See also [LoadFile].
+func (g *GapBuffer) SaveFile(path string) error {
+	data, err := g.Encode()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, filePerm); err != nil {
+		return err
+	}
+
+	g.MarkSaved()
+
+	return nil
+}
+
+// decodeUTF16 returns the UTF-16 data in the given byte order converted to
+// UTF-8.
+func decodeUTF16(data []byte, order binary.ByteOrder) (string, error) {
+	if len(data)%2 != 0 {
+		return "", ErrInvalidEncoding
+	}
+
+	runes := make([]rune, 0, len(data)/2)
+
+	for idx := 0; idx < len(data); idx += 2 {
+		r := rune(order.Uint16(data[idx:]))
+
+		if utf16.IsSurrogate(r) {
+			if idx+3 >= len(data) {
+				return "", ErrInvalidEncoding
+			}
+
+			idx += 2
+			r = utf16.DecodeRune(r, rune(order.Uint16(data[idx:])))
+
+			if r == utf8.RuneError {
+				return "", ErrInvalidEncoding
+			}
+		}
+
+		runes = append(runes, r)
+	}
+
+	return string(runes), nil
+}
+
+// isInvalidByte returns true if the byte at the given offset is not part of a
+// valid UTF-8 encoded rune.
+func isInvalidByte(str string, offset int) bool {
+	_, size := utf8.DecodeRuneInString(str[offset:])
+
+	return size == 1
+}
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     encoding_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		data string
+		want gapbuffer.FileEncoding
+	}{
+		{"empty", "", gapbuffer.FileEncoding{Encoding: gapbuffer.EncodingUTF8, BOM: false}},
+		{"UTF-8", "Grüße", gapbuffer.FileEncoding{Encoding: gapbuffer.EncodingUTF8, BOM: false}},
+		{"UTF-8 BOM", "\xef\xbb\xbfab", gapbuffer.FileEncoding{Encoding: gapbuffer.EncodingUTF8, BOM: true}},
+		{"UTF-16LE BOM", "\xff\xfea\x00", gapbuffer.FileEncoding{Encoding: gapbuffer.EncodingUTF16LE, BOM: true}},
+		{"UTF-16BE BOM", "\xfe\xff\x00a", gapbuffer.FileEncoding{Encoding: gapbuffer.EncodingUTF16BE, BOM: true}},
+		{"UTF-16LE", "a\x00b\x00\n\x00", gapbuffer.FileEncoding{Encoding: gapbuffer.EncodingUTF16LE, BOM: false}},
+		{"UTF-16BE", "\x00a\x00b\x00\n", gapbuffer.FileEncoding{Encoding: gapbuffer.EncodingUTF16BE, BOM: false}},
+		{"Latin-1", "Gr\xfc\xdfe", gapbuffer.FileEncoding{Encoding: gapbuffer.EncodingLatin1, BOM: false}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, gapbuffer.DetectEncoding([]byte(tt.data)))
+		})
+	}
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	text := "Grüße 😀\nline 2"
+	encodings := []gapbuffer.FileEncoding{
+		{Encoding: gapbuffer.EncodingUTF8, BOM: false},
+		{Encoding: gapbuffer.EncodingUTF8, BOM: true},
+		{Encoding: gapbuffer.EncodingUTF16LE, BOM: true},
+		{Encoding: gapbuffer.EncodingUTF16LE, BOM: false},
+		{Encoding: gapbuffer.EncodingUTF16BE, BOM: true},
+		{Encoding: gapbuffer.EncodingUTF16BE, BOM: false},
+	}
+
+	for _, enc := range encodings {
+		enc := enc
+		t.Run(enc.Encoding.String(), func(t *testing.T) {
+			t.Parallel()
+
+			data, err := gapbuffer.EncodeText(text, enc)
+			require.NoError(t, err)
+
+			gb, err := gapbuffer.NewEncoded(data)
+			require.NoError(t, err)
+			assert.Equal(t, text, gb.String())
+			assert.Equal(t, enc, gb.Encoding())
+			assert.False(t, gb.Modified())
+
+			saved, err := gb.Encode()
+			require.NoError(t, err)
+			assert.Equal(t, data, saved)
+		})
+	}
+}
+
+func TestEncodingLatin1(t *testing.T) {
+	t.Parallel()
+
+	gb, err := gapbuffer.NewEncoded([]byte("Gr\xfc\xdfe\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "Grüße\n", gb.String())
+	assert.Equal(t, gapbuffer.EncodingLatin1, gb.Encoding().Encoding)
+
+	gb.Insert("5 € and 😀")
+
+	_, err = gb.Encode()
+
+	var unrepresentable *gapbuffer.UnrepresentableError
+	require.ErrorAs(t, err, &unrepresentable)
+	assert.Equal(t, gapbuffer.EncodingLatin1, unrepresentable.Encoding)
+	assert.Equal(t, []int{10, 18}, unrepresentable.Offsets)
+	assert.Contains(t, err.Error(), "2 characters")
+
+	gb.SetEncoding(gapbuffer.FileEncoding{Encoding: gapbuffer.EncodingUTF16LE, BOM: true})
+	data, err := gb.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, "\xff\xfeG\x00", string(data[:4]))
+}
+
+func TestEncodingInvalid(t *testing.T) {
+	t.Parallel()
+
+	utf16LE := gapbuffer.FileEncoding{Encoding: gapbuffer.EncodingUTF16LE, BOM: false}
+
+	_, err := gapbuffer.DecodeText([]byte("a\x00b"), utf16LE)
+	require.ErrorIs(t, err, gapbuffer.ErrInvalidEncoding)
+
+	_, err = gapbuffer.DecodeText([]byte("a\x00\x3d\xd8"), utf16LE)
+	require.ErrorIs(t, err, gapbuffer.ErrInvalidEncoding)
+
+	_, err = gapbuffer.DecodeText([]byte("\x3d\xd8a\x00"), utf16LE)
+	require.ErrorIs(t, err, gapbuffer.ErrInvalidEncoding)
+
+	str, err := gapbuffer.DecodeText([]byte("\x3d\xd8\x00\xde"), utf16LE)
+	require.NoError(t, err)
+	assert.Equal(t, "😀", str)
+
+	var unrepresentable *gapbuffer.UnrepresentableError
+
+	_, err = gapbuffer.EncodeText("a\xffb", utf16LE)
+	require.ErrorAs(t, err, &unrepresentable)
+	assert.Equal(t, []int{1}, unrepresentable.Offsets)
+
+	data, err := gapbuffer.EncodeText("a\xffb", gapbuffer.FileEncoding{Encoding: gapbuffer.EncodingUTF8, BOM: false})
+	require.NoError(t, err)
+	assert.Equal(t, "a\xffb", string(data))
+}
+
+func TestEncodingFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "utf16.txt")
+	data := "\xff\xfeH\x00i\x00\n\x00"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	gb, err := gapbuffer.LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Hi\n", gb.String())
+
+	gb.Insert("ü")
+	assert.True(t, gb.Modified())
+	require.NoError(t, gb.SaveFile(path))
+	assert.False(t, gb.Modified())
+
+	saved, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, data+"\xfc\x00", string(saved))
+
+	gb.Insert("😀")
+	gb.SetEncoding(gapbuffer.FileEncoding{Encoding: gapbuffer.EncodingLatin1, BOM: false})
+	require.Error(t, gb.SaveFile(path))
+	assert.True(t, gb.Modified())
+
+	saved, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, data+"\xfc\x00", string(saved))
+
+	_, err = gapbuffer.LoadFile(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+}
@@ -393,3 +393,83 @@ func ExampleGapBuffer_StringPair() {
 	// Output: left: 'Hello, World!
 	// ' |cursor| right: 'My name is John.'
 }
+
+func ExampleGapBuffer_DiffSaved() {
+	// Create a new gap buffer and set its text as the saved baseline.
+	gapBuffer := gap.NewStr("Hello, World!\n")
+	gapBuffer.MarkSaved()
+
+	gapBuffer.Insert("My name is John.\n")
+
+	// Print the changes since the last save as unified diff.
+	hunks := gapBuffer.DiffSaved(gap.DefaultDiffOptions)
+	fmt.Print(gap.UnifiedDiff("a/hello.txt", "b/hello.txt", hunks))
+	// Output: --- a/hello.txt
+	// +++ b/hello.txt
+	// @@ -1 +1,2 @@
+	//  Hello, World!
+	// +My name is John.
+}
+
+func ExampleGapBuffer_InsertAll() {
+	// Create a new gap buffer with the cursor at the start of the text.
+	gapBuffer := gap.NewStr("one\ntwo\nthree\n")
+	gapBuffer.MoveTo(0)
+
+	// Add cursors at the start of the second and third line.
+	gapBuffer.AddCursor(4)
+	gapBuffer.AddCursor(8)
+
+	// Insert the same text at every cursor.
+	gapBuffer.InsertAll("- ")
+	fmt.Print(gapBuffer.String())
+	fmt.Println(gapBuffer.Cursors())
+	// Output: - one
+	// - two
+	// - three
+	// [2 8 14]
+}
+
+func ExamplePieceTable() {
+	// Use a piece table or a gap buffer, depending on the size of the file.
+	var buffer gap.Buffer = gap.NewPieceTable("Hello, World!\nMy name is John.")
+
+	buffer.UpMv()
+	buffer.LeftDel()
+	buffer.Insert(".")
+	fmt.Println(buffer.String())
+	fmt.Println(buffer.LineCol())
+	// Output: Hello, World.
+	// My name is John.
+	// 1 13
+}
+
+func ExampleGap() {
+	// A gap buffer of the tokens of a line of code.
+	tokens := gap.NewGapSlice([]string{"x", ":=", "1"}, 0)
+
+	tokens.Insert(2, "(")
+	tokens.Insert(4, "+", "2", ")")
+	tokens.Delete(0, 2)
+	tokens.Insert(0, "y", "=")
+	fmt.Println(tokens.Elems(), tokens.Len())
+	// Output: [y = ( 1 + 2 )] 7
+}
+
+func ExampleRuneGapBuffer() {
+	// All offsets and columns of a rune gap buffer are in runes.
+	runeBuffer := gap.NewRuneStr("Grüße\n😀 smile")
+
+	runeBuffer.MoveToRune(3)
+	runeBuffer.Insert("ü")
+	fmt.Println(runeBuffer.String())
+	fmt.Println(runeBuffer.LineRuneCol())
+
+	// Convert to a gap buffer to get byte offsets.
+	gapBuffer := runeBuffer.GapBuffer()
+	fmt.Println(gapBuffer.LineCol())
+	// Output: Grüüße
+	// 😀 smile
+	// 1 4
+	// 1 6
+}
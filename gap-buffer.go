@@ -22,7 +22,14 @@
 //     string.
 //   - This implementation is not thread save
 //   - A gap buffer is not ideal for using multiple cursors, as that would
-//     involve multiple jumps and copying of data in the gap buffer
+//     involve multiple jumps and copying of data in the gap buffer. So edits
+//     at multiple cursors, see [GapBuffer.AddCursor], are applied in one pass
+//     from the first to the last cursor, moving the gap through the text once
+//   - Invalid UTF-8 is kept byte by byte, every invalid byte is handled like a
+//     rune of its own, see [InvalidUTF8Policy]
+//   - Movements and deletions at the start or the end of the text do nothing
+//     and offsets outside of the text are clamped. The variants ending in
+//     `Err`, like [GapBuffer.LeftMvErr], return an error instead
 //
 // A gap buffer is an array with a "gap" - unused elements in the array - at the
 // cursor position, where text is to be inserted and deleted.
@@ -35,10 +42,13 @@
 //	['H', 'e', 'l', 'l', 'o', 0, 0, 0, 0, 0, ' ', 'w', 'o', 'r', 'l', 'd', '!']
 //	  0    1    2    3    4  |     gap     |  5    6    7    8    9    10   11
 //
-// Movement in the gap buffer works by moving the start and end of the gap, same
-// with deletion of unicode runes in both directions.
+// Deletion of unicode runes in both directions works by moving the start and
+// end of the gap. Movement of the cursor does not move the gap, the gap is
+// moved to the cursor before the next change of the text, so only editing
+// copies data.
 //
-// Moving the cursor two runes to the left:
+// Moving the cursor two runes to the left and deleting a rune, the gap is moved
+// to the cursor first:
 //
 //	Hel|< gap start, the cursor position            gap end >|lo world!
 //
@@ -61,24 +71,26 @@
 //	  0    1    2   |   gap      |  3    4    5    6    7    8    9    10   11
 //
 // Implementation Detail:
-// The line lengths are stored in a another gap buffer, which is synchronized
-// with the gap buffer of the text itself.
+// The text is stored in a [Gap] of bytes, the line lengths are stored in
+// another [Gap] of ints, which is synchronized with the gap of the text itself.
 package gapbuffer
 
 import (
+	"bytes"
 	"strings"
 	"unicode/utf8"
 )
 
 // GapBuffer represents a gap buffer.
 type GapBuffer struct {
-	// The index in the gap buffer `GapBuffer.data` of the start of the gap.
-	// The position of the cursor.
-	start int
+	// The text, the gap is at the position of the cursor after the last change
+	// of the text.
+	text Gap[byte]
 
-	// The index in the gap buffer `GapBuffer.data` of the end of the gap.
-	// The position of the first unicode scalar point after the cursor.
-	end int
+	// The position of the cursor, the byte offset in the text - not in the
+	// array of `GapBuffer.text`. The gap is moved to the cursor before
+	// changing the text.
+	cursor int
 
 	// `wantsCol` is the rune column (not byte column!) the cursor wants to hold
 	// when going up or down.
@@ -89,8 +101,45 @@ type GapBuffer struct {
 	// See [lineBuffer].
 	lines lineBuffer
 
-	// The data of the gap buffer.
-	data []byte
+	// The functions to call on every change of the text, see
+	// [GapBuffer.AddEditFunc].
+	listeners []*editListener
+
+	// The number of the current operation, all edits made by one call of a
+	// public method have the same number. See [GapBuffer.beginOp].
+	op int
+
+	// The nesting depth of [GapBuffer.beginOp] calls.
+	opDepth int
+
+	// The text of the last save, see [GapBuffer.MarkSaved].
+	saved string
+
+	// The changes of the lines compared to `saved`, nil if not tracked. See
+	// [GapBuffer.TrackChanges].
+	changes *changeTracker
+
+	// The additional cursors, nil if there is only one cursor. See
+	// [GapBuffer.AddCursor].
+	cursors *cursorSet
+
+	// How to insert invalid UTF-8, see [GapBuffer.SetInvalidUTF8Policy].
+	utf8Policy InvalidUTF8Policy
+
+	// The encoding the text is saved in, see [GapBuffer.Encoding].
+	encoding FileEncoding
+
+	// Searches the lines of the text in the background, nil if all lines are
+	// in `lines`. See [NewStrIndexed].
+	indexer *lineIndexer
+
+	// The number of bytes at the end of the text whose lines are not in
+	// `lines` yet.
+	unindexed int
+
+	// The number of bytes copied by moving the gap and growing the text, see
+	// [GapBuffer.CopiedBytes].
+	copied int
 }
 
 const (
@@ -110,25 +159,19 @@ const (
 
 // Return the contents of the gap buffer as a string.
 func (g *GapBuffer) String() string {
-	var builder strings.Builder
-
-	builder.Grow(len(g.data) - (g.end - g.start))
-	builder.Write(g.data[:g.start])
-	builder.Write(g.data[g.end:])
-
-	return builder.String()
+	return g.slice(0, g.StringLength())
 }
 
 // Return the contents of the gap buffer as two strings. The part to the left of
 // the cursor is returned in `left` and the part to the right of the cursor is
 // returned in `right`.
 func (g *GapBuffer) StringPair() (left string, right string) {
-	return string(g.data[:g.start]), string(g.data[g.end:])
+	return g.slice(0, g.cursor), g.slice(g.cursor, g.StringLength())
 }
 
 // Return the length in bytes of the contents of the gap buffer.
 func (g *GapBuffer) StringLength() int {
-	return len(g.data) - (g.end - g.start)
+	return g.text.Len()
 }
 
 // Construct a new GapBuffer from a capacity. The capacity is the number of
@@ -140,11 +183,21 @@ func (g *GapBuffer) StringLength() int {
 // See also [New], [NewStr], [NewStrCap].
 func NewCap(size int) *GapBuffer {
 	return &GapBuffer{
-		start:    0,
-		end:      size,
-		wantsCol: 0,
-		data:     make([]byte, size),
-		lines:    *newLineBuf(size),
+		text:       *NewGap[byte](size),
+		cursor:     0,
+		wantsCol:   0,
+		lines:      *newLineBuf(size),
+		listeners:  nil,
+		op:         0,
+		opDepth:    0,
+		saved:      "",
+		changes:    nil,
+		cursors:    nil,
+		utf8Policy: KeepInvalidUTF8,
+		encoding:   FileEncoding{Encoding: EncodingUTF8, BOM: false},
+		indexer:    nil,
+		unindexed:  0,
+		copied:     0,
 	}
 }
 
@@ -180,26 +233,71 @@ func NewStrCap(str string, c int) *GapBuffer {
 	}
 
 	return &GapBuffer{
-		start:    sIdx,
-		end:      size,
-		wantsCol: runeCol,
-		data:     dat,
-		lines:    *lines,
+		text:       Gap[byte]{start: sIdx, end: size, data: dat},
+		cursor:     sIdx,
+		wantsCol:   runeCol,
+		lines:      *lines,
+		listeners:  nil,
+		op:         0,
+		opDepth:    0,
+		saved:      "",
+		changes:    nil,
+		cursors:    nil,
+		utf8Policy: KeepInvalidUTF8,
+		encoding:   FileEncoding{Encoding: EncodingUTF8, BOM: false},
+		indexer:    nil,
+		unindexed:  0,
+		copied:     0,
 	}
 }
 
 // Construct a new GapBuffer from a string. The cursor position is set to the
 // end of the string.
 //
-// See also [New], [NewCap], [NewStrCap].
+// See also [New], [NewCap], [NewStrCap], [NewStrIndexed].
 func NewStr(s string) *GapBuffer {
 	return NewStrCap(s, defaultCapacity)
 }
 
+// Construct a new GapBuffer from a string, with the cursor at the start of the
+// string. The lines of the string are searched in a background goroutine, so
+// the gap buffer of a huge text is ready at once. Methods which need lines that
+// have not been searched yet wait for them, [GapBuffer.LineCount] waits for all
+// lines.
+//
+// The function `progress` is called from the background goroutine with the
+// number of bytes searched so far and the length of the string, if it is not
+// nil.
+//
+// See also [NewStr].
+func NewStrIndexed(str string, progress func(indexed int, total int)) *GapBuffer {
+	if str == "" {
+		return NewStr(str)
+	}
+
+	g := NewCap(max(defaultCapacity, len(str)*growFactor))
+	g.text.end -= copy(g.text.data[g.text.end-len(str):], str)
+	g.indexer = newLineIndexer(str, progress)
+	g.unindexed = len(str)
+	g.index(0)
+
+	return g
+}
+
 // Return the current number of bytes in the buffer, including the "empty" space
 // in the gap.
 func (g *GapBuffer) Size() int {
-	return len(g.data)
+	return g.text.Cap()
+}
+
+// Return the number of bytes copied by moving the gap and growing the text
+// since the gap buffer has been constructed. Moving the gap copies the bytes
+// between the old and the new position of the gap, growing copies all bytes.
+// This is the main cost of editing in a gap buffer, meant for benchmarks.
+//
+// See also [GapBuffer.Size].
+func (g *GapBuffer) CopiedBytes() int {
+	return g.copied
 }
 
 // Return the byte column of the cursor, the number of bytes from the start of
@@ -209,17 +307,18 @@ func (g *GapBuffer) Size() int {
 //
 // See also [GapBuffer.RuneCol], [GapBuffer.LineCol], [GapBuffer.LineRuneCol].
 func (g *GapBuffer) Col() int {
-	return g.start - g.lines.curLineStart()
+	return g.cursor - g.lines.curLineStart()
 }
 
 // Return the rune column of the cursor, the number of unicode runes from the
-// start of the line to the cursor.
+// start of the line to the cursor. Every byte of invalid UTF-8 counts as one
+// rune, see [InvalidUTF8Policy].
 //
 // Numbering starts from 1.
 //
 // See also [GapBuffer.Col], [GapBuffer.LineCol], [GapBuffer.LineRuneCol].
 func (g *GapBuffer) RuneCol() int {
-	return utf8.RuneCount(g.data[g.lines.curLineStart():g.start])
+	return g.runeCount(g.lines.curLineStart(), g.cursor)
 }
 
 // Return the length of the current line the cursor is in, in bytes.
@@ -271,12 +370,19 @@ func (g *GapBuffer) LineRuneCol() (line int, runeCol int) {
 // See also [GapBuffer.RightDel], [GapBuffer.LeftMv], [GapBuffer.RightMv],
 // [GapBuffer.UpMv], [GapBuffer.DownMv].
 func (g *GapBuffer) LeftDel() {
-	if g.start < 1 {
+	if validateOps {
+		defer g.mustValidate()
+	}
+
+	if g.cursor < 1 {
 		return
 	}
 
-	r, rSize := utf8.DecodeLastRune(g.data[:g.start])
-	g.start -= rSize
+	g.moveGap(g.cursor)
+
+	r, rSize := g.runeBefore(g.cursor)
+	g.cursor -= rSize
+	g.text.Delete(g.cursor, g.cursor+rSize)
 
 	if r == '\n' {
 		g.lines.upDel()
@@ -285,6 +391,10 @@ func (g *GapBuffer) LeftDel() {
 	}
 
 	g.wantsCol = g.RuneCol()
+
+	if len(g.listeners) > 0 {
+		g.notify(Edit{Offset: g.cursor, Deleted: string(g.text.data[g.cursor : g.cursor+rSize]), Inserted: ""})
+	}
 }
 
 // Delete the unicode rune to the right of the cursor. Like the "delete" key.
@@ -292,18 +402,29 @@ func (g *GapBuffer) LeftDel() {
 // See also [GapBuffer.LeftDel], [GapBuffer.RightMv], [GapBuffer.LeftMv],
 // [GapBuffer.UpMv], [GapBuffer.DownMv].
 func (g *GapBuffer) RightDel() {
-	if g.end > len(g.data)-1 {
+	if validateOps {
+		defer g.mustValidate()
+	}
+
+	if g.cursor >= g.StringLength() {
 		return
 	}
 
-	r, rSize := utf8.DecodeRune(g.data[g.end:])
-	g.end += rSize
+	g.moveGap(g.cursor)
+
+	r, rSize := g.runeAt(g.cursor)
+	g.index(g.cursor + rSize)
+	g.text.Delete(g.cursor, g.cursor+rSize)
 
 	if r == '\n' {
 		g.lines.downDel()
 	} else {
 		g.lines.del(rSize)
 	}
+
+	if len(g.listeners) > 0 {
+		g.notify(Edit{Offset: g.cursor, Deleted: string(g.text.data[g.text.end-rSize : g.text.end]), Inserted: ""})
+	}
 }
 
 // Move the cursor one unicode rune to the left.
@@ -311,17 +432,18 @@ func (g *GapBuffer) RightDel() {
 // See also [GapBuffer.RightMv], [GapBuffer.LeftDel], [GapBuffer.RightDel],
 // [GapBuffer.UpMv], [GapBuffer.DownMv].
 func (g *GapBuffer) LeftMv() {
-	if g.start < 1 {
-		return
+	if validateOps {
+		defer g.mustValidate()
 	}
 
-	rChar, d := utf8.DecodeLastRune(g.data[:g.start])
-	g.end -= d
+	if g.cursor < 1 {
+		return
+	}
 
-	_ = copy(g.data[g.end:], g.data[g.start-d:g.start])
-	g.start -= d
+	r, d := g.runeBefore(g.cursor)
+	g.cursor -= d
 
-	if rChar == '\n' {
+	if r == '\n' {
 		g.lines.up()
 	}
 
@@ -333,14 +455,17 @@ func (g *GapBuffer) LeftMv() {
 // See also [GapBuffer.LeftMv], [GapBuffer.LeftDel], [GapBuffer.RightDel],
 // [GapBuffer.UpMv], [GapBuffer.DownMv].
 func (g *GapBuffer) RightMv() {
-	if g.end > len(g.data)-1 {
+	if validateOps {
+		defer g.mustValidate()
+	}
+
+	if g.cursor >= g.StringLength() {
 		return
 	}
 
-	r, d := utf8.DecodeRune(g.data[g.end:])
-	_ = copy(g.data[g.start:], g.data[g.end:g.end+d])
-	g.start += d
-	g.end += d
+	r, d := g.runeAt(g.cursor)
+	g.index(g.cursor + d)
+	g.cursor += d
 
 	if r == '\n' {
 		g.lines.down()
@@ -375,31 +500,16 @@ func (g *GapBuffer) RightMv() {
 // See also [GapBuffer.DownMv], [GapBuffer.LeftMv], [GapBuffer.RightMv],
 // [GapBuffer.LeftDel], [GapBuffer.RightDel].
 func (g *GapBuffer) UpMv() {
+	if validateOps {
+		defer g.mustValidate()
+	}
+
 	if g.lines.curLine() == 1 {
 		return
 	}
 
 	g.lines.up()
-	lineStart := g.lines.curLineStart()
-	newStart := lineStart
-	max := g.lines.curLineEnd()
-	runeCnt := 0
-
-	for idx := lineStart; idx < max+1; {
-		newStart = idx
-
-		if runeCnt == g.wantsCol {
-			break
-		}
-
-		_, d := utf8.DecodeRune(g.data[idx:])
-		idx += d
-		runeCnt++
-	}
-
-	g.end -= (g.start - newStart)
-	_ = copy(g.data[g.end:], g.data[newStart:g.start])
-	g.start = newStart
+	g.cursor = g.wantedOffset()
 }
 
 // Move the cursor down one line.
@@ -428,65 +538,388 @@ func (g *GapBuffer) UpMv() {
 // See also [GapBuffer.UpMv], [GapBuffer.LeftMv], [GapBuffer.RightMv],
 // [GapBuffer.LeftDel], [GapBuffer.RightDel].
 func (g *GapBuffer) DownMv() {
+	if validateOps {
+		defer g.mustValidate()
+	}
+
+	g.index(g.lines.curLineStart() + g.lines.curLineLength())
+
 	if g.lines.isLastLine() {
 		return
 	}
 
-	newLine := g.lines.curLineEnd() + 1 - g.start
-	idx := newLine
-	runeCnt := 0
-
-	for g.end+idx < len(g.data) && g.data[g.end+idx] != '\n' {
-		if runeCnt == g.wantsCol {
-			break
-		}
+	g.lines.down()
+	g.cursor = g.wantedOffset()
+}
 
-		_, d := utf8.DecodeRune(g.data[g.end+idx:])
-		if g.end+idx+d > len(g.data)-1 {
-			break
-		}
+// wantedOffset returns the offset of the rune column `wantsCol` in the current
+// line, or the end of the line, if the line is shorter.
+func (g *GapBuffer) wantedOffset() int {
+	pos := g.lines.curLineStart()
+	end := pos + g.lines.curLineLength()
 
-		idx += d
-		runeCnt++
+	if !g.lines.isLastLine() {
+		end--
 	}
 
-	_ = copy(g.data[g.start:], g.data[g.end:g.end+idx])
-	g.start += idx
-	g.end += idx
-
-	// if we are at the last line, we can move one step further to the right
-	if g.end == len(g.data)-1 && runeCnt < g.wantsCol {
-		g.data[g.start] = g.data[g.end]
-		g.start++
-		g.end++
+	for runeCnt := 0; runeCnt < g.wantsCol && pos < end; runeCnt++ {
+		_, d := g.runeAt(pos)
+		pos += d
 	}
 
-	g.lines.down()
-}
-
-// grow resizes the gap buffer by `growFactor` times its current size and copies
-// the existing data.
-func (g *GapBuffer) grow() {
-	tmp := make([]byte, len(g.data)*growFactor)
-	_ = copy(tmp, g.data[:g.start])
-	nE := len(tmp) - (len(g.data) - g.end)
-	_ = copy(tmp[nE:], g.data[g.end:])
-	g.end = nE
-	g.data = tmp
+	return pos
 }
 
 // Insert inserts the given string at the current cursor position.
 // The string can be a single unicode scalar point or text of arbitrary size and
 // anything in between (like a single unicode rune).
 //
-// The cursor is moved to the end of the inserted text.
+// The cursor is moved to the end of the inserted text. Invalid UTF-8 in the
+// string is handled according to the [InvalidUTF8Policy] of the gap buffer.
 func (g *GapBuffer) Insert(str string) {
-	if g.end-g.start < len(str)+1 {
-		g.grow()
+	str, ok := g.applyUTF8Policy(str)
+	if !ok {
+		return
+	}
+
+	g.insert(str)
+}
+
+// insert inserts the given string at the cursor like [GapBuffer.Insert], but
+// ignores the [InvalidUTF8Policy].
+func (g *GapBuffer) insert(str string) {
+	if validateOps {
+		defer g.mustValidate()
+	}
+
+	g.moveGap(g.cursor)
+	g.reserve(len(str) + 1)
+
+	g.lines.insert(str, g.text.start)
+	l := copy(g.text.data[g.text.start:], str)
+	g.text.start += l
+	g.cursor = g.text.start
+	g.wantsCol = g.RuneCol()
+
+	if len(g.listeners) > 0 && str != "" {
+		g.notify(Edit{Offset: g.text.start - l, Deleted: "", Inserted: str})
+	}
+}
+
+// Return the byte offset of the cursor from the start of the text. This is the
+// number of bytes to the left of the cursor.
+//
+// Numbering starts from 0.
+//
+// See also [GapBuffer.MoveTo], [GapBuffer.Col], [GapBuffer.LineCol].
+func (g *GapBuffer) Offset() int {
+	return g.cursor
+}
+
+// Return the number of lines in the gap buffer. An empty gap buffer has one
+// (empty) line, text ending in a newline has an empty last line.
+//
+// See also [GapBuffer.Line].
+func (g *GapBuffer) LineCount() int {
+	g.index(g.StringLength())
+
+	return g.lines.lineCount()
+}
+
+// Move the cursor to the given byte offset from the start of the text.
+//
+// Offsets less than 0 move the cursor to the start of the text, offsets
+// greater than [GapBuffer.StringLength] to the end of the text. The offset
+// should be at the start of a unicode rune, else the cursor ends up in the
+// middle of a rune.
+//
+// See also [GapBuffer.Offset], [GapBuffer.LeftMv], [GapBuffer.RightMv].
+func (g *GapBuffer) MoveTo(offset int) {
+	if validateOps {
+		defer g.mustValidate()
 	}
 
-	g.lines.insert(str, g.start)
-	l := copy(g.data[g.start:], str)
-	g.start += l
+	g.setCursor(g.clamp(offset))
 	g.wantsCol = g.RuneCol()
 }
+
+// Delete the text between the byte offsets `from` (inclusive) and `to`
+// (exclusive). The cursor is moved to `from`.
+//
+// Offsets are clamped to the text, if `to` is less than `from`, the two are
+// swapped.
+//
+// See also [GapBuffer.ReplaceRange], [GapBuffer.LeftDel],
+// [GapBuffer.RightDel].
+func (g *GapBuffer) DeleteRange(from int, to int) {
+	if validateOps {
+		defer g.mustValidate()
+	}
+
+	from, to = g.clamp(from), g.clamp(to)
+	if to < from {
+		from, to = to, from
+	}
+
+	g.setCursor(from)
+	g.index(to)
+
+	if to == from {
+		g.wantsCol = g.RuneCol()
+
+		return
+	}
+
+	g.moveGap(from)
+
+	n := to - from
+	g.lines.delRight(n, g.countNewlines(from, to))
+	g.text.Delete(from, to)
+	g.wantsCol = g.RuneCol()
+
+	if len(g.listeners) > 0 {
+		g.notify(Edit{Offset: from, Deleted: string(g.text.data[g.text.end-n : g.text.end]), Inserted: ""})
+	}
+}
+
+// Replace the text between the byte offsets `from` (inclusive) and `to`
+// (exclusive) with the given string. The cursor is moved to the end of the
+// inserted text.
+//
+// Offsets are handled like in [GapBuffer.DeleteRange].
+//
+// See also [GapBuffer.DeleteRange], [GapBuffer.Insert].
+func (g *GapBuffer) ReplaceRange(from int, to int, str string) {
+	str, ok := g.applyUTF8Policy(str)
+	if !ok {
+		return
+	}
+
+	g.replaceRange(from, to, str)
+}
+
+// replaceRange replaces the text between the byte offsets like
+// [GapBuffer.ReplaceRange], but ignores the [InvalidUTF8Policy].
+func (g *GapBuffer) replaceRange(from int, to int, str string) {
+	g.beginOp()
+	defer g.endOp()
+
+	g.DeleteRange(from, to)
+	g.insert(str)
+}
+
+// MarkSaved sets the current text as the saved baseline, call this after
+// loading or saving the text. The baseline of a new gap buffer is the empty
+// text.
+//
+// See also [GapBuffer.Saved], [GapBuffer.Modified], [GapBuffer.DiffSaved].
+func (g *GapBuffer) MarkSaved() {
+	g.saved = g.String()
+	g.resetChanges()
+}
+
+// Return the saved baseline, the text at the last call of
+// [GapBuffer.MarkSaved].
+func (g *GapBuffer) Saved() string {
+	return g.saved
+}
+
+// Return true if the text differs from the saved baseline.
+//
+// See also [GapBuffer.MarkSaved].
+func (g *GapBuffer) Modified() bool {
+	if g.StringLength() != len(g.saved) {
+		return true
+	}
+
+	return string(g.text.data[:g.text.start]) != g.saved[:g.text.start] ||
+		string(g.text.data[g.text.end:]) != g.saved[g.text.start:]
+}
+
+// clamp returns the given offset clamped to the range of valid offsets,
+// 0 to [GapBuffer.StringLength].
+func (g *GapBuffer) clamp(offset int) int {
+	return min(max(offset, 0), g.StringLength())
+}
+
+// setCursor moves the cursor to the given offset in the text, without moving
+// the gap. The offset must be valid.
+func (g *GapBuffer) setCursor(offset int) {
+	g.index(offset)
+
+	switch {
+	case offset < g.cursor:
+		for nl := g.countNewlines(offset, g.cursor); nl > 0; nl-- {
+			g.lines.up()
+		}
+
+	case offset > g.cursor:
+		for nl := g.countNewlines(g.cursor, offset); nl > 0; nl-- {
+			g.lines.down()
+		}
+	}
+
+	g.cursor = offset
+}
+
+// moveGap moves the gap of the text to the given offset and counts the copied
+// bytes.
+func (g *GapBuffer) moveGap(offset int) {
+	g.copied += max(offset-g.text.start, g.text.start-offset)
+	g.text.MoveGap(offset)
+}
+
+// reserve makes room for at least `n` bytes in the gap of the text like
+// [Gap.Reserve] and counts the copied bytes.
+func (g *GapBuffer) reserve(n int) {
+	for g.text.end-g.text.start < n {
+		g.copied += g.text.Len()
+		g.text.grow()
+	}
+}
+
+// index waits for the lines of the text up to the end of the line containing
+// the given offset and adds them to `lines`, see [NewStrIndexed].
+func (g *GapBuffer) index(offset int) {
+	for g.indexer != nil && g.StringLength()-g.unindexed <= offset {
+		g.addIndexed()
+	}
+}
+
+// indexLine waits for the lines of the text up to the line with the given
+// index and adds them to `lines`, see [NewStrIndexed].
+func (g *GapBuffer) indexLine(idx int) {
+	for g.indexer != nil && g.lines.lineCount() <= idx+1 {
+		g.addIndexed()
+	}
+}
+
+// addIndexed waits for the background goroutine to search more lines of the
+// text and adds them to `lines`.
+func (g *GapBuffer) addIndexed() {
+	lens, done := g.indexer.take()
+	g.lines.appendLengths(lens)
+
+	for _, n := range lens {
+		g.unindexed -= n
+	}
+
+	if done {
+		g.indexer = nil
+	}
+}
+
+// byteAt returns the byte at the given offset in the text. The offset must be
+// valid.
+func (g *GapBuffer) byteAt(offset int) byte {
+	return g.text.At(offset)
+}
+
+// runeAt returns the unicode rune starting at the given offset in the text and
+// its size in bytes. The offset must be valid.
+func (g *GapBuffer) runeAt(offset int) (rune, int) {
+	if offset < g.text.start {
+		if g.text.start-offset < utf8.UTFMax {
+			return g.decodeSplit(offset, min(offset+utf8.UTFMax, g.StringLength()), utf8.DecodeRune)
+		}
+
+		return utf8.DecodeRune(g.text.data[offset:g.text.start])
+	}
+
+	return utf8.DecodeRune(g.text.data[offset+g.text.end-g.text.start:])
+}
+
+// runeBefore returns the unicode rune ending at the given offset in the text
+// and its size in bytes. The offset must be valid.
+func (g *GapBuffer) runeBefore(offset int) (rune, int) {
+	if offset <= g.text.start {
+		return utf8.DecodeLastRune(g.text.data[:offset])
+	}
+
+	if offset-g.text.start < utf8.UTFMax {
+		return g.decodeSplit(max(offset-utf8.UTFMax, 0), offset, utf8.DecodeLastRune)
+	}
+
+	return utf8.DecodeLastRune(g.text.data[g.text.end : offset+g.text.end-g.text.start])
+}
+
+// decodeSplit decodes a unicode rune in the bytes between the byte offsets
+// `from` (inclusive) and `to` (exclusive), which may be split by the gap, using
+// the given decoding function. The offsets must be valid and at most
+// [utf8.UTFMax] bytes apart.
+func (g *GapBuffer) decodeSplit(from int, to int, decode func([]byte) (rune, int)) (rune, int) {
+	var buf [utf8.UTFMax]byte
+
+	left, right := g.text.Slices(from, to)
+	n := copy(buf[:], left)
+	n += copy(buf[n:], right)
+
+	return decode(buf[:n])
+}
+
+// splitRune returns the number of bytes before and after the gap of a valid
+// unicode rune which is split by the gap, or 0 and 0 if the gap is not in the
+// middle of a valid rune. This happens if the text has been changed with the
+// cursor in the middle of a rune.
+func (g *GapBuffer) splitRune() (before int, after int) {
+	start := g.text.start
+
+	for k := 1; k < utf8.UTFMax && k <= start; k++ {
+		if utf8.RuneStart(g.text.data[start-k]) {
+			if r, size := g.runeAt(start - k); size > k && (r != utf8.RuneError || size > 1) {
+				return k, size - k
+			}
+
+			return 0, 0
+		}
+	}
+
+	return 0, 0
+}
+
+// runeCount returns the number of unicode runes between the byte offsets
+// `from` (inclusive) and `to` (exclusive). The offsets must be valid. Every
+// byte of invalid UTF-8 counts as one rune.
+func (g *GapBuffer) runeCount(from int, to int) int {
+	left, right := g.text.Slices(from, to)
+	count := utf8.RuneCount(left) + utf8.RuneCount(right)
+
+	if len(left) > 0 && len(right) > 0 {
+		// The bytes of a rune split by the gap have been counted as invalid
+		// bytes.
+		if before, after := g.splitRune(); before > 0 && before <= len(left) && after <= len(right) {
+			count -= before + after - 1
+		}
+	}
+
+	return count
+}
+
+// countNewlines returns the number of newline characters between the byte
+// offsets `from` (inclusive) and `to` (exclusive). The offsets must be valid.
+func (g *GapBuffer) countNewlines(from int, to int) int {
+	left, right := g.text.Slices(from, to)
+
+	return bytes.Count(left, []byte{'\n'}) + bytes.Count(right, []byte{'\n'})
+}
+
+// slice returns the text between the byte offsets `from` (inclusive) and `to`
+// (exclusive) as a string. The offsets must be valid.
+func (g *GapBuffer) slice(from int, to int) string {
+	left, right := g.text.Slices(from, to)
+	if right == nil {
+		return string(left)
+	}
+
+	if left == nil {
+		return string(right)
+	}
+
+	var builder strings.Builder
+
+	builder.Grow(to - from)
+	builder.Write(left)
+	builder.Write(right)
+
+	return builder.String()
+}
@@ -0,0 +1,404 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     gap-buffer_bench_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+)
+
+// largeText returns a text of 100,000 lines of different lengths, about 4 MB.
+func largeText() string {
+	var builder strings.Builder
+
+	for idx := 0; idx < 100_000; idx++ {
+		builder.WriteString(strings.Repeat("abcdefgh", idx%10))
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+func BenchmarkNavigateLines(b *testing.B) {
+	gb := gapbuffer.NewStr(largeText())
+	gb.MoveTo(0)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for gb.Line() < gb.LineCount() {
+			gb.DownMv()
+		}
+
+		for gb.Line() > 1 {
+			gb.UpMv()
+		}
+	}
+}
+
+func BenchmarkNavigateRunes(b *testing.B) {
+	gb := gapbuffer.NewStr(largeText())
+	gb.MoveTo(gb.StringLength() / 2)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 10_000; j++ {
+			gb.RightMv()
+		}
+
+		for j := 0; j < 10_000; j++ {
+			gb.LeftMv()
+		}
+	}
+}
+
+func BenchmarkNavigateJump(b *testing.B) {
+	gb := gapbuffer.NewStr(largeText())
+	length := gb.StringLength()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		gb.MoveTo(0)
+		gb.MoveTo(length)
+	}
+}
+
+func BenchmarkNavigateEdit(b *testing.B) {
+	gb := gapbuffer.NewStr(largeText())
+	gb.MoveTo(0)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 1_000; j++ {
+			gb.DownMv()
+		}
+
+		gb.Insert("x")
+		gb.LeftDel()
+
+		if gb.Line() == gb.LineCount() {
+			gb.MoveTo(0)
+		}
+	}
+}
+
+func BenchmarkNavigateEditJump(b *testing.B) {
+	gb := gapbuffer.NewStr(largeText())
+	length := gb.StringLength()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		gb.MoveTo(0)
+		gb.Insert("x")
+		gb.MoveTo(length)
+		gb.Insert("x")
+		gb.LeftDel()
+		gb.MoveTo(0)
+		gb.RightDel()
+	}
+}
+
+func BenchmarkNewStr(b *testing.B) {
+	text := largeText()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		gb := gapbuffer.NewStr(text)
+		gb.MoveTo(0)
+	}
+}
+
+// Benchmark the time until the first screen can be shown.
+func BenchmarkNewStrIndexed(b *testing.B) {
+	text := largeText()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		gb := gapbuffer.NewStrIndexed(text, nil)
+		for j := 0; j < 50; j++ {
+			gb.DownMv()
+		}
+	}
+}
+
+// The comparative benchmarks of all [gapbuffer.Buffer] implementations.
+
+func BenchmarkTyping(b *testing.B) {
+	text := largeText()
+
+	for _, buffer := range buffers {
+		b.Run(buffer.name, func(b *testing.B) {
+			buf := buffer.newStr(text)
+			buf.MoveTo(len(text) / 2)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if i%80 == 79 {
+					buf.Insert("\n")
+				} else {
+					buf.Insert("x")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRandomEdits(b *testing.B) {
+	text := largeText()
+
+	for _, buffer := range buffers {
+		b.Run(buffer.name, func(b *testing.B) {
+			rnd := rand.New(rand.NewSource(42)) //nolint:gosec // benchmark
+			buf := buffer.newStr(text)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				from := rnd.Intn(buf.StringLength())
+				buf.ReplaceRange(from, from+rnd.Intn(10), "edit\n")
+			}
+		})
+	}
+}
+
+func BenchmarkLineJumps(b *testing.B) {
+	text := largeText()
+
+	for _, buffer := range buffers {
+		b.Run(buffer.name, func(b *testing.B) {
+			rnd := rand.New(rand.NewSource(42)) //nolint:gosec // benchmark
+			buf := buffer.newStr(text)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				buf.MoveTo(rnd.Intn(len(text)))
+				_, _ = buf.LineCol()
+			}
+		})
+	}
+}
+
+func BenchmarkRuneCol(b *testing.B) {
+	text := strings.Repeat("äöü ", 2_500)
+
+	b.Run("GapBuffer", func(b *testing.B) {
+		gb := gapbuffer.NewStr(text)
+
+		for i := 0; i < b.N; i++ {
+			_ = gb.RuneCol()
+		}
+	})
+
+	b.Run("RuneGapBuffer", func(b *testing.B) {
+		rb := gapbuffer.NewRuneStr(text)
+
+		for i := 0; i < b.N; i++ {
+			_ = rb.RuneCol()
+		}
+	})
+}
+
+// The benchmarks replaying editing traces, see [gapbuffer.Trace]. Every trace
+// in testdata/traces is replayed by BenchmarkTraceFiles, record your own
+// sessions with [gapbuffer.RecordTrace] and add them there.
+
+// typingTrace returns the trace of typing a document of 2,000 lines, with a
+// typo corrected every 50 runes.
+func typingTrace() *gapbuffer.Trace {
+	line := "The quick brown fox jumps over the lazy dog, äöü 😀"
+	trace := &gapbuffer.Trace{Text: "", Ops: nil}
+
+	for idx := 0; idx < 2_000; idx++ {
+		for _, r := range line {
+			trace.Ops = append(trace.Ops, gapbuffer.TraceOp{
+				Kind: gapbuffer.TraceInsert, Count: 1, From: 0, To: 0, Text: string(r),
+			})
+		}
+
+		trace.Ops = append(trace.Ops,
+			gapbuffer.TraceOp{Kind: gapbuffer.TraceLeftDel, Count: 3, From: 0, To: 0, Text: ""},
+			gapbuffer.TraceOp{Kind: gapbuffer.TraceInsert, Count: 1, From: 0, To: 0, Text: "ü 😀\n"})
+	}
+
+	return trace
+}
+
+// randomEditsTrace returns the trace of 1,000 small edits at random places of
+// the given text.
+func randomEditsTrace(text string) *gapbuffer.Trace {
+	rnd := rand.New(rand.NewSource(42)) //nolint:gosec // benchmark
+	trace := &gapbuffer.Trace{Text: text, Ops: nil}
+	length := len(text)
+
+	for idx := 0; idx < 1_000; idx++ {
+		from := rnd.Intn(length)
+		trace.Ops = append(trace.Ops,
+			gapbuffer.TraceOp{Kind: gapbuffer.TraceMoveTo, Count: 1, From: from, To: 0, Text: ""},
+			gapbuffer.TraceOp{Kind: gapbuffer.TraceRightDel, Count: 1 + rnd.Intn(5), From: 0, To: 0, Text: ""},
+			gapbuffer.TraceOp{Kind: gapbuffer.TraceInsert, Count: 1, From: 0, To: 0, Text: "edit\n"})
+		length += 5
+	}
+
+	return trace
+}
+
+// largePastesTrace returns the trace of pasting and deleting 64 KB at 100
+// random places of the given text.
+func largePastesTrace(text string) *gapbuffer.Trace {
+	rnd := rand.New(rand.NewSource(42)) //nolint:gosec // benchmark
+	paste := strings.Repeat("pasted text\n", 64*1024/12)
+	trace := &gapbuffer.Trace{Text: text, Ops: nil}
+
+	for idx := 0; idx < 100; idx++ {
+		from := rnd.Intn(len(text))
+		trace.Ops = append(trace.Ops,
+			gapbuffer.TraceOp{Kind: gapbuffer.TraceMoveTo, Count: 1, From: from, To: 0, Text: ""},
+			gapbuffer.TraceOp{Kind: gapbuffer.TraceInsert, Count: 1, From: 0, To: 0, Text: paste})
+
+		if idx%2 == 1 {
+			trace.Ops = append(trace.Ops, gapbuffer.TraceOp{
+				Kind: gapbuffer.TraceDeleteRange, Count: 1, From: from, To: from + len(paste), Text: "",
+			})
+		}
+	}
+
+	return trace
+}
+
+// millionLines returns a text of 1,000,000 lines of different lengths.
+func millionLines() string {
+	var builder strings.Builder
+
+	for idx := 0; idx < 1_000_000; idx++ {
+		builder.WriteString(strings.Repeat("abcd", idx%20))
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+// navigateTrace returns the trace of paging through the given text of a
+// million lines, down to the end and up to the start again, editing a line
+// every 100,000 lines.
+func navigateTrace(text string) *gapbuffer.Trace {
+	trace := &gapbuffer.Trace{Text: text, Ops: nil}
+
+	for _, kind := range []gapbuffer.TraceOpKind{gapbuffer.TraceDownMv, gapbuffer.TraceUpMv} {
+		for idx := 0; idx < 10; idx++ {
+			trace.Ops = append(trace.Ops,
+				gapbuffer.TraceOp{Kind: kind, Count: 100_000, From: 0, To: 0, Text: ""},
+				gapbuffer.TraceOp{Kind: gapbuffer.TraceInsert, Count: 1, From: 0, To: 0, Text: "x"})
+		}
+	}
+
+	return trace
+}
+
+// lineJumpsTrace returns the trace of jumping to 200 random places of the
+// given text and typing there.
+func lineJumpsTrace(text string) *gapbuffer.Trace {
+	rnd := rand.New(rand.NewSource(42)) //nolint:gosec // benchmark
+	trace := &gapbuffer.Trace{Text: text, Ops: nil}
+
+	for idx := 0; idx < 200; idx++ {
+		trace.Ops = append(trace.Ops,
+			gapbuffer.TraceOp{Kind: gapbuffer.TraceMoveTo, Count: 1, From: rnd.Intn(len(text)), To: 0, Text: ""},
+			gapbuffer.TraceOp{Kind: gapbuffer.TraceDownMv, Count: 1 + rnd.Intn(3), From: 0, To: 0, Text: ""},
+			gapbuffer.TraceOp{Kind: gapbuffer.TraceInsert, Count: 1, From: 0, To: 0, Text: "x"})
+	}
+
+	return trace
+}
+
+// benchmarkTrace replays the trace with every [gapbuffer.Buffer]
+// implementation. Reports the allocations and, for the gap buffer, the bytes
+// copied by moving the gap per replay of the trace.
+func benchmarkTrace(b *testing.B, trace *gapbuffer.Trace) {
+	b.Helper()
+
+	for _, buffer := range buffers {
+		b.Run(buffer.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			copied := 0
+
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+
+				buf := buffer.newStr(trace.Text)
+				buf.MoveTo(0)
+
+				b.StartTimer()
+				trace.Replay(buf)
+
+				if gb, ok := buf.(*gapbuffer.GapBuffer); ok {
+					copied += gb.CopiedBytes()
+				}
+			}
+
+			if buffer.name == "GapBuffer" {
+				b.ReportMetric(float64(copied)/float64(b.N), "copied-B/op")
+			}
+		})
+	}
+}
+
+func BenchmarkTraces(b *testing.B) {
+	text := largeText()
+	lines := millionLines()
+
+	traces := []struct {
+		name  string
+		trace func() *gapbuffer.Trace
+	}{
+		{"Typing", typingTrace},
+		{"RandomEdits", func() *gapbuffer.Trace { return randomEditsTrace(text) }},
+		{"LargePastes", func() *gapbuffer.Trace { return largePastesTrace(text) }},
+		{"NavigateMillionLines", func() *gapbuffer.Trace { return navigateTrace(lines) }},
+		{"LineJumps", func() *gapbuffer.Trace { return lineJumpsTrace(lines) }},
+	}
+
+	for _, tt := range traces {
+		trace := tt.trace()
+
+		b.Run(tt.name, func(b *testing.B) {
+			benchmarkTrace(b, trace)
+		})
+	}
+}
+
+// Replay every trace in testdata/traces.
+func BenchmarkTraceFiles(b *testing.B) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "traces", "*.trace"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		trace, err := gapbuffer.ParseTrace(file)
+		file.Close()
+
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(strings.TrimSuffix(filepath.Base(path), ".trace"), func(b *testing.B) {
+			benchmarkTrace(b, trace)
+		})
+	}
+}
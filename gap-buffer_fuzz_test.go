@@ -0,0 +1,289 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     gap-buffer_fuzz_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+)
+
+// The strings inserted by the fuzz targets, the string of the fuzz input is
+// added to them.
+var fuzzInserts = []string{
+	"a", "xyz", "\n", "\n\n", "ü", "😀", "日本\n語", "a\nb\n", "\r\n", "",
+}
+
+// The operations of the fuzz targets. The first byte of an operation selects
+// it, [opInsert] uses the next byte to select the string to insert.
+const (
+	opInsert = iota
+	opLeftMv
+	opRightMv
+	opUpMv
+	opDownMv
+	opLeftDel
+	opRightDel
+	opCount
+)
+
+// refModel is the naive reference model of a gap buffer: the text as a string
+// and the cursor as a byte offset into it.
+type refModel struct {
+	text     string
+	cursor   int
+	wantsCol int
+}
+
+func newRefModel(text string) *refModel {
+	m := &refModel{text: text, cursor: len(text), wantsCol: 0}
+	m.wantsCol = m.runeCol()
+
+	return m
+}
+
+func (m *refModel) lineStart() int {
+	return strings.LastIndexByte(m.text[:m.cursor], '\n') + 1
+}
+
+func (m *refModel) lineEnd() int {
+	if nl := strings.IndexByte(m.text[m.cursor:], '\n'); nl >= 0 {
+		return m.cursor + nl
+	}
+
+	return len(m.text)
+}
+
+func (m *refModel) line() int {
+	return strings.Count(m.text[:m.cursor], "\n") + 1
+}
+
+func (m *refModel) runeCol() int {
+	return utf8.RuneCountInString(m.text[m.lineStart():m.cursor])
+}
+
+func (m *refModel) lineLength() int {
+	return m.lineEnd() - m.lineStart()
+}
+
+// moveToWantedCol moves the cursor from the start of the line to the wanted
+// rune column or the end of the line.
+func (m *refModel) moveToWantedCol() {
+	end := m.lineEnd()
+
+	for col := 0; col < m.wantsCol && m.cursor < end; col++ {
+		_, size := utf8.DecodeRuneInString(m.text[m.cursor:])
+		m.cursor += size
+	}
+}
+
+func (m *refModel) apply(op byte, insert string) {
+	switch op {
+	case opInsert:
+		m.text = m.text[:m.cursor] + insert + m.text[m.cursor:]
+		m.cursor += len(insert)
+		m.wantsCol = m.runeCol()
+
+	case opLeftMv, opLeftDel:
+		if m.cursor == 0 {
+			return
+		}
+
+		_, size := utf8.DecodeLastRuneInString(m.text[:m.cursor])
+		m.cursor -= size
+
+		if op == opLeftDel {
+			m.text = m.text[:m.cursor] + m.text[m.cursor+size:]
+		}
+
+		m.wantsCol = m.runeCol()
+
+	case opRightMv:
+		if m.cursor == len(m.text) {
+			return
+		}
+
+		_, size := utf8.DecodeRuneInString(m.text[m.cursor:])
+		m.cursor += size
+		m.wantsCol = m.runeCol()
+
+	case opRightDel:
+		if m.cursor == len(m.text) {
+			return
+		}
+
+		_, size := utf8.DecodeRuneInString(m.text[m.cursor:])
+		m.text = m.text[:m.cursor] + m.text[m.cursor+size:]
+
+	case opUpMv:
+		start := m.lineStart()
+		if start == 0 {
+			return
+		}
+
+		m.cursor = start - 1
+		m.cursor = m.lineStart()
+		m.moveToWantedCol()
+
+	case opDownMv:
+		end := m.lineEnd()
+		if end == len(m.text) {
+			return
+		}
+
+		m.cursor = end + 1
+		m.moveToWantedCol()
+	}
+}
+
+// applyOp applies the operation to the gap buffer.
+func applyOp(gb *gapbuffer.GapBuffer, op byte, insert string) {
+	switch op {
+	case opInsert:
+		gb.Insert(insert)
+	case opLeftMv:
+		gb.LeftMv()
+	case opRightMv:
+		gb.RightMv()
+	case opUpMv:
+		gb.UpMv()
+	case opDownMv:
+		gb.DownMv()
+	case opLeftDel:
+		gb.LeftDel()
+	case opRightDel:
+		gb.RightDel()
+	}
+}
+
+// checkModel compares the gap buffer to the reference model.
+func checkModel(t *testing.T, gb *gapbuffer.GapBuffer, m *refModel, step int) {
+	t.Helper()
+
+	if got := gb.String(); got != m.text {
+		t.Fatalf("step %d: String() = %q, want %q", step, got, m.text)
+	}
+
+	if left, right := gb.StringPair(); left != m.text[:m.cursor] || right != m.text[m.cursor:] {
+		t.Fatalf("step %d: StringPair() = %q, %q, want %q, %q",
+			step, left, right, m.text[:m.cursor], m.text[m.cursor:])
+	}
+
+	if line, col := gb.LineCol(); line != m.line() || col != m.cursor-m.lineStart() {
+		t.Fatalf("step %d: LineCol() = %d, %d, want %d, %d",
+			step, line, col, m.line(), m.cursor-m.lineStart())
+	}
+
+	if got := gb.RuneCol(); got != m.runeCol() {
+		t.Fatalf("step %d: RuneCol() = %d, want %d", step, got, m.runeCol())
+	}
+
+	if got := gb.LineLength(); got != m.lineLength() {
+		t.Fatalf("step %d: LineLength() = %d, want %d", step, got, m.lineLength())
+	}
+
+	if err := gb.Validate(); err != nil {
+		t.Fatalf("step %d: %v\n%s", step, err, gb.DebugString())
+	}
+}
+
+// FuzzGapBuffer applies the operations encoded in `ops` to a gap buffer of
+// the text `text` and to the reference model and compares them after every
+// step. The string `insert` is inserted by [opInsert] too.
+func FuzzGapBuffer(f *testing.F) {
+	f.Add("", []byte{opInsert, 6, opUpMv, opInsert, 4, opDownMv, opLeftDel}, "")
+	f.Add("Hello\nworld", []byte{opUpMv, opRightMv, opDownMv, opRightDel, opLeftMv, opLeftDel}, "!")
+	f.Add("Grüße\n😀 smile\n", []byte{opUpMv, opUpMv, opDownMv, opDownMv, opLeftDel, opLeftDel}, "\xe2\x82")
+	f.Add("a\xffb\xe2\x82c\n\xc3", []byte{opLeftMv, opLeftMv, opUpMv, opRightDel, opInsert, 10}, "\xac")
+
+	f.Fuzz(func(t *testing.T, text string, ops []byte, insert string) {
+		gb := gapbuffer.NewStrCap(text, 4)
+		m := newRefModel(text)
+		inserts := append(fuzzInserts, insert)
+
+		checkModel(t, gb, m, 0)
+
+		for idx := 0; idx < len(ops); idx++ {
+			op := ops[idx] % opCount
+			str := ""
+
+			if op == opInsert && idx+1 < len(ops) {
+				idx++
+				str = inserts[int(ops[idx])%len(inserts)]
+			}
+
+			applyOp(gb, op, str)
+			m.apply(op, str)
+			checkModel(t, gb, m, idx+1)
+		}
+	})
+}
+
+// FuzzRuneGapBuffer applies the operations like [FuzzGapBuffer] to a
+// [gapbuffer.RuneGapBuffer] and compares it to a gap buffer. The text and the
+// inserted string are valid UTF-8, as invalid bytes completed to a valid rune
+// by an insertion are a single rune in a gap buffer, but not in a rune gap
+// buffer.
+func FuzzRuneGapBuffer(f *testing.F) {
+	f.Add("", []byte{opInsert, 6, opUpMv, opInsert, 4, opDownMv, opLeftDel}, "")
+	f.Add("Grüße\n😀 smile\n", []byte{opUpMv, opUpMv, opDownMv, opDownMv, opLeftDel, opRightMv}, "ö\n")
+
+	f.Fuzz(func(t *testing.T, text string, ops []byte, insert string) {
+		text, insert = strings.ToValidUTF8(text, "?"), strings.ToValidUTF8(insert, "?")
+		gb := gapbuffer.NewStr(text)
+		rb := gapbuffer.NewRuneStr(text)
+		inserts := append(fuzzInserts, insert)
+
+		for idx := 0; idx < len(ops); idx++ {
+			op := ops[idx] % opCount
+			str := ""
+
+			if op == opInsert && idx+1 < len(ops) {
+				idx++
+				str = inserts[int(ops[idx])%len(inserts)]
+			}
+
+			applyOp(gb, op, str)
+			applyRuneOp(rb, op, str)
+
+			left, right := rb.StringPair()
+			if wantLeft, wantRight := gb.StringPair(); left != wantLeft || right != wantRight {
+				t.Fatalf("step %d: StringPair() = %q, %q, want %q, %q", idx, left, right, wantLeft, wantRight)
+			}
+
+			line, runeCol := rb.LineRuneCol()
+			if wantLine, wantCol := gb.LineRuneCol(); line != wantLine || runeCol != wantCol {
+				t.Fatalf("step %d: LineRuneCol() = %d, %d, want %d, %d", idx, line, runeCol, wantLine, wantCol)
+			}
+		}
+	})
+}
+
+// applyRuneOp applies the operation to the rune gap buffer.
+func applyRuneOp(rb *gapbuffer.RuneGapBuffer, op byte, insert string) {
+	switch op {
+	case opInsert:
+		rb.Insert(insert)
+	case opLeftMv:
+		rb.LeftMv()
+	case opRightMv:
+		rb.RightMv()
+	case opUpMv:
+		rb.UpMv()
+	case opDownMv:
+		rb.DownMv()
+	case opLeftDel:
+		rb.LeftDel()
+	case opRightDel:
+		rb.RightDel()
+	}
+}
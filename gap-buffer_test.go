@@ -17,197 +17,335 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// The implementations of [gapbuffer.Buffer] to run the tests with.
+var buffers = []struct { //nolint:gochecknoglobals // test table
+	name   string
+	newStr func(s string) gapbuffer.Buffer
+}{
+	{name: "GapBuffer", newStr: func(s string) gapbuffer.Buffer { return gapbuffer.NewStr(s) }},
+	{name: "PieceTable", newStr: func(s string) gapbuffer.Buffer { return gapbuffer.NewPieceTable(s) }},
+	{name: "Rope", newStr: func(s string) gapbuffer.Buffer { return gapbuffer.NewRope(s) }},
+}
+
 // ==============================================================================
 //                       Simple Sanity Checks
 
 func TestEmpty(t *testing.T) {
 	t.Parallel()
 
-	gb := gapbuffer.New()
-	strLen := gb.StringLength()
+	for _, buffer := range buffers {
+		buffer := buffer
+
+		t.Run(buffer.name, func(t *testing.T) {
+			t.Parallel()
 
-	assert.Equal(t, "", gb.String(), "Error, empty gap buffer isn't empty!")
-	assert.Equal(t, 0, strLen, "Error checking string length!")
+			gb := buffer.newStr("")
+			strLen := gb.StringLength()
+
+			assert.Equal(t, "", gb.String(), "Error, empty gap buffer isn't empty!")
+			assert.Equal(t, 0, strLen, "Error checking string length!")
+		})
+	}
 }
 
 func TestInitial(t *testing.T) {
 	t.Parallel()
 
-	gb := gapbuffer.NewStr("Hello World!")
-	l, r := gb.StringPair()
-	strLen := gb.StringLength()
+	for _, buffer := range buffers {
+		buffer := buffer
+
+		t.Run(buffer.name, func(t *testing.T) {
+			t.Parallel()
+
+			gb := buffer.newStr("Hello World!")
+			l, r := gb.StringPair()
+			strLen := gb.StringLength()
 
-	assert.Equal(t, "Hello World!", l, "Error, left part isn't 'Hello World!'!")
-	assert.Equal(t, "", r, "Error, right part isn't empty!")
-	assert.Equal(t, len(l)+len(r), strLen, "Error checking string length!")
+			assert.Equal(t, "Hello World!", l, "Error, left part isn't 'Hello World!'!")
+			assert.Equal(t, "", r, "Error, right part isn't empty!")
+			assert.Equal(t, len(l)+len(r), strLen, "Error checking string length!")
+		})
+	}
 }
 
 func TestMoveLeft(t *testing.T) {
 	t.Parallel()
 
-	gapBuffer := gapbuffer.NewStr("Hello World!")
-	gapBuffer.LeftMv()
-	gapBuffer.LeftMv()
-	gapBuffer.LeftMv()
-	gapBuffer.LeftMv()
-	gapBuffer.LeftMv()
-	gapBuffer.LeftMv()
-	l, r := gapBuffer.StringPair()
-	strLen := gapBuffer.StringLength()
-
-	assert.Equal(t, "Hello ", l, "Error, left part isn't 'Hello '!")
-	assert.Equal(t, "World!", r, "Error, right part isn't 'World!'!")
-	assert.Equal(t, len(l)+len(r), strLen, "Error checking string length!")
+	for _, buffer := range buffers {
+		buffer := buffer
+
+		t.Run(buffer.name, func(t *testing.T) {
+			t.Parallel()
+
+			gapBuffer := buffer.newStr("Hello World!")
+			gapBuffer.LeftMv()
+			gapBuffer.LeftMv()
+			gapBuffer.LeftMv()
+			gapBuffer.LeftMv()
+			gapBuffer.LeftMv()
+			gapBuffer.LeftMv()
+			l, r := gapBuffer.StringPair()
+			strLen := gapBuffer.StringLength()
+
+			assert.Equal(t, "Hello ", l, "Error, left part isn't 'Hello '!")
+			assert.Equal(t, "World!", r, "Error, right part isn't 'World!'!")
+			assert.Equal(t, len(l)+len(r), strLen, "Error checking string length!")
+		})
+	}
 }
 
 func TestDeleteLeft(t *testing.T) {
 	t.Parallel()
 
-	gapBuf := gapbuffer.NewStr("Hello World!")
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	l, r := gapBuf.StringPair()
-	strLen := gapBuf.StringLength()
-
-	assert.Equal(t, "Hello", l, "Error, left part isn't 'Hello'!")
-	assert.Equal(t, "", r, "Error, right part isn't empty!")
-	assert.Equal(t, len(l)+len(r), strLen, "Error checking string length!")
+	for _, buffer := range buffers {
+		buffer := buffer
+
+		t.Run(buffer.name, func(t *testing.T) {
+			t.Parallel()
+
+			gapBuf := buffer.newStr("Hello World!")
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			l, r := gapBuf.StringPair()
+			strLen := gapBuf.StringLength()
+
+			assert.Equal(t, "Hello", l, "Error, left part isn't 'Hello'!")
+			assert.Equal(t, "", r, "Error, right part isn't empty!")
+			assert.Equal(t, len(l)+len(r), strLen, "Error checking string length!")
+		})
+	}
 }
 
 func TestDeleteRight(t *testing.T) {
 	t.Parallel()
 
-	gapBuf := gapbuffer.NewStr("Hello World!")
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	l, r := gapBuf.StringPair()
-	strLen := gapBuf.StringLength()
-
-	assert.Equal(t, "Hello", l, "Error, left part isn't 'Hello '!")
-	assert.Equal(t, "", r, "Error, right part isn't empty!")
-	assert.Equal(t, len(l)+len(r), strLen, "Error checking string length!")
+	for _, buffer := range buffers {
+		buffer := buffer
+
+		t.Run(buffer.name, func(t *testing.T) {
+			t.Parallel()
+
+			gapBuf := buffer.newStr("Hello World!")
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			l, r := gapBuf.StringPair()
+			strLen := gapBuf.StringLength()
+
+			assert.Equal(t, "Hello", l, "Error, left part isn't 'Hello '!")
+			assert.Equal(t, "", r, "Error, right part isn't empty!")
+			assert.Equal(t, len(l)+len(r), strLen, "Error checking string length!")
+		})
+	}
 }
 
 func TestInsertWithNewlines(t *testing.T) {
 	t.Parallel()
 
-	gapBuf := gapbuffer.NewStr("Hello World!")
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.RightDel()
-	gapBuf.Insert("\nfunny\n")
-
-	l, r := gapBuf.StringPair()
-	strLen := gapBuf.StringLength()
-
-	assert.Equal(t, "Hello\nfunny\n", l, "Error, left part isn't 'Hello\\nfunny\\n'!")
-	assert.Equal(t, "World!", r, "Error, right part isn't 'World!'!")
-	assert.Equal(t, len(l)+len(r), strLen, "Error checking string length!")
+	for _, buffer := range buffers {
+		buffer := buffer
+
+		t.Run(buffer.name, func(t *testing.T) {
+			t.Parallel()
+
+			gapBuf := buffer.newStr("Hello World!")
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.RightDel()
+			gapBuf.Insert("\nfunny\n")
+
+			l, r := gapBuf.StringPair()
+			strLen := gapBuf.StringLength()
+
+			assert.Equal(t, "Hello\nfunny\n", l, "Error, left part isn't 'Hello\\nfunny\\n'!")
+			assert.Equal(t, "World!", r, "Error, right part isn't 'World!'!")
+			assert.Equal(t, len(l)+len(r), strLen, "Error checking string length!")
+		})
+	}
 }
 
 func TestMoveUp(t *testing.T) {
 	t.Parallel()
 
-	gapBuf := gapbuffer.NewStr("Hello World!")
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.Insert("\nfunny\n")
-	gapBuf.UpMv()
-
-	l, r := gapBuf.StringPair()
-	strLen := gapBuf.StringLength()
-
-	assert.Equal(t, "Hello \n", l, "Error, left part isn't 'Hello \\n'!")
-	assert.Equal(t, "funny\nWorld!", r, "Error, right part isn't 'funny\\nWorld!'!")
-	assert.Equal(t, len(l)+len(r), strLen, "Error checking string length!")
+	for _, buffer := range buffers {
+		buffer := buffer
+
+		t.Run(buffer.name, func(t *testing.T) {
+			t.Parallel()
+
+			gapBuf := buffer.newStr("Hello World!")
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.Insert("\nfunny\n")
+			gapBuf.UpMv()
+
+			l, r := gapBuf.StringPair()
+			strLen := gapBuf.StringLength()
+
+			assert.Equal(t, "Hello \n", l, "Error, left part isn't 'Hello \\n'!")
+			assert.Equal(t, "funny\nWorld!", r, "Error, right part isn't 'funny\\nWorld!'!")
+			assert.Equal(t, len(l)+len(r), strLen, "Error checking string length!")
+		})
+	}
 }
 
 func TestLineLength(t *testing.T) {
 	t.Parallel()
 
-	gb := gapbuffer.NewStr("Hello\nWorld!")
-	gb.UpMv()
-	length := gb.LineLength()
+	for _, buffer := range buffers {
+		buffer := buffer
 
-	assert.Equal(t, 5, length, "Error, line length isn't 5!")
+		t.Run(buffer.name, func(t *testing.T) {
+			t.Parallel()
+
+			gb := buffer.newStr("Hello\nWorld!")
+			gb.UpMv()
+			length := gb.LineLength()
+
+			assert.Equal(t, 5, length, "Error, line length isn't 5!")
+		})
+	}
 }
 
 func TestLeftDelEverything(t *testing.T) {
 	t.Parallel()
 
-	gapBuf := gapbuffer.NewStr("Hello\nWorld!")
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	gapBuf.LeftDel()
-	empty := gapBuf.String()
-
-	assert.Equal(t, "", empty, "Error, empty gap buffer isn't empty!")
+	for _, buffer := range buffers {
+		buffer := buffer
+
+		t.Run(buffer.name, func(t *testing.T) {
+			t.Parallel()
+
+			gapBuf := buffer.newStr("Hello\nWorld!")
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			gapBuf.LeftDel()
+			empty := gapBuf.String()
+
+			assert.Equal(t, "", empty, "Error, empty gap buffer isn't empty!")
+		})
+	}
 }
 
 func TestRightDelEverything(t *testing.T) {
 	t.Parallel()
 
-	gapBuf := gapbuffer.NewStr("Hello\nWorld!")
-	gapBuf.UpMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.LeftMv()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	gapBuf.RightDel()
-	empty := gapBuf.String()
-
-	assert.Equal(t, "", empty, "Error, empty gap buffer isn't empty!")
+	for _, buffer := range buffers {
+		buffer := buffer
+
+		t.Run(buffer.name, func(t *testing.T) {
+			t.Parallel()
+
+			gapBuf := buffer.newStr("Hello\nWorld!")
+			gapBuf.UpMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.LeftMv()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			gapBuf.RightDel()
+			empty := gapBuf.String()
+
+			assert.Equal(t, "", empty, "Error, empty gap buffer isn't empty!")
+		})
+	}
+}
+
+func TestSplitRune(t *testing.T) {
+	t.Parallel()
+
+	for _, buffer := range buffers {
+		buffer := buffer
+
+		t.Run(buffer.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Editing in the middle of a rune splits it, e.g. into pieces of a
+			// piece table.
+			gb := buffer.newStr("a\nb\n\nüxyz")
+			gb.MoveTo(6)
+			gb.Insert("-")
+			gb.DeleteRange(6, 7)
+			gb.MoveTo(gb.StringLength())
+
+			line, col := gb.LineRuneCol()
+			assert.Equal(t, "a\nb\n\nüxyz", gb.String())
+			assert.Equal(t, 4, line, "Line")
+			assert.Equal(t, 4, col, "Rune column")
+
+			gb = buffer.newStr("a😀b")
+			gb.MoveTo(2)
+			gb.Insert("-")
+			gb.MoveTo(5)
+			gb.Insert("-")
+			gb.DeleteRange(5, 6)
+			gb.DeleteRange(2, 3)
+			gb.MoveTo(gb.StringLength())
+
+			assert.Equal(t, "a😀b", gb.String())
+			assert.Equal(t, 3, gb.RuneCol(), "Rune split into three parts")
+
+			gb = buffer.newStr("a\nxyz")
+			gb.MoveTo(2)
+			gb.Insert("\xc3")
+			gb.MoveTo(0)
+			gb.MoveTo(3)
+			gb.Insert("\xbc")
+			gb.MoveTo(gb.StringLength())
+
+			assert.Equal(t, "a\nüxyz", gb.String())
+			assert.Equal(t, 4, gb.RuneCol(), "Inserted in parts")
+		})
+	}
 }
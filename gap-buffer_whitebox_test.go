@@ -12,6 +12,7 @@
 package gapbuffer //nolint:testpackage // I want to white-box test this
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -21,9 +22,12 @@ func TestCurLineStartEndAscending(t *testing.T) {
 	t.Parallel()
 
 	lines := lineBuffer{
-		start:   8,
-		end:     10,
-		lengths: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 0},
+		lengths: Gap[int]{
+			start: 9,
+			end:   10,
+			data:  []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 0},
+		},
+		offset: 36,
 	}
 	s := lines.curLineStart()
 	e := lines.curLineEnd()
@@ -36,9 +40,12 @@ func TestCurLineStartEndAll2s(t *testing.T) {
 	t.Parallel()
 
 	lines := lineBuffer{
-		start:   8,
-		end:     10,
-		lengths: []int{2, 2, 2, 2, 2, 2, 2, 2, 2, 0},
+		lengths: Gap[int]{
+			start: 9,
+			end:   10,
+			data:  []int{2, 2, 2, 2, 2, 2, 2, 2, 2, 0},
+		},
+		offset: 16,
 	}
 	s := lines.curLineStart()
 	e := lines.curLineEnd()
@@ -51,9 +58,12 @@ func TestCurLineStartEndAll2s0(t *testing.T) {
 	t.Parallel()
 
 	lines := lineBuffer{
-		start:   9,
-		end:     10,
-		lengths: []int{2, 2, 2, 2, 2, 2, 2, 2, 2, 0},
+		lengths: Gap[int]{
+			start: 10,
+			end:   10,
+			data:  []int{2, 2, 2, 2, 2, 2, 2, 2, 2, 0},
+		},
+		offset: 18,
 	}
 	s := lines.curLineStart()
 	e := lines.curLineEnd()
@@ -66,9 +76,12 @@ func TestLineNew3332(t *testing.T) {
 	t.Parallel()
 
 	exp := lineBuffer{
-		start:   3,
-		end:     10,
-		lengths: []int{3, 3, 3, 2, 0, 0, 0, 0, 0, 0},
+		lengths: Gap[int]{
+			start: 4,
+			end:   10,
+			data:  []int{3, 3, 3, 2, 0, 0, 0, 0, 0, 0},
+		},
+		offset: 9,
 	}
 	lb := newLineBufStr("12\n12\n12\n12", 10)
 	assert.Equal(t, exp, *lb)
@@ -78,9 +91,12 @@ func TestLineInsert33310(t *testing.T) {
 	t.Parallel()
 
 	exp := lineBuffer{
-		start:   8,
-		end:     10,
-		lengths: []int{3, 3, 3, 3, 3, 3, 3, 3, 10, 0},
+		lengths: Gap[int]{
+			start: 9,
+			end:   10,
+			data:  []int{3, 3, 3, 3, 3, 3, 3, 3, 10, 0},
+		},
+		offset: 24,
 	}
 	lb := newLineBufStr("12\n12\n12\n12\n12\n12\n12\n12\n12", 20)
 	lb.insert("34567890", 25)
@@ -91,9 +107,12 @@ func TestLineInsert33532(t *testing.T) {
 	t.Parallel()
 
 	exp := lineBuffer{
-		start:   7,
-		end:     10,
-		lengths: []int{3, 3, 3, 5, 3, 3, 3, 2, 0, 0},
+		lengths: Gap[int]{
+			start: 8,
+			end:   10,
+			data:  []int{3, 3, 3, 5, 3, 3, 3, 2, 0, 0},
+		},
+		offset: 23,
 	}
 	lb := newLineBufStr("12\n12\n12\n12", 20)
 	lb.insert("12\n12\n12\n12\n12", 11)
@@ -104,9 +123,12 @@ func TestLineInsert333353332(t *testing.T) {
 	t.Parallel()
 
 	exp := lineBuffer{
-		start:   10,
-		end:     20,
-		lengths: []int{3, 3, 3, 5, 3, 3, 3, 3, 3, 3, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		lengths: Gap[int]{
+			start: 11,
+			end:   20,
+			data:  []int{3, 3, 3, 5, 3, 3, 3, 3, 3, 3, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		offset: 32,
 	}
 	lb := newLineBufStr("12\n12\n12\n12", 20)
 	lb.insert("12\n12\n12\n12\n12\n12\n12\n12", 11)
@@ -117,9 +139,12 @@ func TestLineInsertNewline(t *testing.T) {
 	t.Parallel()
 
 	exp := lineBuffer{
-		start:   2,
-		end:     10,
-		lengths: []int{3, 3, 0, 0, 0, 0, 0, 0, 0, 0},
+		lengths: Gap[int]{
+			start: 3,
+			end:   10,
+			data:  []int{3, 3, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		offset: 6,
 	}
 	lb := newLineBufStr("12\n12", 20)
 	lb.insert("\n", 5)
@@ -133,14 +158,34 @@ func TestLineInsertSpecial(t *testing.T) {
 	lineBuf.insert("\nfunny\n", 6)
 
 	exp := lineBuffer{
-		lengths: []int{7, 6, 0, 0, 0, 0, 0, 0, 0, 0},
-		start:   2,
-		end:     10,
+		lengths: Gap[int]{
+			start: 3,
+			end:   10,
+			data:  []int{7, 6, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		offset: 13,
 	}
 
 	assert.Equal(t, exp, *lineBuf)
 }
 
+func TestLineAppendLengths(t *testing.T) {
+	t.Parallel()
+
+	exp := lineBuffer{
+		lengths: Gap[int]{
+			start: 2,
+			end:   7,
+			data:  []int{3, 3, 1, 0, 0, 0, 0, 3, 3, 0},
+		},
+		offset: 3,
+	}
+	lb := newLineBufStr("12\n12\n1", 10)
+	lb.up()
+	lb.appendLengths([]int{2, 3, 0})
+	assert.Equal(t, exp, *lb)
+}
+
 func TestInsertEmpty(t *testing.T) {
 	t.Parallel()
 
@@ -148,14 +193,20 @@ func TestInsertEmpty(t *testing.T) {
 	gapBuf.Insert("")
 
 	exp := GapBuffer{
-		start:    0,
-		end:      10,
+		text: Gap[byte]{
+			start: 0,
+			end:   10,
+			data:  []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		cursor:   0,
 		wantsCol: 0,
-		data:     []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
 		lines: lineBuffer{
-			lengths: []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-			start:   0,
-			end:     10,
+			lengths: Gap[int]{
+				start: 1,
+				end:   10,
+				data:  []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			},
+			offset: 0,
 		},
 	}
 	assert.Equal(t, exp, *gapBuf)
@@ -168,14 +219,20 @@ func TestInsertHelloWorld(t *testing.T) {
 	gapBuf.Insert("world!")
 
 	exp := GapBuffer{
-		start:    12,
-		end:      20,
+		text: Gap[byte]{
+			start: 12,
+			end:   20,
+			data:  []byte{'h', 'e', 'l', 'l', 'o', ' ', 'w', 'o', 'r', 'l', 'd', '!', 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		cursor:   12,
 		wantsCol: 12,
-		data:     []byte{'h', 'e', 'l', 'l', 'o', ' ', 'w', 'o', 'r', 'l', 'd', '!', 0, 0, 0, 0, 0, 0, 0, 0},
 		lines: lineBuffer{
-			lengths: []int{12, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-			start:   0,
-			end:     10,
+			lengths: Gap[int]{
+				start: 1,
+				end:   10,
+				data:  []int{12, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			},
+			offset: 0,
 		},
 	}
 	assert.Equal(t, exp, *gapBuf)
@@ -188,14 +245,20 @@ func TestInsertHelloWorldNLs(t *testing.T) {
 	gapBuffer.Insert("\nwo\nld!")
 
 	exp := GapBuffer{
-		start:    14,
-		end:      20,
+		text: Gap[byte]{
+			start: 14,
+			end:   20,
+			data:  []byte{'h', '\n', 'e', 'l', '\n', 'l', 'o', '\n', 'w', 'o', '\n', 'l', 'd', '!', 0, 0, 0, 0, 0, 0},
+		},
+		cursor:   14,
 		wantsCol: 3,
-		data:     []byte{'h', '\n', 'e', 'l', '\n', 'l', 'o', '\n', 'w', 'o', '\n', 'l', 'd', '!', 0, 0, 0, 0, 0, 0},
 		lines: lineBuffer{
-			lengths: []int{2, 3, 3, 3, 3, 0, 0, 0, 0, 0},
-			start:   4,
-			end:     10,
+			lengths: Gap[int]{
+				start: 5,
+				end:   10,
+				data:  []int{2, 3, 3, 3, 3, 0, 0, 0, 0, 0},
+			},
+			offset: 11,
 		},
 	}
 	assert.Equal(t, exp, *gapBuffer)
@@ -208,14 +271,20 @@ func TestInsertHelloNLs(t *testing.T) {
 	gapBuf.Insert("\n\n\n\n\n")
 
 	exp := GapBuffer{
-		start:    12,
-		end:      20,
+		text: Gap[byte]{
+			start: 12,
+			end:   20,
+			data:  []byte{'h', '\n', 'e', 'l', '\n', 'l', 'o', '\n', '\n', '\n', '\n', '\n', 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		cursor:   12,
 		wantsCol: 0,
-		data:     []byte{'h', '\n', 'e', 'l', '\n', 'l', 'o', '\n', '\n', '\n', '\n', '\n', 0, 0, 0, 0, 0, 0, 0, 0},
 		lines: lineBuffer{
-			lengths: []int{2, 3, 3, 1, 1, 1, 1, 0, 0, 0},
-			start:   7,
-			end:     10,
+			lengths: Gap[int]{
+				start: 8,
+				end:   10,
+				data:  []int{2, 3, 3, 1, 1, 1, 1, 0, 0, 0},
+			},
+			offset: 12,
 		},
 	}
 	assert.Equal(t, exp, *gapBuf)
@@ -232,14 +301,20 @@ func TestMvLeftInsertEmpty(t *testing.T) {
 	gBuf.Insert("")
 
 	exp := GapBuffer{
-		start:    0,
-		end:      10,
+		text: Gap[byte]{
+			start: 0,
+			end:   10,
+			data:  []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		cursor:   0,
 		wantsCol: 0,
-		data:     []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
 		lines: lineBuffer{
-			lengths: []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-			start:   0,
-			end:     10,
+			lengths: Gap[int]{
+				start: 1,
+				end:   10,
+				data:  []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			},
+			offset: 0,
 		},
 	}
 	assert.Equal(t, exp, *gBuf)
@@ -256,15 +331,22 @@ func TestMvLeftInsertHello(t *testing.T) {
 	gBuf.Insert("")
 
 	exp := GapBuffer{
-		start:    2,
-		end:      7,
+		text: Gap[byte]{
+			start: 2,
+			end:   7,
+			data:  []byte{'h', 'e', 'l', 'l', 'o', 0, 0, 'l', 'l', 'o'},
+		},
+		cursor:   2,
 		wantsCol: 2,
-		data:     []byte{'h', 'e', 'l', 'l', 'o', 0, 0, 'l', 'l', 'o'},
 		lines: lineBuffer{
-			lengths: []int{5, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-			start:   0,
-			end:     10,
+			lengths: Gap[int]{
+				start: 1,
+				end:   10,
+				data:  []int{5, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			},
+			offset: 0,
 		},
+		copied: 3,
 	}
 	assert.Equal(t, exp, *gBuf)
 }
@@ -280,15 +362,22 @@ func TestMvLeftInsertHelloWorld(t *testing.T) {
 	gBuf.Insert(" world!")
 
 	exp := GapBuffer{
-		start:    9,
-		end:      17,
+		text: Gap[byte]{
+			start: 9,
+			end:   17,
+			data:  []byte{'h', 'e', ' ', 'w', 'o', 'r', 'l', 'd', '!', 0, 0, 0, 0, 0, 0, 0, 0, 'l', 'l', 'o'},
+		},
+		cursor:   9,
 		wantsCol: 9,
-		data:     []byte{'h', 'e', ' ', 'w', 'o', 'r', 'l', 'd', '!', 0, 0, 0, 0, 0, 0, 0, 0, 'l', 'l', 'o'},
 		lines: lineBuffer{
-			lengths: []int{12, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-			start:   0,
-			end:     10,
+			lengths: Gap[int]{
+				start: 1,
+				end:   10,
+				data:  []int{12, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			},
+			offset: 0,
 		},
+		copied: 8,
 	}
 	assert.Equal(t, exp, *gBuf)
 }
@@ -304,15 +393,22 @@ func TestMvLeftInsertHelloWorldNL(t *testing.T) {
 	gBuf.Insert("\nwo\nld!")
 
 	exp := GapBuffer{
-		start:    8,
-		end:      14,
+		text: Gap[byte]{
+			start: 8,
+			end:   14,
+			data:  []byte{'h', '\n', 'w', 'o', '\n', 'l', 'd', '!', 0, 0, 0, 0, 0, 0, '\n', 'e', 'l', '\n', 'l', 'o'},
+		},
+		cursor:   8,
 		wantsCol: 3,
-		data:     []byte{'h', '\n', 'w', 'o', '\n', 'l', 'd', '!', 0, 0, 0, 0, 0, 0, '\n', 'e', 'l', '\n', 'l', 'o'},
 		lines: lineBuffer{
-			lengths: []int{2, 3, 4, 0, 0, 0, 0, 0, 3, 2},
-			start:   2,
-			end:     8,
+			lengths: Gap[int]{
+				start: 3,
+				end:   8,
+				data:  []int{2, 3, 4, 0, 0, 0, 0, 0, 3, 2},
+			},
+			offset: 5,
 		},
+		copied: 6,
 	}
 	assert.Equal(t, exp, *gBuf)
 }
@@ -328,15 +424,22 @@ func TestMvLeftInsertHelloNL(t *testing.T) {
 	gBuf.Insert("\n\n\n\n\n")
 
 	exp := GapBuffer{
-		start:    6,
-		end:      14,
+		text: Gap[byte]{
+			start: 6,
+			end:   14,
+			data:  []byte{'h', '\n', '\n', '\n', '\n', '\n', 'o', 0, 0, 0, 0, 0, 0, 0, '\n', 'e', 'l', '\n', 'l', 'o'},
+		},
+		cursor:   6,
 		wantsCol: 0,
-		data:     []byte{'h', '\n', '\n', '\n', '\n', '\n', 'o', 0, 0, 0, 0, 0, 0, 0, '\n', 'e', 'l', '\n', 'l', 'o'},
 		lines: lineBuffer{
-			lengths: []int{2, 1, 1, 1, 1, 1, 0, 0, 3, 2},
-			start:   5,
-			end:     8,
+			lengths: Gap[int]{
+				start: 6,
+				end:   8,
+				data:  []int{2, 1, 1, 1, 1, 1, 0, 0, 3, 2},
+			},
+			offset: 6,
 		},
+		copied: 6,
 	}
 	assert.Equal(t, exp, *gBuf)
 }
@@ -353,14 +456,20 @@ func TestMvRightEmpty(t *testing.T) {
 	gBuf.Insert("")
 
 	exp := GapBuffer{
-		start:    0,
-		end:      10,
+		text: Gap[byte]{
+			start: 0,
+			end:   10,
+			data:  []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		cursor:   0,
 		wantsCol: 0,
-		data:     []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
 		lines: lineBuffer{
-			lengths: []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-			start:   0,
-			end:     10,
+			lengths: Gap[int]{
+				start: 1,
+				end:   10,
+				data:  []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			},
+			offset: 0,
 		},
 	}
 	assert.Equal(t, exp, *gBuf)
@@ -378,15 +487,22 @@ func TestMvRightHelloNL(t *testing.T) {
 	gBuf.Insert("\nwo\nld!")
 
 	exp := GapBuffer{
-		start:    11,
-		end:      17,
+		text: Gap[byte]{
+			start: 11,
+			end:   17,
+			data:  []byte{'h', '\n', 'e', 'l', '\n', 'w', 'o', '\n', 'l', 'd', '!', 0, 0, 0, 0, 0, 0, '\n', 'l', 'o'},
+		},
+		cursor:   11,
 		wantsCol: 3,
-		data:     []byte{'h', '\n', 'e', 'l', '\n', 'w', 'o', '\n', 'l', 'd', '!', 0, 0, 0, '\n', 'e', 'l', '\n', 'l', 'o'},
 		lines: lineBuffer{
-			lengths: []int{2, 3, 3, 4, 0, 0, 0, 0, 3, 2},
-			start:   3,
-			end:     9,
+			lengths: Gap[int]{
+				start: 4,
+				end:   9,
+				data:  []int{2, 3, 3, 4, 0, 0, 0, 0, 3, 2},
+			},
+			offset: 8,
 		},
+		copied: 3,
 	}
 	assert.Equal(t, exp, *gBuf)
 }
@@ -403,15 +519,22 @@ func TestMvRightNL(t *testing.T) {
 	gBuf.Insert("\n\n\n\n\n")
 
 	exp := GapBuffer{
-		start:    9,
-		end:      17,
+		text: Gap[byte]{
+			start: 9,
+			end:   17,
+			data:  []byte{'h', '\n', 'e', 'l', '\n', '\n', '\n', '\n', '\n', 0, 0, 0, 0, 0, 0, 0, 0, '\n', 'l', 'o'},
+		},
+		cursor:   9,
 		wantsCol: 0,
-		data:     []byte{'h', '\n', 'e', 'l', '\n', '\n', '\n', '\n', '\n', 0, 0, 0, 0, 0, '\n', 'e', 'l', '\n', 'l', 'o'},
 		lines: lineBuffer{
-			lengths: []int{2, 3, 1, 1, 1, 1, 1, 0, 3, 2},
-			start:   6,
-			end:     9,
+			lengths: Gap[int]{
+				start: 7,
+				end:   9,
+				data:  []int{2, 3, 1, 1, 1, 1, 1, 0, 3, 2},
+			},
+			offset: 9,
 		},
+		copied: 3,
 	}
 	assert.Equal(t, exp, *gBuf)
 }
@@ -424,14 +547,20 @@ func TestUpDownEmpty(t *testing.T) {
 	gBuf.DownMv()
 
 	exp := GapBuffer{
-		start:    0,
-		end:      10,
+		text: Gap[byte]{
+			start: 0,
+			end:   10,
+			data:  []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		cursor:   0,
 		wantsCol: 0,
-		data:     []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
 		lines: lineBuffer{
-			lengths: []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-			start:   0,
-			end:     10,
+			lengths: Gap[int]{
+				start: 1,
+				end:   10,
+				data:  []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			},
+			offset: 0,
 		},
 	}
 
@@ -446,14 +575,20 @@ func TestUpDownNL(t *testing.T) {
 	gBuf.DownMv()
 
 	exp := GapBuffer{
-		start:    1,
-		end:      10,
+		text: Gap[byte]{
+			start: 1,
+			end:   10,
+			data:  []byte{'\n', 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		cursor:   1,
 		wantsCol: 0,
-		data:     []byte{'\n', 0, 0, 0, 0, 0, 0, 0, 0, '\n'},
 		lines: lineBuffer{
-			lengths: []int{1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-			start:   1,
-			end:     10,
+			lengths: Gap[int]{
+				start: 2,
+				end:   10,
+				data:  []int{1, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			},
+			offset: 1,
 		},
 	}
 
@@ -469,15 +604,22 @@ func TestUpDownInsert12(t *testing.T) {
 	gBuf.DownMv()
 
 	exp := GapBuffer{
-		start:    4,
-		end:      10,
+		text: Gap[byte]{
+			start: 2,
+			end:   8,
+			data:  []byte{'1', '2', 0, 0, 0, 0, 0, 0, '\n', '1'},
+		},
+		cursor:   4,
 		wantsCol: 2,
-		data:     []byte{'1', '2', '\n', '1', 0, 0, 0, 0, '\n', '1'},
 		lines: lineBuffer{
-			lengths: []int{3, 1, 0, 0, 0, 0, 0, 0, 0, 1},
-			start:   1,
-			end:     10,
+			lengths: Gap[int]{
+				start: 2,
+				end:   10,
+				data:  []int{3, 1, 0, 0, 0, 0, 0, 0, 0, 1},
+			},
+			offset: 3,
 		},
+		copied: 2,
 	}
 	assert.Equal(t, exp, *gBuf)
 }
@@ -491,15 +633,22 @@ func TestUpDownInsert12NL(t *testing.T) {
 	gBuf.DownMv()
 
 	exp := GapBuffer{
-		start:    3,
-		end:      10,
+		text: Gap[byte]{
+			start: 0,
+			end:   7,
+			data:  []byte{'1', '2', '\n', 0, 0, 0, 0, '1', '2', '\n'},
+		},
+		cursor:   3,
 		wantsCol: 0,
-		data:     []byte{'1', '2', '\n', 0, 0, 0, 0, '1', '2', '\n'},
 		lines: lineBuffer{
-			lengths: []int{3, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-			start:   1,
-			end:     10,
+			lengths: Gap[int]{
+				start: 2,
+				end:   10,
+				data:  []int{3, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			},
+			offset: 3,
 		},
+		copied: 3,
 	}
 	assert.Equal(t, exp, *gBuf)
 }
@@ -513,15 +662,22 @@ func TestUpDownInsert11NL(t *testing.T) {
 	gBuf.DownMv()
 
 	exp := GapBuffer{
-		start:    3,
-		end:      9,
+		text: Gap[byte]{
+			start: 2,
+			end:   8,
+			data:  []byte{'1', '\n', '1', 0, 0, 0, 0, 0, '\n', '1'},
+		},
+		cursor:   3,
 		wantsCol: 0,
-		data:     []byte{'1', '\n', '\n', 0, 0, 0, 0, 0, '\n', '1'},
 		lines: lineBuffer{
-			lengths: []int{2, 1, 1, 0, 0, 0, 0, 0, 0, 1},
-			start:   2,
-			end:     10,
+			lengths: Gap[int]{
+				start: 3,
+				end:   10,
+				data:  []int{2, 1, 1, 0, 0, 0, 0, 0, 0, 1},
+			},
+			offset: 3,
 		},
+		copied: 2,
 	}
 	assert.Equal(t, exp, *gBuf)
 }
@@ -534,14 +690,20 @@ func TestUpDownNL1(t *testing.T) {
 	gBuf.DownMv()
 
 	exp := GapBuffer{
-		start:    2,
-		end:      10,
+		text: Gap[byte]{
+			start: 2,
+			end:   10,
+			data:  []byte{'\n', '1', 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		cursor:   2,
 		wantsCol: 1,
-		data:     []byte{'\n', '1', 0, 0, 0, 0, 0, 0, '\n', '1'},
 		lines: lineBuffer{
-			lengths: []int{1, 1, 0, 0, 0, 0, 0, 0, 0, 1},
-			start:   1,
-			end:     10,
+			lengths: Gap[int]{
+				start: 2,
+				end:   10,
+				data:  []int{1, 1, 0, 0, 0, 0, 0, 0, 0, 1},
+			},
+			offset: 1,
 		},
 	}
 
@@ -556,14 +718,20 @@ func TestUpDown12NL(t *testing.T) {
 	gBuf.DownMv()
 
 	exp := GapBuffer{
-		start:    3,
-		end:      10,
+		text: Gap[byte]{
+			start: 3,
+			end:   10,
+			data:  []byte{'1', '2', '\n', 0, 0, 0, 0, 0, 0, 0},
+		},
+		cursor:   3,
 		wantsCol: 0,
-		data:     []byte{'1', '2', '\n', 0, 0, 0, 0, '1', '2', '\n'},
 		lines: lineBuffer{
-			lengths: []int{3, 0, 0, 0, 0, 0, 0, 0, 0, 0},
-			start:   1,
-			end:     10,
+			lengths: Gap[int]{
+				start: 2,
+				end:   10,
+				data:  []int{3, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			},
+			offset: 3,
 		},
 	}
 
@@ -578,16 +746,166 @@ func TestUpDown1NL1(t *testing.T) {
 	gBuf.DownMv()
 
 	exp := GapBuffer{
-		start:    3,
-		end:      10,
+		text: Gap[byte]{
+			start: 3,
+			end:   10,
+			data:  []byte{'1', '\n', '1', 0, 0, 0, 0, 0, 0, 0},
+		},
+		cursor:   3,
 		wantsCol: 1,
-		data:     []byte{'1', '\n', '1', 0, 0, 0, 0, 0, '\n', '1'},
 		lines: lineBuffer{
-			lengths: []int{2, 1, 0, 0, 0, 0, 0, 0, 0, 1},
-			start:   1,
-			end:     10,
+			lengths: Gap[int]{
+				start: 2,
+				end:   10,
+				data:  []int{2, 1, 0, 0, 0, 0, 0, 0, 0, 1},
+			},
+			offset: 2,
 		},
 	}
 
 	assert.Equal(t, exp, *gBuf)
 }
+
+func TestPieceTableTyping(t *testing.T) {
+	t.Parallel()
+
+	pt := NewPieceTable("Hello world!")
+	pt.MoveTo(6)
+	pt.Insert("b")
+	pt.Insert("i")
+	pt.Insert("g ")
+	pt.LeftDel()
+	pt.Insert(" ")
+
+	exp := []piece{
+		{added: false, start: 0, length: 6},
+		{added: true, start: 0, length: 3},
+		{added: true, start: 4, length: 1},
+		{added: false, start: 6, length: 6},
+	}
+	assert.Equal(t, exp, pt.pieces)
+	assert.Equal(t, "big  ", string(pt.add))
+	assert.Equal(t, "Hello big world!", pt.String())
+}
+
+// checkRopeNode checks the cached counts and the size of the rope node and
+// returns the depth of its leaves.
+func checkRopeNode(t *testing.T, n *ropeNode) int {
+	t.Helper()
+
+	if n.children == nil {
+		assert.LessOrEqual(t, len(n.chunk), ropeChunkSize, "Chunk size")
+		assert.Equal(t, *newRopeLeaf(n.chunk), *n, "Leaf counts")
+
+		return 0
+	}
+
+	assert.LessOrEqual(t, len(n.children), ropeMaxChildren, "Number of children")
+
+	depth := checkRopeNode(t, n.children[0])
+	for _, c := range n.children[1:] {
+		assert.Equal(t, depth, checkRopeNode(t, c), "Leaves at the same depth")
+	}
+
+	exp := newRopeInner(n.children)
+	assert.Equal(t, []int{exp.bytes, exp.runes, exp.newlines}, []int{n.bytes, n.runes, n.newlines}, "Counts")
+
+	return depth + 1
+}
+
+func TestRopeBalanced(t *testing.T) {
+	t.Parallel()
+
+	rope := NewRope(strings.Repeat("line ä\n", 100_000))
+	assert.Equal(t, 3, checkRopeNode(t, rope.root), "Depth")
+
+	for idx := 0; idx < 1_000; idx++ {
+		rope.MoveTo(idx * 613 % rope.StringLength())
+		rope.Insert(strings.Repeat("x\n", idx))
+	}
+
+	checkRopeNode(t, rope.root)
+
+	for idx := 0; rope.StringLength() > 1_000; idx++ {
+		from := rope.StringLength() / 3
+		rope.DeleteRange(from, from+1_234)
+
+		if idx%100 == 0 {
+			checkRopeNode(t, rope.root)
+		}
+	}
+
+	assert.Equal(t, 0, checkRopeNode(t, rope.root), "Merged into one leaf")
+}
+
+func TestPieceTableIndexLazily(t *testing.T) {
+	t.Parallel()
+
+	line := strings.Repeat("x", 999) + "\n"
+	pt := newPieceTable([]byte(strings.Repeat(line, 1_000)), nil)
+	pt.index(0)
+	assert.Equal(t, 67, pt.lines.lineCount(), "Only the first chunk")
+	assert.Equal(t, 1_000*1_000-66*1_000, pt.unindexed)
+
+	pt.MoveTo(100_000)
+	assert.Equal(t, 101, pt.Line())
+	assert.Equal(t, 133, pt.lines.lineCount(), "Two chunks")
+
+	pt.MoveTo(0)
+	pt.Insert("ab\ncd")
+	assert.Equal(t, 1_002, pt.LineCount())
+	assert.Equal(t, 0, pt.unindexed)
+	assert.Equal(t, 2, pt.Line())
+}
+
+func TestValidateCorrupt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		corrupt func(g *GapBuffer)
+		message string
+	}{
+		{"text gap", func(g *GapBuffer) { g.text.end = len(g.text.data) + 1 }, "gap of the text"},
+		{"line gap", func(g *GapBuffer) { g.lines.lengths.start = 0 }, "no current line"},
+		{"empty line", func(g *GapBuffer) { g.lines.lengths.data[0] = 0 }, "line 1 has the length 0"},
+		{"cursor", func(g *GapBuffer) { g.cursor = 100 }, "cursor 100 is outside of the text"},
+		{"line sum", func(g *GapBuffer) { g.lines.lengths.data[len(g.lines.lengths.data)-1]-- }, "sum up to 10"},
+		{"newline", func(g *GapBuffer) { g.lines.lengths.data[0]-- }, "line 1 at offset 0 of length 3"},
+		{"last line", func(g *GapBuffer) { g.text.data[10] = '\n' }, "last line 3"},
+		{"line offset", func(g *GapBuffer) { g.lines.offset = 5 }, "starts at 4, not at 5"},
+		{"cursor line", func(g *GapBuffer) { g.cursor = 9 }, "cursor 9 is outside of line 2 from 4 to 8"},
+		{"wants col", func(g *GapBuffer) { g.wantsCol = 1 }, "wanted rune column 1"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			g := NewStr("abc\ndefg\nhi")
+			g.UpMv()
+			assert.NoError(t, g.Validate())
+
+			tt.corrupt(g)
+			err := g.Validate()
+			assert.ErrorIs(t, err, ErrInvalidState)
+			assert.ErrorContains(t, err, tt.message)
+			assert.NotEmpty(t, g.DebugString())
+			assert.Panics(t, g.mustValidate)
+		})
+	}
+}
+
+func TestAddCursorAtPrimary(t *testing.T) {
+	t.Parallel()
+
+	g := NewStr("abc")
+	g.AddCursor(g.Offset())
+	assert.Nil(t, g.cursors, "No additional cursor")
+	assert.Empty(t, g.listeners, "No EditFunc of the cursors")
+
+	g.AddCursor(0)
+	g.ClearCursors()
+	assert.Empty(t, g.listeners, "EditFunc removed")
+}
@@ -0,0 +1,238 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     gap.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+// Gap is a gap buffer of elements of any type, an array with a "gap" - unused
+// elements - at the position of the last insertion or deletion. Inserting and
+// deleting at the gap is O(1), moving the gap copies the elements in between.
+// [GapBuffer] holds its text and its line lengths in a Gap.
+//
+// The elements [a, b, c, d] with the gap after b look like this:
+//
+//	[a, b, _, _, _, c, d]
+//	 0  1 |  gap  | 2  3
+//
+// Indices are the indices of the elements, not of the array, and are not
+// checked: invalid indices panic or return garbage.
+type Gap[T any] struct {
+	// The index in `Gap.data` of the start of the gap, this is the number of
+	// elements before the gap.
+	start int
+
+	// The index in `Gap.data` of the end of the gap, the first element after
+	// the gap.
+	end int
+
+	// The array holding the elements and the gap.
+	data []T
+}
+
+// Construct a new, empty Gap with the given capacity, the number of elements
+// it can hold without a resize.
+//
+// See also [NewGapSlice].
+func NewGap[T any](capacity int) *Gap[T] {
+	return &Gap[T]{
+		start: 0,
+		end:   capacity,
+		data:  make([]T, capacity),
+	}
+}
+
+// Construct a new Gap holding a copy of the given elements, with the gap at
+// the end. The capacity is the maximum of the given capacity and twice the
+// number of elements.
+//
+// See also [NewGap].
+func NewGapSlice[T any](elems []T, capacity int) *Gap[T] {
+	size := max(capacity, len(elems)*growFactor)
+	data := make([]T, size)
+
+	return &Gap[T]{
+		start: copy(data, elems),
+		end:   size,
+		data:  data,
+	}
+}
+
+// Return the number of elements.
+func (g *Gap[T]) Len() int {
+	return len(g.data) - (g.end - g.start)
+}
+
+// Return the number of elements the Gap can hold without a resize, the number
+// of elements plus the size of the gap.
+func (g *Gap[T]) Cap() int {
+	return len(g.data)
+}
+
+// Return the index of the gap, the number of elements before it. Elements
+// inserted at this index don't move the gap.
+func (g *Gap[T]) GapPos() int {
+	return g.start
+}
+
+// Return the element with the given index.
+func (g *Gap[T]) At(idx int) T {
+	if idx < g.start {
+		return g.data[idx]
+	}
+
+	return g.data[idx+g.end-g.start]
+}
+
+// Set the element with the given index.
+func (g *Gap[T]) Set(idx int, elem T) {
+	if idx < g.start {
+		g.data[idx] = elem
+	} else {
+		g.data[idx+g.end-g.start] = elem
+	}
+}
+
+// Move the gap to the given index, copying the elements in between to the
+// other side of the gap.
+func (g *Gap[T]) MoveGap(idx int) {
+	switch {
+	case idx < g.start:
+		g.end -= g.start - idx
+		_ = copy(g.data[g.end:], g.data[idx:g.start])
+		g.start = idx
+
+	case idx > g.start:
+		n := idx - g.start
+		_ = copy(g.data[g.start:], g.data[g.end:g.end+n])
+		g.start += n
+		g.end += n
+	}
+}
+
+// Insert the elements at the given index. The gap is moved to the end of the
+// inserted elements.
+//
+// See also [Gap.Delete].
+func (g *Gap[T]) Insert(idx int, elems ...T) {
+	g.MoveGap(idx)
+	g.Reserve(len(elems))
+	g.start += copy(g.data[g.start:], elems)
+}
+
+// Delete the elements between the indices `from` (inclusive) and `to`
+// (exclusive). The gap is moved to `from`, but only the elements on one side
+// of the deleted ones are copied.
+//
+// See also [Gap.Insert].
+func (g *Gap[T]) Delete(from int, to int) {
+	if to >= g.start && from <= g.start {
+		g.end += to - g.start
+		g.start = from
+
+		return
+	}
+
+	if g.start > to {
+		g.MoveGap(to)
+		g.start = from
+
+		return
+	}
+
+	g.MoveGap(from)
+	g.end += to - from
+}
+
+// Return the elements between the indices `from` (inclusive) and `to`
+// (exclusive), the part before the gap and the part after the gap. The
+// returned slices are not copies, they are only valid until the next change.
+func (g *Gap[T]) Slices(from int, to int) (left []T, right []T) {
+	gap := g.end - g.start
+
+	switch {
+	case to <= g.start:
+		return g.data[from:to], nil
+
+	case from >= g.start:
+		return nil, g.data[from+gap : to+gap]
+
+	default:
+		return g.data[from:g.start], g.data[g.end : to+gap]
+	}
+}
+
+// Call the given function with the index and the value of every element
+// between the indices `from` (inclusive) and `to` (exclusive), in order. Stops
+// if the function returns false.
+func (g *Gap[T]) Each(from int, to int, f func(idx int, elem T) bool) {
+	left, right := g.Slices(from, to)
+
+	for i, elem := range left {
+		if !f(from+i, elem) {
+			return
+		}
+	}
+
+	for i, elem := range right {
+		if !f(from+len(left)+i, elem) {
+			return
+		}
+	}
+}
+
+// Return a copy of all elements.
+func (g *Gap[T]) Elems() []T {
+	elems := make([]T, 0, g.Len())
+	elems = append(elems, g.data[:g.start]...)
+
+	return append(elems, g.data[g.end:]...)
+}
+
+// Make room for at least `n` elements in the gap, growing the array by
+// `growFactor` times its current size as often as needed.
+func (g *Gap[T]) Reserve(n int) {
+	for g.end-g.start < n {
+		g.grow()
+	}
+}
+
+// grow resizes the array by `growFactor` times its current size and copies
+// the existing elements.
+func (g *Gap[T]) grow() {
+	tmp := make([]T, max(len(g.data)*growFactor, 1))
+	_ = copy(tmp, g.data[:g.start])
+	nE := len(tmp) - (len(g.data) - g.end)
+	_ = copy(tmp[nE:], g.data[g.end:])
+	g.end = nE
+	g.data = tmp
+}
+
+// stepLeft moves the gap one element to the left. Faster than [Gap.MoveGap]
+// for the common case of moving a cursor by one line.
+func (g *Gap[T]) stepLeft() {
+	g.start--
+	g.end--
+	g.data[g.end] = g.data[g.start]
+}
+
+// stepRight moves the gap one element to the right, see [Gap.stepLeft].
+func (g *Gap[T]) stepRight() {
+	g.data[g.start] = g.data[g.end]
+	g.start++
+	g.end++
+}
+
+// appendElems adds the elements to the end, after the elements after the gap,
+// without moving the gap.
+func (g *Gap[T]) appendElems(elems ...T) {
+	g.Reserve(len(elems))
+	n := len(elems)
+	_ = copy(g.data[g.end-n:], g.data[g.end:])
+	g.end -= n
+	_ = copy(g.data[len(g.data)-n:], elems)
+}
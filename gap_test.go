@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     gap_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGap(t *testing.T) {
+	t.Parallel()
+
+	g := gapbuffer.NewGap[string](2)
+	assert.Equal(t, 0, g.Len())
+	assert.Equal(t, 2, g.Cap())
+
+	g.Insert(0, "a", "b", "c")
+	g.Insert(1, "x")
+	assert.Equal(t, []string{"a", "x", "b", "c"}, g.Elems())
+	assert.Equal(t, 2, g.GapPos())
+	assert.Equal(t, 4, g.Cap())
+
+	g.Set(3, "C")
+	assert.Equal(t, "C", g.At(3))
+	assert.Equal(t, "a", g.At(0))
+
+	g.Delete(0, 2)
+	assert.Equal(t, []string{"b", "C"}, g.Elems())
+	assert.Equal(t, 0, g.GapPos())
+
+	g.MoveGap(2)
+	left, right := g.Slices(0, 2)
+	assert.Equal(t, []string{"b", "C"}, left)
+	assert.Empty(t, right)
+}
+
+func TestNewGapSlice(t *testing.T) {
+	t.Parallel()
+
+	elems := []int{1, 2, 3}
+	g := gapbuffer.NewGapSlice(elems, 4)
+	elems[0] = 0
+
+	assert.Equal(t, []int{1, 2, 3}, g.Elems(), "The elements are copied")
+	assert.Equal(t, 3, g.GapPos())
+	assert.Equal(t, 6, g.Cap())
+
+	g = gapbuffer.NewGapSlice[int](nil, 0)
+	g.Insert(0, 1)
+	assert.Equal(t, []int{1}, g.Elems())
+}
+
+func TestGapEach(t *testing.T) {
+	t.Parallel()
+
+	g := gapbuffer.NewGapSlice([]rune("Hello, World!"), 0)
+	g.MoveGap(5)
+
+	var (
+		idxs  []int
+		runes []rune
+	)
+
+	g.Each(3, 9, func(idx int, r rune) bool {
+		idxs = append(idxs, idx)
+		runes = append(runes, r)
+
+		return r != ' '
+	})
+
+	assert.Equal(t, []int{3, 4, 5, 6}, idxs)
+	assert.Equal(t, "lo, ", string(runes))
+}
+
+func TestGapRandom(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(74)) //nolint:gosec // test
+	g := gapbuffer.NewGap[int](0)
+	exp := []int{}
+
+	for i := 0; i < 10_000; i++ {
+		from := rnd.Intn(len(exp) + 1)
+		to := from + rnd.Intn(len(exp)-from+1)
+
+		switch rnd.Intn(4) {
+		case 0:
+			g.Delete(from, to)
+			exp = slices.Delete(exp, from, to)
+		case 1:
+			g.MoveGap(from)
+		default:
+			g.Insert(from, i, i+1)
+			exp = slices.Insert(exp, from, i, i+1)
+		}
+
+		require.Equal(t, len(exp), g.Len())
+		left, right := g.Slices(0, g.Len())
+		require.Equal(t, exp, append(slices.Clone(left), right...))
+	}
+
+	assert.Equal(t, exp, g.Elems())
+}
@@ -0,0 +1,242 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     gutter.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"slices"
+	"strings"
+)
+
+// LineStatus is the state of a line compared to the saved baseline, like shown
+// in the gutter of an editor.
+type LineStatus int
+
+const (
+	// LineUnchanged is a line of the saved baseline.
+	LineUnchanged LineStatus = iota
+
+	// LineAdded is a line that is not in the saved baseline.
+	LineAdded
+
+	// LineModified is a line of the saved baseline that has been changed.
+	LineModified
+)
+
+// LineChange is the change of a line compared to the saved baseline.
+type LineChange struct {
+	// The state of the line.
+	Status LineStatus
+
+	// True, if lines of the saved baseline have been deleted before this line.
+	DeletedBefore bool
+}
+
+// changeTracker tracks the origin of every line in the saved baseline, see
+// [GapBuffer.TrackChanges].
+type changeTracker struct {
+	// The lines of the saved baseline.
+	base []string
+
+	// The lines of the gap buffer, the same number as in the line buffer.
+	lines []trackedLine
+
+	// Removes the [EditFunc] of the tracker from the gap buffer.
+	remove func()
+}
+
+// trackedLine is the state of a line of the gap buffer.
+type trackedLine struct {
+	// The index of the line in the saved baseline this line originates from,
+	// -1 for added lines.
+	origin int
+
+	// True, if lines of the saved baseline have been deleted before this line.
+	deletedBefore bool
+}
+
+// TrackChanges starts to track which lines differ from the saved baseline, see
+// [GapBuffer.LineChanges]. The lines are compared by a diff once, after that
+// the changes are tracked line by line on every edit. Calling
+// [GapBuffer.MarkSaved] or [GapBuffer.MergeSaved] compares the lines again.
+//
+// See also [GapBuffer.StopTrackingChanges].
+func (g *GapBuffer) TrackChanges() {
+	if g.changes != nil {
+		return
+	}
+
+	g.changes = &changeTracker{base: nil, lines: nil, remove: nil}
+	g.changes.remove = g.AddEditFunc(g.trackEdit)
+	g.resetChanges()
+}
+
+// StopTrackingChanges stops tracking the changes started by
+// [GapBuffer.TrackChanges].
+func (g *GapBuffer) StopTrackingChanges() {
+	if g.changes == nil {
+		return
+	}
+
+	g.changes.remove()
+	g.changes = nil
+}
+
+// LineChanges returns the changes compared to the saved baseline of the lines
+// `first` to `last` (inclusive), numbering starts from 1 like
+// [GapBuffer.Line]. The line numbers are clamped to the existing lines.
+//
+// An empty last line - after a final newline - is never added or modified, but
+// may have lines deleted before it.
+//
+// Returns nil if the changes aren't tracked, see [GapBuffer.TrackChanges].
+func (g *GapBuffer) LineChanges(first int, last int) []LineChange {
+	if g.changes == nil {
+		return nil
+	}
+
+	count := g.lines.lineCount()
+	from, to := max(first-1, 0), min(last, count)
+
+	if from >= to {
+		return []LineChange{}
+	}
+
+	changes := make([]LineChange, 0, to-from)
+	start := g.lines.lineStart(from)
+
+	for idx := from; idx < to; idx++ {
+		length := g.lines.lineLength(idx)
+		line := g.changes.lines[idx]
+		change := LineChange{Status: LineUnchanged, DeletedBefore: line.deletedBefore}
+
+		switch {
+		case length == 0:
+		case line.origin < 0:
+			change.Status = LineAdded
+		case g.slice(start, start+length) != g.changes.base[line.origin]:
+			change.Status = LineModified
+		}
+
+		changes = append(changes, change)
+		start += length
+	}
+
+	return changes
+}
+
+// resetChanges compares the lines of the gap buffer with the saved baseline.
+func (g *GapBuffer) resetChanges() {
+	if g.changes == nil {
+		return
+	}
+
+	base, current := splitLines(g.saved), g.lineStrings()
+	ids := make(map[string]int, len(base))
+	regions := diffRegions(internLines(ids, base), internLines(ids, current))
+	lines := make([]trackedLine, 0, g.lines.lineCount())
+
+	// True, if the next line follows deleted lines.
+	deleted := false
+	add := func(origin int) {
+		lines = append(lines, trackedLine{origin: origin, deletedBefore: deleted})
+		deleted = false
+	}
+
+	baseIdx := 0
+
+	for _, region := range regions {
+		for ; baseIdx < region.baseStart; baseIdx++ {
+			add(baseIdx)
+		}
+
+		// Lines replacing lines of the baseline are modified lines.
+		for idx := region.sideStart; idx < region.sideEnd; idx++ {
+			if baseIdx < region.baseEnd {
+				add(baseIdx)
+				baseIdx++
+			} else {
+				add(-1)
+			}
+		}
+
+		if baseIdx < region.baseEnd {
+			deleted = true
+			baseIdx = region.baseEnd
+		}
+	}
+
+	for ; baseIdx < len(base); baseIdx++ {
+		add(baseIdx)
+	}
+
+	// The empty last line after a final newline.
+	if len(lines) < g.lines.lineCount() {
+		add(-1)
+	}
+
+	if deleted {
+		lines[len(lines)-1].deletedBefore = true
+	}
+
+	g.changes.base = base
+	g.changes.lines = lines
+}
+
+// trackEdit updates the origins of the lines after the edit, it is the
+// [EditFunc] of the change tracker.
+func (g *GapBuffer) trackEdit(e Edit) {
+	lines := g.changes.lines
+
+	// The cursor is after the inserted text.
+	newlines := strings.Count(e.Inserted, "\n")
+	line := g.lines.cur() - newlines
+	atLineStart := e.Offset == 0 || g.byteAt(e.Offset-1) == '\n'
+
+	if newlines > 0 {
+		added := make([]trackedLine, newlines)
+		for idx := range added {
+			added[idx] = trackedLine{origin: -1, deletedBefore: false}
+		}
+
+		// Text inserted at the start of a line is inserted before the line.
+		if !atLineStart {
+			line++
+		}
+
+		g.changes.lines = slices.Insert(lines, line, added...)
+
+		return
+	}
+
+	deleted := strings.Count(e.Deleted, "\n")
+	if deleted == 0 {
+		return
+	}
+
+	// Whole lines deleted: remove them. Else the first line keeps its origin.
+	first := line
+	if !atLineStart || !strings.HasSuffix(e.Deleted, "\n") {
+		first++
+	}
+
+	removed := false
+
+	for _, l := range lines[first : first+deleted] {
+		removed = removed || l.origin >= 0 || l.deletedBefore
+	}
+
+	lines = slices.Delete(lines, first, first+deleted)
+
+	if removed {
+		lines[min(first, len(lines)-1)].deletedBefore = true
+	}
+
+	g.changes.lines = lines
+}
@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     gutter_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	unchanged = gapbuffer.LineChange{Status: gapbuffer.LineUnchanged, DeletedBefore: false}
+	added     = gapbuffer.LineChange{Status: gapbuffer.LineAdded, DeletedBefore: false}
+	modified  = gapbuffer.LineChange{Status: gapbuffer.LineModified, DeletedBefore: false}
+	deleted   = gapbuffer.LineChange{Status: gapbuffer.LineUnchanged, DeletedBefore: true}
+)
+
+func TestLineChanges(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("one\ntwo\nthree\nfour\n")
+	assert.Nil(t, gb.LineChanges(1, 5), "Not tracked")
+
+	gb.MarkSaved()
+	gb.TrackChanges()
+	assert.Equal(t, []gapbuffer.LineChange{unchanged, unchanged, unchanged, unchanged, unchanged},
+		gb.LineChanges(1, 5))
+
+	// Add a line before "two".
+	gb.MoveTo(4)
+	gb.Insert("1.5\n")
+	assert.Equal(t, []gapbuffer.LineChange{unchanged, added, unchanged, unchanged},
+		gb.LineChanges(1, 4))
+
+	// Modify "three".
+	gb.MoveTo(strings.Index(gb.String(), "three"))
+	gb.RightDel()
+	gb.Insert("T")
+	assert.Equal(t, []gapbuffer.LineChange{unchanged, modified}, gb.LineChanges(3, 4))
+
+	// Undo the modification.
+	gb.LeftDel()
+	gb.Insert("t")
+	assert.Equal(t, []gapbuffer.LineChange{unchanged}, gb.LineChanges(4, 4), "Changed back")
+
+	// Delete the line "four".
+	gb.DownMv()
+	gb.DeleteRange(gb.Offset()-gb.Col(), gb.Offset()-gb.Col()+len("four\n"))
+	assert.Equal(t, "one\n1.5\ntwo\nthree\n", gb.String())
+	assert.Equal(t, []gapbuffer.LineChange{unchanged, unchanged, deleted}, gb.LineChanges(3, 10))
+
+	// Join "one" and the added line.
+	gb.MoveTo(3)
+	gb.RightDel()
+	assert.Equal(t, []gapbuffer.LineChange{modified, unchanged, unchanged, deleted}, gb.LineChanges(-1, 10))
+
+	// Join "one1.5" and "two".
+	gb.MoveTo(6)
+	gb.RightDel()
+	assert.Equal(t, []gapbuffer.LineChange{modified, deleted, deleted}, gb.LineChanges(-1, 10))
+
+	gb.MarkSaved()
+	assert.Equal(t, []gapbuffer.LineChange{unchanged, unchanged, unchanged}, gb.LineChanges(1, 10))
+
+	gb.StopTrackingChanges()
+	assert.Nil(t, gb.LineChanges(1, 5), "Not tracked")
+}
+
+func TestLineChangesInitial(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("one\ntwo\nthree\nfour\nfive")
+	gb.MarkSaved()
+	gb.ReplaceRange(0, len("one\ntwo\nthree\n"), "1\nthree\nnew\n")
+	gb.DeleteRange(gb.StringLength()-len("five"), gb.StringLength())
+
+	// A new change tracker compares the lines.
+	other := gapbuffer.NewStr(gb.String())
+	other.TrackChanges()
+	assert.Equal(t, []gapbuffer.LineChange{added, added, added, added, unchanged}, other.LineChanges(1, 10),
+		"Empty baseline")
+
+	other = gapbuffer.NewStr(gb.Saved())
+	other.MarkSaved()
+	other.ReplaceRange(0, other.StringLength(), gb.String())
+	other.StopTrackingChanges()
+	other.TrackChanges()
+	assert.Equal(t, []gapbuffer.LineChange{modified, deleted, added, unchanged, deleted},
+		other.LineChanges(1, 10))
+}
+
+func TestLineChangesRandom(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(99)) //nolint:gosec // test
+
+	for i := 0; i < 50; i++ {
+		gb := gapbuffer.NewStr(strings.Join(randomLines(rnd), ""))
+		gb.MarkSaved()
+		gb.TrackChanges()
+
+		for j := 0; j < 30; j++ {
+			from := rnd.Intn(gb.StringLength() + 1)
+			to := from + rnd.Intn(gb.StringLength()-from+1)
+
+			switch rnd.Intn(4) {
+			case 0:
+				gb.MoveTo(from)
+				gb.Insert([]string{"x", "\n", "y\n", "\nz", "a\nb\n"}[rnd.Intn(5)])
+			case 1:
+				gb.DeleteRange(from, to)
+			case 2:
+				gb.MoveTo(from)
+				gb.LeftDel()
+			default:
+				gb.MoveTo(from)
+				gb.RightDel()
+			}
+
+			changes := gb.LineChanges(1, gb.LineCount())
+			require.Len(t, changes, gb.LineCount(), "One change per line")
+
+			for idx, line := range strings.SplitAfter(gb.String(), "\n") {
+				if changes[idx].Status == gapbuffer.LineUnchanged && line != "" {
+					require.Contains(t, gb.Saved(), line, "Unchanged lines are saved lines")
+				}
+			}
+		}
+	}
+}
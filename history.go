@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     history.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import "io"
+
+// History is the undo and redo history of a gap buffer. It records every
+// change of the text of the gap buffer, see [GapBuffer.AddEditFunc].
+//
+// All edits made by one call of a method of the gap buffer are a single undo
+// step, like the deletion and insertion of [GapBuffer.ReplaceRange], the edits
+// at all cursors of [GapBuffer.InsertAll] or all hunks of
+// [GapBuffer.ApplyPatch]. More edits are grouped into one step by
+// [History.BeginGroup] and [History.EndGroup]. A new edit clears the redo
+// history.
+type History struct {
+	// The gap buffer whose changes are recorded.
+	buf *GapBuffer
+
+	// Removes the [EditFunc] from the gap buffer.
+	remove func()
+
+	// The undo steps, the last one is undone first.
+	undo [][]Edit
+
+	// The redo steps, the last one is redone first.
+	redo [][]Edit
+
+	// The nesting depth of [History.BeginGroup] calls.
+	depth int
+
+	// True, if the current group already has an undo step.
+	started bool
+
+	// The operation of the last recorded edit, see [GapBuffer.beginOp].
+	op int
+
+	// True while undoing or redoing, to not record these edits.
+	applying bool
+}
+
+// NewHistory returns a new, empty undo history recording the changes of the
+// gap buffer.
+//
+// See also [History.Undo], [History.Redo], [LoadUndoFile].
+func NewHistory(g *GapBuffer) *History {
+	h := &History{
+		buf:      g,
+		remove:   nil,
+		undo:     nil,
+		redo:     nil,
+		depth:    0,
+		started:  false,
+		op:       g.op,
+		applying: false,
+	}
+	h.remove = g.AddEditFunc(h.record)
+
+	return h
+}
+
+// Close stops recording the changes of the gap buffer.
+func (h *History) Close() {
+	h.remove()
+}
+
+// Return true if there is an undo step.
+func (h *History) CanUndo() bool {
+	return len(h.undo) > 0
+}
+
+// Return true if there is a redo step.
+func (h *History) CanRedo() bool {
+	return len(h.redo) > 0
+}
+
+// BeginGroup starts a group of edits, all edits until the matching
+// [History.EndGroup] are undone and redone as a single step. Groups can be
+// nested, the outermost group is the undo step.
+func (h *History) BeginGroup() {
+	if h.depth == 0 {
+		h.started = false
+	}
+
+	h.depth++
+}
+
+// EndGroup ends the group started by the matching [History.BeginGroup].
+func (h *History) EndGroup() {
+	if h.depth > 0 {
+		h.depth--
+	}
+}
+
+// Undo undoes the last undo step and moves the cursor to the position of the
+// undone change. Returns false, if there is nothing to undo.
+func (h *History) Undo() bool {
+	if len(h.undo) == 0 {
+		return false
+	}
+
+	step := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.applying = true
+
+	for idx := len(step) - 1; idx >= 0; idx-- {
+		e := step[idx]
+		h.buf.replaceRange(e.Offset, e.Offset+len(e.Inserted), e.Deleted)
+	}
+
+	h.applying = false
+	h.redo = append(h.redo, step)
+
+	return true
+}
+
+// Redo redoes the last undone step and moves the cursor to the position of the
+// change. Returns false, if there is nothing to redo.
+func (h *History) Redo() bool {
+	if len(h.redo) == 0 {
+		return false
+	}
+
+	step := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.applying = true
+
+	for _, e := range step {
+		h.buf.replaceRange(e.Offset, e.Offset+len(e.Deleted), e.Inserted)
+	}
+
+	h.applying = false
+	h.undo = append(h.undo, step)
+
+	return true
+}
+
+// ApplyPatch applies the patch to the gap buffer like [GapBuffer.ApplyPatch],
+// as a single undo step.
+func (h *History) ApplyPatch(r io.Reader) (rejected []Hunk, err error) {
+	h.BeginGroup()
+	defer h.EndGroup()
+
+	return h.buf.ApplyPatch(r)
+}
+
+// record adds an edit to the undo history, it is the [EditFunc] of the
+// history.
+func (h *History) record(e Edit) {
+	if h.applying {
+		return
+	}
+
+	h.redo = nil
+	sameOp := h.op == h.buf.op
+	h.op = h.buf.op
+
+	if len(h.undo) > 0 && ((h.depth > 0 && h.started) || sameOp) {
+		h.undo[len(h.undo)-1] = append(h.undo[len(h.undo)-1], e)
+
+		return
+	}
+
+	h.undo = append(h.undo, []Edit{e})
+	h.started = h.depth > 0
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     history_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUndoRedo(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("Hello World!")
+	history := gapbuffer.NewHistory(gb)
+
+	editSomething(gb)
+	edited := gb.String()
+
+	for history.Undo() {
+	}
+
+	assert.Equal(t, "Hello World!", gb.String(), "Undo everything")
+	assert.False(t, history.CanUndo(), "Nothing to undo")
+
+	for history.Redo() {
+	}
+
+	assert.Equal(t, edited, gb.String(), "Redo everything")
+	assert.False(t, history.CanRedo(), "Nothing to redo")
+}
+
+func TestUndoGroup(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("Hello")
+	history := gapbuffer.NewHistory(gb)
+
+	gb.Insert(",")
+	history.BeginGroup()
+	gb.Insert(" World")
+	history.BeginGroup()
+	gb.LeftDel()
+	history.EndGroup()
+	gb.Insert("d!")
+	history.EndGroup()
+
+	assert.Equal(t, "Hello, World!", gb.String())
+	require.True(t, history.Undo())
+	assert.Equal(t, "Hello,", gb.String(), "Undo the group")
+	assert.Equal(t, 6, gb.Offset(), "Cursor")
+	require.True(t, history.Undo())
+	assert.Equal(t, "Hello", gb.String(), "Undo the first edit")
+
+	require.True(t, history.Redo())
+	gb.Insert("?")
+	assert.False(t, history.CanRedo(), "Edit clears the redo history")
+}
+
+func TestUndoReplaceRange(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("Hello World")
+	history := gapbuffer.NewHistory(gb)
+
+	gb.ReplaceRange(0, 5, "Howdy")
+	require.NoError(t, gb.ApplyContentChange(gapbuffer.TextDocumentContentChangeEvent{
+		Range: &gapbuffer.Range{
+			Start: gapbuffer.Position{Line: 0, Character: 6},
+			End:   gapbuffer.Position{Line: 0, Character: 11},
+		},
+		Text: "Moon",
+	}, gapbuffer.PositionEncodingUTF16))
+	assert.Equal(t, "Howdy Moon", gb.String())
+
+	require.True(t, history.Undo())
+	assert.Equal(t, "Howdy World", gb.String(), "Undo the content change")
+	require.True(t, history.Undo())
+	assert.Equal(t, "Hello World", gb.String(), "Undo the replacement")
+	assert.False(t, history.CanUndo(), "Nothing to undo")
+
+	require.True(t, history.Redo())
+	assert.Equal(t, "Howdy World", gb.String(), "Redo the replacement")
+}
+
+func TestUndoInsertAll(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("a\nb\nc")
+	history := gapbuffer.NewHistory(gb)
+
+	gb.MoveTo(0)
+	gb.AddCursor(2)
+	gb.AddCursor(4)
+	gb.InsertAll("X")
+	gb.LeftDelAll()
+	gb.RightDelAll()
+	assert.Equal(t, "\n\n", gb.String())
+
+	require.True(t, history.Undo())
+	assert.Equal(t, "a\nb\nc", gb.String(), "Undo the deletions to the right")
+	require.True(t, history.Undo())
+	assert.Equal(t, "Xa\nXb\nXc", gb.String(), "Undo the deletions to the left")
+	require.True(t, history.Undo())
+	assert.Equal(t, "a\nb\nc", gb.String(), "Undo the insertions at all cursors")
+	assert.False(t, history.CanUndo(), "Nothing to undo")
+}
+
+func TestUndoFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".text.un~")
+	gb := gapbuffer.NewStr("Hello World!")
+	history := gapbuffer.NewHistory(gb)
+
+	editSomething(gb)
+	require.True(t, history.Undo())
+	require.NoError(t, history.SaveUndoFile(path))
+
+	// "Reopen the file tomorrow".
+	reopened := gapbuffer.NewStr(gb.String())
+	loaded, err := gapbuffer.LoadUndoFile(reopened, path)
+	require.NoError(t, err)
+
+	require.True(t, loaded.Redo())
+	require.True(t, history.Redo())
+	assert.Equal(t, gb.String(), reopened.String(), "Redo")
+
+	for loaded.Undo() {
+	}
+
+	assert.Equal(t, "Hello World!", reopened.String(), "Undo everything")
+}
+
+func TestUndoFileMismatch(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".text.un~")
+	gb := gapbuffer.NewStr("Hello World!")
+	history := gapbuffer.NewHistory(gb)
+
+	gb.Insert("!")
+	require.NoError(t, history.SaveUndoFile(path))
+
+	_, err := gapbuffer.LoadUndoFile(gapbuffer.NewStr("Changed externally"), path)
+	require.ErrorIs(t, err, gapbuffer.ErrUndoFileMismatch)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	data[3] = 42
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	_, err = gapbuffer.LoadUndoFile(gapbuffer.NewStr(gb.String()), path)
+	require.ErrorIs(t, err, gapbuffer.ErrUndoVersion)
+
+	data[3] = 1
+	data[len(data)-1]++
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	_, err = gapbuffer.LoadUndoFile(gapbuffer.NewStr(gb.String()), path)
+	require.ErrorIs(t, err, gapbuffer.ErrCorruptUndoFile)
+}
@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     invalid-utf8.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// InvalidUTF8Policy defines how [GapBuffer.Insert] and [GapBuffer.ReplaceRange]
+// handle strings which are not valid UTF-8.
+//
+// Invalid UTF-8 already in the text, like stray bytes in a binary file, is
+// always kept exactly, [GapBuffer.String] returns the same bytes. Every byte
+// which is not part of a valid UTF-8 encoded rune is handled like a rune of its
+// own: the cursor moves over it one byte at a time, deleting a rune deletes one
+// invalid byte and rune columns count it as one rune. This is how
+// [utf8.DecodeRune] decodes invalid bytes, as [utf8.RuneError] of width 1.
+//
+// Undo and redo in a [History] and replaying a [Journal] restore the text
+// exactly and ignore the policy.
+//
+// [RejectInvalidUTF8] only makes [GapBuffer.Insert] and
+// [GapBuffer.ReplaceRange] do nothing. Methods that return an error return
+// [ErrInvalidUTF8] instead of dropping a rejected string, without changing the
+// text: [GapBuffer.ApplyContentChange], [GapBuffer.ApplyPatch],
+// [GapBuffer.Merge], [GapBuffer.MergeSaved], [GapBuffer.ReplaceBlock],
+// [GapBuffer.PasteBlock] and the checked variants like [GapBuffer.InsertErr],
+// Use [GapBuffer.CanInsert] to check a string before inserting it.
+//
+// All replicas of a shared document must hold the same bytes, so the packages
+// `ot` and `crdt` return [ErrInvalidUTF8] for any invalid UTF-8, unless the
+// policy is [KeepInvalidUTF8], instead of replacing it.
+//
+// See [GapBuffer.SetInvalidUTF8Policy].
+type InvalidUTF8Policy int
+
+const (
+	// Insert invalid UTF-8 unchanged, the default.
+	KeepInvalidUTF8 InvalidUTF8Policy = iota
+
+	// Don't insert strings containing invalid UTF-8, [GapBuffer.Insert] and
+	// [GapBuffer.ReplaceRange] do nothing, methods returning an error return
+	// [ErrInvalidUTF8].
+	RejectInvalidUTF8
+
+	// Replace every run of invalid bytes by the unicode replacement character
+	// U+FFFD before inserting.
+	ReplaceInvalidUTF8
+)
+
+// Return how invalid UTF-8 is inserted.
+//
+// See also [GapBuffer.SetInvalidUTF8Policy].
+func (g *GapBuffer) InvalidUTF8Policy() InvalidUTF8Policy {
+	return g.utf8Policy
+}
+
+// SetInvalidUTF8Policy sets how invalid UTF-8 is inserted, see
+// [InvalidUTF8Policy]. The default is [KeepInvalidUTF8].
+//
+// See also [GapBuffer.InvalidUTF8Policy].
+func (g *GapBuffer) SetInvalidUTF8Policy(policy InvalidUTF8Policy) {
+	g.utf8Policy = policy
+}
+
+// Return false if [GapBuffer.Insert] and [GapBuffer.ReplaceRange] would not
+// insert the string, because it is not valid UTF-8 and the
+// [InvalidUTF8Policy] is [RejectInvalidUTF8].
+func (g *GapBuffer) CanInsert(str string) bool {
+	return g.utf8Policy != RejectInvalidUTF8 || utf8.ValidString(str)
+}
+
+// Return true if the text contains bytes which are not valid UTF-8.
+//
+// See also [GapBuffer.InvalidUTF8Offsets].
+func (g *GapBuffer) HasInvalidUTF8() bool {
+	before, after := g.splitRune()
+	left, right := g.text.Slices(0, g.StringLength())
+
+	return !utf8.Valid(left[:len(left)-before]) || !utf8.Valid(right[after:])
+}
+
+// Return the byte offsets of all bytes in the text which are not part of a
+// valid UTF-8 encoded rune, in ascending order. A sequence of invalid bytes
+// returns the offset of each byte, as the cursor moves over each of them.
+// Returns nil if the text is valid UTF-8.
+//
+// See also [GapBuffer.HasInvalidUTF8].
+func (g *GapBuffer) InvalidUTF8Offsets() []int {
+	if !g.HasInvalidUTF8() {
+		return nil
+	}
+
+	var offsets []int
+
+	for offset := 0; offset < g.StringLength(); {
+		r, size := g.runeAt(offset)
+		if r == utf8.RuneError && size == 1 {
+			offsets = append(offsets, offset)
+		}
+
+		offset += size
+	}
+
+	return offsets
+}
+
+// applyUTF8Policy returns the string to insert according to the
+// [InvalidUTF8Policy], and false if nothing should be inserted.
+func (g *GapBuffer) applyUTF8Policy(str string) (string, bool) {
+	if g.utf8Policy == KeepInvalidUTF8 || utf8.ValidString(str) {
+		return str, true
+	}
+
+	if g.utf8Policy == RejectInvalidUTF8 {
+		return "", false
+	}
+
+	return strings.ToValidUTF8(str, string(utf8.RuneError)), true
+}
@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     invalid-utf8_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"strings"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvalidUTF8RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	text := "a\xffb\xe2\x82c\n\xc3"
+	gb := gapbuffer.NewStr(text)
+	assert.Equal(t, text, gb.String())
+	assert.True(t, gb.HasInvalidUTF8())
+	assert.Equal(t, []int{1, 3, 4, 7}, gb.InvalidUTF8Offsets())
+	assert.Equal(t, 1, gb.RuneCol())
+
+	gb.UpMv()
+	assert.Equal(t, 1, gb.Offset())
+	gb.MoveTo(6)
+	assert.Equal(t, 6, gb.RuneCol())
+
+	offsets := []int{}
+	for gb.LeftMvErr() == nil {
+		offsets = append(offsets, gb.Offset())
+	}
+
+	assert.Equal(t, []int{5, 4, 3, 2, 1, 0}, offsets)
+
+	gb.MoveTo(4)
+	gb.Insert("ä")
+	gb.LeftDel()
+	gb.LeftDel()
+	gb.RightDel()
+	assert.Equal(t, "a\xffbc\n\xc3", gb.String())
+	assert.Equal(t, []int{1, 5}, gb.InvalidUTF8Offsets())
+}
+
+func TestInvalidUTF8SplitByGap(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("aéé")
+	gb.DeleteRange(2, 4)
+	assert.Equal(t, "aé", gb.String())
+	assert.False(t, gb.HasInvalidUTF8())
+	assert.Nil(t, gb.InvalidUTF8Offsets())
+
+	gb.MoveTo(gb.StringLength())
+	assert.Equal(t, 2, gb.RuneCol())
+	gb.LeftMv()
+	assert.Equal(t, 1, gb.Offset())
+	gb.RightMv()
+	assert.Equal(t, 3, gb.Offset())
+}
+
+func TestInvalidUTF8Policy(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("ab")
+	gb.Insert("\xff")
+	assert.Equal(t, "ab\xff", gb.String(), "Keep")
+
+	gb.SetInvalidUTF8Policy(gapbuffer.RejectInvalidUTF8)
+	gb.Insert("c\xfe")
+	gb.ReplaceRange(0, 1, "\xfe")
+	assert.Equal(t, "ab\xff", gb.String(), "Reject")
+	assert.ErrorIs(t, gb.InsertErr("\xfe"), gapbuffer.ErrInvalidUTF8)
+
+	gb.SetInvalidUTF8Policy(gapbuffer.ReplaceInvalidUTF8)
+	gb.Insert("c\xfe\xfdd")
+	gb.ReplaceRange(0, 1, "\xc3")
+	assert.Equal(t, "�b\xffc�d", gb.String(), "Replace")
+	require.NoError(t, gb.InsertErr("\xfe"))
+}
+
+func TestRejectInvalidUTF8Errors(t *testing.T) {
+	t.Parallel()
+
+	text := "line 1\nline 2\n"
+	gb := gapbuffer.NewStr(text)
+	gb.SetInvalidUTF8Policy(gapbuffer.RejectInvalidUTF8)
+	assert.True(t, gb.CanInsert("ü"))
+	assert.False(t, gb.CanInsert("\xfc"))
+
+	err := gb.ApplyContentChange(gapbuffer.TextDocumentContentChangeEvent{Range: nil, Text: "\xfc"},
+		gapbuffer.PositionEncodingUTF8)
+	require.ErrorIs(t, err, gapbuffer.ErrInvalidUTF8)
+
+	_, err = gb.ApplyPatch(strings.NewReader("@@ -1,1 +1,1 @@\n-line 1\n+l\xfcne 1\n"))
+	require.ErrorIs(t, err, gapbuffer.ErrInvalidUTF8)
+
+	require.ErrorIs(t, gb.ReplaceBlock(gapbuffer.NewBlock(1, 0, 2, 0), "\xfc"), gapbuffer.ErrInvalidUTF8)
+	require.ErrorIs(t, gb.PasteBlock(gapbuffer.NewBlock(1, 0, 1, 0), []string{"a", "\xfc"}), gapbuffer.ErrInvalidUTF8)
+	assert.Equal(t, text, gb.String(), "Nothing changed")
+
+	gb.SetInvalidUTF8Policy(gapbuffer.KeepInvalidUTF8)
+	assert.True(t, gb.CanInsert("\xfc"))
+	require.NoError(t, gb.ReplaceBlock(gapbuffer.NewBlock(1, 0, 2, 0), "\xfc"))
+	assert.Equal(t, "\xfcline 1\n\xfcline 2\n", gb.String(), "Kept")
+}
+
+func TestReplaceInvalidUTF8Edits(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("x\ny\nz\n")
+	gb.SetInvalidUTF8Policy(gapbuffer.ReplaceInvalidUTF8)
+	gb.MoveTo(2)
+
+	rejected, err := gb.ApplyPatch(strings.NewReader("@@ -1,1 +1,1 @@\n-x\n+\xffX\n@@ -3,1 +3,1 @@\n-z\n+Z\n"))
+	require.NoError(t, err)
+	assert.Empty(t, rejected)
+	assert.Equal(t, "\uFFFDX\ny\nZ\n", gb.String(), "Patch")
+	assert.Equal(t, strings.Index(gb.String(), "y"), gb.Offset(), "Cursor")
+
+	gb = gapbuffer.NewStr("ab\ncd\n")
+	gb.SetInvalidUTF8Policy(gapbuffer.ReplaceInvalidUTF8)
+	require.NoError(t, gb.ReplaceBlock(gapbuffer.NewBlock(1, 1, 2, 1), "\xff"))
+	assert.Equal(t, "a\uFFFDb\nc\uFFFDd\n", gb.String(), "Block")
+	assert.Equal(t, len("a\uFFFD"), gb.Offset(), "Block cursor")
+
+	require.NoError(t, gb.PasteBlock(gapbuffer.NewBlock(1, 0, 1, 0), []string{"\xfe", "x"}))
+	assert.Equal(t, "\uFFFDa\uFFFDb\nxc\uFFFDd\n", gb.String(), "Paste")
+	assert.Equal(t, len("\uFFFD"), gb.Offset(), "Paste cursor")
+}
+
+func TestInvalidUTF8Undo(t *testing.T) {
+	t.Parallel()
+
+	text := "a\xffb"
+	gb := gapbuffer.NewStr(text)
+	gb.SetInvalidUTF8Policy(gapbuffer.RejectInvalidUTF8)
+	history := gapbuffer.NewHistory(gb)
+
+	gb.DeleteRange(0, 3)
+	assert.Equal(t, "", gb.String())
+	assert.True(t, history.Undo())
+	assert.Equal(t, text, gb.String(), "Undo ignores the policy")
+}
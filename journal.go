@@ -0,0 +1,311 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     journal.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+)
+
+// Journal is an append-only file - a "swap file" - recording every change of a
+// gap buffer, to be able to recover unsaved changes after a crash with
+// [Recover].
+//
+// The journal starts with a checkpoint, a copy of the whole text and the
+// cursor position, followed by one record per [Edit]. After a number of edits,
+// the next [Journal.Sync] replaces the journal by a new one starting with a
+// new checkpoint. Edits only append their record, they never write a
+// checkpoint.
+//
+// File format, all integers are little endian:
+//
+//	header:  "GBJ1"
+//	record:  kind (1 byte) | payload length (uint32) | payload | CRC-32 (uint32)
+//
+// The CRC-32 (IEEE) covers the kind, the length and the payload. The payload of
+// a checkpoint is the cursor offset as unsigned varint followed by the text,
+// the payload of an edit is the offset and the length of the deleted bytes as
+// unsigned varints, followed by the deleted bytes and the inserted text.
+type Journal struct {
+	// The path of the journal file.
+	path string
+
+	// The open journal file.
+	file *os.File
+
+	// The gap buffer whose changes are recorded.
+	buf *GapBuffer
+
+	// Removes the [EditFunc] from the gap buffer.
+	remove func()
+
+	// The number of edits after which [Journal.Sync] writes a new checkpoint.
+	interval int
+
+	// The number of edits since the last checkpoint.
+	edits int
+
+	// The first error writing the journal.
+	err error
+}
+
+// The kinds of journal records.
+const (
+	recCheckpoint byte = iota + 1
+	recEdit
+)
+
+const (
+	// The magic bytes at the start of a journal file.
+	journalMagic = "GBJ1"
+
+	// The size of the kind and length of a record.
+	recHeaderSize = 5
+
+	// The size of the checksum of a record.
+	recCRCSize = 4
+
+	// The default number of edits between two checkpoints.
+	defaultCheckpointInterval = 1000
+
+	// The file mode of a journal file.
+	journalPerm = 0o600
+)
+
+// ErrCorruptJournal is returned by [Recover] if the journal file is not a
+// journal or an edit does not match the text.
+var ErrCorruptJournal = errors.New("corrupt journal file")
+
+// NewJournal creates the journal file at the given path - replacing an existing
+// file - and records all changes of the gap buffer to it. After
+// `checkpointInterval` edits, [Journal.Sync] writes a new checkpoint, if the
+// interval is not positive, 1000 edits are used.
+//
+// Returns an error if the journal file can't be written.
+//
+// See also [Recover], [Journal.Close], [Journal.Remove].
+func NewJournal(g *GapBuffer, path string, checkpointInterval int) (*Journal, error) {
+	if checkpointInterval <= 0 {
+		checkpointInterval = defaultCheckpointInterval
+	}
+
+	j := &Journal{
+		path:     path,
+		file:     nil,
+		buf:      g,
+		remove:   nil,
+		interval: checkpointInterval,
+		edits:    0,
+		err:      nil,
+	}
+
+	if err := j.Checkpoint(); err != nil {
+		return nil, err
+	}
+
+	j.remove = g.AddEditFunc(j.record)
+
+	return j, nil
+}
+
+// Return the first error that happened writing the journal, nil if there has
+// been no error. After an error, no more edits are recorded.
+func (j *Journal) Err() error {
+	return j.err
+}
+
+// Checkpoint replaces the journal by a new one, starting with a checkpoint of
+// the current text and cursor position of the gap buffer.
+//
+// The new journal is written to a temporary file, which replaces the old
+// journal only if it has been written completely.
+func (j *Journal) Checkpoint() error {
+	tmpPath := j.path + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, journalPerm)
+	if err != nil {
+		return err
+	}
+
+	payload := binary.AppendUvarint(nil, uint64(j.buf.Offset()))
+	payload = append(payload, j.buf.String()...)
+	data := appendRecord([]byte(journalMagic), recCheckpoint, payload)
+
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+
+	if err == nil {
+		err = os.Rename(tmpPath, j.path)
+	}
+
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(tmpPath)
+
+		return err
+	}
+
+	if j.file != nil {
+		_ = j.file.Close()
+	}
+
+	j.file = file
+	j.edits = 0
+
+	return nil
+}
+
+// Sync commits the journal to stable storage, see [os.File.Sync]. If there
+// have been at least `checkpointInterval` edits since the last checkpoint, the
+// journal is replaced by a new checkpoint instead, see [Journal.Checkpoint].
+//
+// Call Sync when the editor is idle or on a timer, writing a checkpoint takes
+// time proportional to the length of the text.
+func (j *Journal) Sync() error {
+	if j.edits >= j.interval {
+		return j.Checkpoint()
+	}
+
+	return j.file.Sync()
+}
+
+// Close stops recording the changes of the gap buffer and closes the journal
+// file. The journal file is kept, use [Journal.Remove] to delete it.
+func (j *Journal) Close() error {
+	j.remove()
+
+	return j.file.Close()
+}
+
+// Remove stops recording the changes of the gap buffer and deletes the journal
+// file. Call this after the text has been saved.
+func (j *Journal) Remove() error {
+	err := j.Close()
+
+	return errors.Join(err, os.Remove(j.path))
+}
+
+// record appends an edit to the journal, it is the [EditFunc] of the journal.
+func (j *Journal) record(e Edit) {
+	if j.err != nil {
+		return
+	}
+
+	j.edits++
+	payload := binary.AppendUvarint(nil, uint64(e.Offset))
+	payload = binary.AppendUvarint(payload, uint64(len(e.Deleted)))
+	payload = append(payload, e.Deleted...)
+	payload = append(payload, e.Inserted...)
+
+	_, j.err = j.file.Write(appendRecord(nil, recEdit, payload))
+}
+
+// Recover rebuilds the gap buffer, including the cursor position, from the
+// journal file at the given path.
+//
+// Records are replayed up to the first incomplete record or record with a
+// wrong checksum, like the last record that has been written while crashing.
+//
+// Returns [ErrCorruptJournal] if the file is not a journal or an edit does not
+// match the recovered text.
+func Recover(journalPath string) (*GapBuffer, error) {
+	data, err := os.ReadFile(journalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < len(journalMagic) || string(data[:len(journalMagic)]) != journalMagic {
+		return nil, ErrCorruptJournal
+	}
+
+	data = data[len(journalMagic):]
+
+	kind, payload, data := nextRecord(data)
+	if kind != recCheckpoint {
+		return nil, ErrCorruptJournal
+	}
+
+	cursor, n := binary.Uvarint(payload)
+	if n <= 0 {
+		return nil, ErrCorruptJournal
+	}
+
+	g := NewStr(string(payload[n:]))
+	g.MoveTo(int(cursor))
+
+	for kind, payload, data = nextRecord(data); kind == recEdit; kind, payload, data = nextRecord(data) {
+		if err = replayEdit(g, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+// replayEdit applies the edit record to the gap buffer.
+func replayEdit(g *GapBuffer, payload []byte) error {
+	offset, n := binary.Uvarint(payload)
+	if n <= 0 || offset > uint64(g.StringLength()) {
+		return ErrCorruptJournal
+	}
+
+	payload = payload[n:]
+
+	delLen, n := binary.Uvarint(payload)
+	if n <= 0 || delLen > uint64(len(payload)-n) {
+		return ErrCorruptJournal
+	}
+
+	payload = payload[n:]
+	from := int(offset)
+	to := from + int(delLen)
+
+	if to > g.StringLength() || g.slice(from, to) != string(payload[:delLen]) {
+		return ErrCorruptJournal
+	}
+
+	g.replaceRange(from, to, string(payload[delLen:]))
+
+	return nil
+}
+
+// appendRecord appends a record with the given kind and payload to `data`.
+func appendRecord(data []byte, kind byte, payload []byte) []byte {
+	start := len(data)
+	data = append(data, kind)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(payload)))
+	data = append(data, payload...)
+
+	return binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(data[start:]))
+}
+
+// nextRecord returns the kind and payload of the first record in `data` and the
+// data after the record. The kind is 0, if the record is incomplete or the
+// checksum is wrong.
+func nextRecord(data []byte) (kind byte, payload []byte, rest []byte) {
+	if len(data) < recHeaderSize+recCRCSize {
+		return 0, nil, nil
+	}
+
+	size := binary.LittleEndian.Uint32(data[1:recHeaderSize])
+	if uint64(len(data)) < uint64(recHeaderSize+recCRCSize)+uint64(size) {
+		return 0, nil, nil
+	}
+
+	end := recHeaderSize + int(size)
+	if crc32.ChecksumIEEE(data[:end]) != binary.LittleEndian.Uint32(data[end:end+recCRCSize]) {
+		return 0, nil, nil
+	}
+
+	return data[0], data[recHeaderSize:end], data[end+recCRCSize:]
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     journal_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// editSomething does some edits of the gap buffer.
+func editSomething(gb *gapbuffer.GapBuffer) {
+	gb.Insert("\nfunny\n")
+	gb.LeftMv()
+	gb.LeftMv()
+	gb.LeftDel()
+	gb.UpMv()
+	gb.RightDel()
+	gb.Insert("😀")
+	gb.ReplaceRange(0, 5, "Bye")
+}
+
+func TestAddEditFunc(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("Hello")
+	edits := []gapbuffer.Edit{}
+	remove := gb.AddEditFunc(func(e gapbuffer.Edit) { edits = append(edits, e) })
+
+	gb.Insert("!")
+	gb.LeftMv()
+	gb.LeftDel()
+	gb.ReplaceRange(0, 1, "J")
+	remove()
+	gb.Insert("x")
+
+	assert.Equal(t, []gapbuffer.Edit{
+		{Offset: 5, Deleted: "", Inserted: "!"},
+		{Offset: 4, Deleted: "o", Inserted: ""},
+		{Offset: 0, Deleted: "H", Inserted: ""},
+		{Offset: 0, Deleted: "", Inserted: "J"},
+	}, edits)
+}
+
+func TestJournalRecover(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "text.swp")
+	gb := gapbuffer.NewStr("Hello World!")
+	gb.MoveTo(5)
+
+	journal, err := gapbuffer.NewJournal(gb, path, 0)
+	require.NoError(t, err)
+
+	editSomething(gb)
+	require.NoError(t, journal.Err())
+
+	recovered, err := gapbuffer.Recover(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, gb.String(), recovered.String())
+	assert.Equal(t, gb.Offset(), recovered.Offset(), "Cursor")
+	assert.Equal(t, gb.LineCount(), recovered.LineCount(), "Line count")
+
+	require.NoError(t, journal.Remove())
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestJournalCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "text.swp")
+	gb := gapbuffer.New()
+
+	journal, err := gapbuffer.NewJournal(gb, path, 3)
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		gb.Insert("0123456789")
+	}
+
+	edits := journalSize(t, path)
+	require.NoError(t, journal.Sync())
+	checkpoint := journalSize(t, path)
+	assert.Less(t, checkpoint, edits, "Checkpoint written by Sync")
+
+	gb.Insert("0123456789")
+	require.NoError(t, journal.Sync())
+	assert.Greater(t, journalSize(t, path), checkpoint, "Interval not reached")
+
+	gb.MoveTo(17)
+	require.NoError(t, journal.Checkpoint())
+	require.NoError(t, journal.Close())
+
+	recovered, err := gapbuffer.Recover(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, gb.String(), recovered.String())
+	assert.Equal(t, 17, recovered.Offset(), "Cursor")
+}
+
+// journalSize returns the size of the journal file in bytes.
+func journalSize(t *testing.T, path string) int64 {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	return info.Size()
+}
+
+func TestJournalTornRecord(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "text.swp")
+	gb := gapbuffer.NewStr("Hello")
+
+	journal, err := gapbuffer.NewJournal(gb, path, 0)
+	require.NoError(t, err)
+
+	gb.Insert(" World")
+	gb.Insert("!")
+	require.NoError(t, journal.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-2))
+
+	recovered, err := gapbuffer.Recover(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello World", recovered.String())
+}
+
+func TestJournalCorrupt(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "text.swp")
+	require.NoError(t, os.WriteFile(path, []byte("not a journal"), 0o600))
+
+	_, err := gapbuffer.Recover(path)
+	require.ErrorIs(t, err, gapbuffer.ErrCorruptJournal)
+}
@@ -9,33 +9,28 @@
 
 package gapbuffer
 
-import "strings"
+import (
+	"bytes"
+	"strings"
+)
 
 // This is a gap buffer which holds the line lengths of the lines in `GapBuffer`.
 //
 // Warning: this data structure does not check it's function arguments, it is
 // only meant to be called by [GapBuffer] if needed and the arguments are valid.
 type lineBuffer struct {
-	// The start of the gap, the index of the current line in `lengths`.
+	// The lengths of the lines in the gap buffer. This includes the new line
+	// character at the end of each line. Only the last line does not have a
+	// new line character at the end and may have a length of zero, every other
+	// line but the last has a length of at least one.
 	//
-	// Note: the string after `GapBuffer.end` may be part of the current line.
-	//
-	// See [lineBuffer.end]
-	start int
+	// The current line is the last one before the gap, the gap is after the
+	// current line.
+	lengths Gap[int]
 
-	// The end of the gap, the index of the "next" line after the current one,
-	// if such a line exists.
-	//
-	// Note:
-	//
-	// See [lineBuffer.start]
-	end int
-
-	// The array holding the lengths of the lines in the gap buffer. This
-	// includes the new line character at the end of each line. Only the last
-	// line does not have a new line character at the end and may have a length
-	// of zero, every other line but the last has a length of at least one.
-	lengths []int
+	// The index in the gap buffer of the first character of the current line,
+	// the sum of the lengths of all lines before the current one.
+	offset int
 }
 
 // newLineBuf returns a new line buffer from the given capacity of the parent
@@ -43,7 +38,7 @@ type lineBuffer struct {
 // [lineCapFactor] and [minLineCap].
 func newLineBuf(c int) *lineBuffer {
 	cR := max(c/lineCapFactor, minLineCap)
-	lb := &lineBuffer{start: 0, end: cR, lengths: make([]int, cR)}
+	lb := &lineBuffer{lengths: Gap[int]{start: 1, end: cR, data: make([]int, cR)}, offset: 0}
 
 	return lb
 }
@@ -78,105 +73,154 @@ func (l *lineBuffer) insert(str string, pos int) {
 		return
 	}
 
-	lens := lineLengths(str)
-	if l.end-l.start < len(lens)+1 {
-		l.grow()
+	l.insertLengths(lineLengths(str), pos)
+}
+
+// insertLengths inserts lines with the given lengths, like the ones returned
+// by [lineLengths], at the position `pos` in the current line, see
+// [lineBuffer.insert].
+func (l *lineBuffer) insertLengths(lens []int, pos int) {
+	l.lengths.Reserve(len(lens))
+
+	lens[0] += pos - l.offset
+	lens[len(lens)-1] += l.offset + l.curLineLength() - pos
+
+	for _, n := range lens[:len(lens)-1] {
+		l.offset += n
 	}
 
-	lens[0] += pos - l.curLineStart()
-	lens[len(lens)-1] += l.curLineStart() + l.curLineLength() - pos
+	l.lengths.Set(l.cur(), lens[0])
+	l.lengths.Insert(l.cur()+1, lens[1:]...)
+}
 
-	for idx := range lens {
-		l.lengths[l.start+idx] = lens[idx]
+// appendText adds the lines of the given text, which has been appended to the
+// end of the text. The first line of the text continues the last line.
+func (l *lineBuffer) appendText(text []byte) {
+	lens := make([]int, 0, bytes.Count(text, []byte{'\n'})+1)
+
+	for {
+		nl := bytes.IndexByte(text, '\n')
+		if nl < 0 {
+			l.appendLengths(append(lens, len(text)))
+
+			return
+		}
+
+		lens = append(lens, nl+1)
+		text = text[nl+1:]
 	}
+}
 
-	l.start += len(lens) - 1
+// appendLengths adds the lines with the given lengths, like the ones returned
+// by [lineLengths], to the end of the line buffer. The first length is added
+// to the last line.
+func (l *lineBuffer) appendLengths(lens []int) {
+	last := l.lengths.Len() - 1
+	l.lengths.Set(last, l.lengths.At(last)+lens[0])
+	l.lengths.appendElems(lens[1:]...)
 }
 
-// size returns the size of the lineBuffer in ints, including the "empty" space
-// of the gap.
-func (l *lineBuffer) size() int {
-	return len(l.lengths)
+// cur returns the index of the current line, the last one before the gap.
+func (l *lineBuffer) cur() int {
+	return l.lengths.start - 1
 }
 
 // up reacts to a movement of the curser up one line.
 //
-// The line length of the current line is set as the length of [lineBuffer.end],
-// and the gap is moved one step to the left.
+// The gap is moved one step to the left, before the current line.
 //
 // Warning: this function does not check if the cursor is in the first line, if
 // it is, this panics!
 func (l *lineBuffer) up() {
-	l.end--
-	l.lengths[l.end] = l.lengths[l.start]
-	l.start--
+	l.lengths.stepLeft()
+	l.offset -= l.lengths.At(l.cur())
 }
 
 // upDel reacts to the deletion of the newline before the cursor.
 //
-// The gap is widened one step to the left.
+// The gap is widened one step to the left and the current line is joined with
+// the previous one, which loses its newline character.
 //
 // Warning: this function does not check if the cursor is in the first line, if
 // it is, this panics!
 func (l *lineBuffer) upDel() {
-	l.start--
+	cur := l.cur()
+	l.offset -= l.lengths.At(cur - 1)
+	l.lengths.Set(cur-1, l.lengths.At(cur-1)+l.lengths.At(cur)-1)
+	l.lengths.Delete(cur, cur+1)
 }
 
 // down reacts to a movement of the curser down one line.
 //
-// The line length of the next line - of [lineBuffer.end is set as the length of
-// the current line, and the gap is moved one step to the right.
+// The gap is moved one step to the right, after the next line.
 //
 // Warning: this function does not check if the cursor is in the last line, if
 // it is, this panics!
 func (l *lineBuffer) down() {
-	l.start++
-	l.lengths[l.start] = l.lengths[l.end]
-	l.end++
+	l.offset += l.lengths.At(l.cur())
+	l.lengths.stepRight()
 }
 
 // downDel reacts to the deletion of the newline after the cursor.
 //
-// The gap is widened one step to the right.
+// The gap is widened one step to the right and the next line is joined with
+// the current one, which loses its newline character.
 //
 // Warning: this function does not check if the cursor is in the last line, if
 // it is, this panics!
 func (l *lineBuffer) downDel() {
-	l.end++
+	cur := l.cur()
+	l.lengths.Set(cur, l.lengths.At(cur)+l.lengths.At(cur+1)-1)
+	l.lengths.Delete(cur+1, cur+2)
 }
 
-// del reacts to the deletion of a rune by shortening the line length by the
-// number of bytes given. If the current line length already is zero, nothing
-// happens.
-func (l *lineBuffer) del(b int) {
-	if l.lengths[l.start] == 0 {
-		return
-	}
+// delLeft reacts to the deletion of `b` bytes containing `nl` newline
+// characters to the left of the cursor. The current line is joined with the
+// `nl` lines before it.
+//
+// Warning: this function does not check if there are `nl` lines before the
+// current one, if there aren't, this panics!
+func (l *lineBuffer) delLeft(b int, nl int) {
+	cur := l.cur()
+	sum := 0
+	l.lengths.Each(cur-nl, cur, func(_ int, n int) bool {
+		sum += n
+
+		return true
+	})
 
-	l.lengths[l.start] -= b
+	l.offset -= sum
+	sum += l.lengths.At(cur)
+	l.lengths.Delete(cur-nl+1, cur+1)
+	l.lengths.Set(cur-nl, sum-b)
 }
 
-// newlineSplit splits the given string into lines, by splitting on newline
-// characters '\n' and returning the substrings in a slice.
-//
-// If a substring between two newlines is empty, the empty string is added to
-// the slice. The number of elements, (the length) of the returned slice is at
-// least the number of newline characters in the given string.
+// delRight reacts to the deletion of `b` bytes containing `nl` newline
+// characters to the right of the cursor. The current line is joined with the
+// `nl` lines after it.
 //
-// Example:
-//
-//	newlineSplit("\nfunny\n") == ["", "funny"]
-func newlineSplit(str string) []string {
-	line, rest, _ := strings.Cut(str, "\n")
-	lines := make([]string, 0)
-	lines = append(lines, line)
-
-	for rest != "" {
-		line, rest, _ = strings.Cut(rest, "\n")
-		lines = append(lines, line)
-	}
+// Warning: this function does not check if there are `nl` lines after the
+// current one, if there aren't, this panics!
+func (l *lineBuffer) delRight(b int, nl int) {
+	cur := l.cur()
+	sum := 0
+	l.lengths.Each(cur, cur+nl+1, func(_ int, n int) bool {
+		sum += n
+
+		return true
+	})
+
+	l.lengths.Delete(cur+1, cur+nl+1)
+	l.lengths.Set(cur, sum-b)
+}
 
-	return lines
+// del reacts to the deletion of a rune by shortening the line length by the
+// number of bytes given. If the current line length already is zero, nothing
+// happens.
+func (l *lineBuffer) del(b int) {
+	if n := l.lengths.At(l.cur()); n > 0 {
+		l.lengths.Set(l.cur(), n-b)
+	}
 }
 
 // lineLengths returns the lengths of the lines in bytes in the given string in
@@ -189,77 +233,100 @@ func newlineSplit(str string) []string {
 // Example:
 //
 //	lineLengths("\nfunny\n") == [1, 6, 0]
-//
-// Warning: does not work with empty strings!
 func lineLengths(str string) []int {
-	lines := newlineSplit(str)
+	lens := make([]int, 0, strings.Count(str, "\n")+1)
 
-	lens := make([]int, 0, len(lines))
-
-	for i := range lines {
-		lens = append(lens, len(lines[i])+1)
-	}
+	for {
+		nl := strings.IndexByte(str, '\n')
+		if nl < 0 {
+			return append(lens, len(str))
+		}
 
-	if strings.HasSuffix(str, "\n") {
-		lens = append(lens, 0)
-	} else {
-		lens[len(lens)-1]--
+		lens = append(lens, nl+1)
+		str = str[nl+1:]
 	}
-
-	return lens
-}
-
-// grow resizes the line buffer by `growFactor` times its current size and
-// copies the existing data.
-func (l *lineBuffer) grow() {
-	tmp := make([]int, growFactor*l.size())
-	_ = copy(tmp, l.lengths[:l.start+1])
-	nE := len(tmp) - (l.size() - l.end)
-	_ = copy(tmp[nE:], l.lengths[l.end:])
-	l.end = nE
-	l.lengths = tmp
 }
 
 // curLine returns the number of the current line, starting from 1.
 func (l *lineBuffer) curLine() int {
-	return l.start + 1
+	return l.lengths.start
 }
 
 // curLineLength returns the length of the current line, including the final
 // newline character, if it isn't the last line.
 func (l *lineBuffer) curLineLength() int {
-	return l.lengths[l.start]
+	return l.lengths.At(l.cur())
 }
 
 // isLastLine returns true if the cursor is in the last line.
 func (l *lineBuffer) isLastLine() bool {
-	return l.end == l.size()
+	return l.lengths.end == l.lengths.Cap()
 }
 
 // curLineStart returns the index in the gap buffer of the first character in
 // the current line. This is the sum of all line length before the current line.
 func (l *lineBuffer) curLineStart() int {
-	if l.start == 0 {
-		return 0
+	return l.offset
+}
+
+// lineCount returns the number of lines, which is at least 1.
+func (l *lineBuffer) lineCount() int {
+	return l.lengths.Len()
+}
+
+// lineLength returns the length of the line with the given index, starting
+// from 0, including the final newline character, if it isn't the last line.
+//
+// Warning: this function does not check if the index is valid, if it isn't,
+// this panics or returns garbage!
+func (l *lineBuffer) lineLength(idx int) int {
+	return l.lengths.At(idx)
+}
+
+// lineStart returns the index in the gap buffer of the first character in the
+// line with the given index, starting from 0. This is the sum of all line
+// lengths before the line.
+//
+// Warning: this function does not check if the index is valid, if it isn't,
+// this panics or returns garbage!
+func (l *lineBuffer) lineStart(idx int) int {
+	if idx == l.cur() {
+		return l.offset
 	}
 
 	sum := 0
-	for i := range l.lengths[:l.start] {
-		sum += l.lengths[i]
-	}
+	l.lengths.Each(0, idx, func(_ int, n int) bool {
+		sum += n
+
+		return true
+	})
 
 	return sum
 }
 
+// lineIndex returns the index of the line, starting from 0, that contains the
+// given index in the gap buffer. An index at the very end of the text belongs to
+// the last line.
+func (l *lineBuffer) lineIndex(pos int) int {
+	sum := 0
+	last := l.lineCount() - 1
+
+	for idx := 0; idx < last; idx++ {
+		sum += l.lineLength(idx)
+		if pos < sum {
+			return idx
+		}
+	}
+
+	return last
+}
+
 // curLineEnd returns the index in the gap buffer of the last character in the
 // current line, including the newline character. This is the sum of all
 // line lengths before the current line and the length of the current line minus
 // one to get the index instead of the length.
 func (l *lineBuffer) curLineEnd() int {
-	sum := 0
-	for i := range l.lengths[:l.start+1] {
-		sum += l.lengths[i]
-	}
+	sum := l.offset + l.curLineLength()
 
 	// do not subtract from a zero length line.
 	if l.curLineLength() == 0 {
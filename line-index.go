@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     line-index.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"strings"
+	"sync"
+)
+
+// lineIndexer searches the lines of a text in a background goroutine, see
+// [NewStrIndexed]. The [GapBuffer] takes the lengths of the lines found so far
+// when it needs them.
+type lineIndexer struct {
+	// Guards all other fields.
+	mu sync.Mutex
+
+	// Signals that lines have been found or the indexer is done.
+	found *sync.Cond
+
+	// The lengths of the complete lines found and not yet taken, including
+	// the newline character.
+	lengths []int
+
+	// The length of the last line of the text, without a newline character at
+	// the end. Only valid if `done` is true.
+	last int
+
+	// True, if the whole text has been searched.
+	done bool
+}
+
+// newLineIndexer starts searching the lines of the given text in a new
+// goroutine. The function `progress` is called from this goroutine after
+// every chunk of [indexChunkSize] bytes, if it is not nil.
+func newLineIndexer(text string, progress func(indexed int, total int)) *lineIndexer {
+	x := &lineIndexer{
+		mu:      sync.Mutex{},
+		found:   nil,
+		lengths: nil,
+		last:    0,
+		done:    false,
+	}
+	x.found = sync.NewCond(&x.mu)
+
+	go x.run(text, progress)
+
+	return x
+}
+
+// run searches the lines of the text in chunks of [indexChunkSize] bytes.
+func (x *lineIndexer) run(text string, progress func(indexed int, total int)) {
+	lineStart := 0
+
+	for pos := 0; pos < len(text); {
+		end := min(pos+indexChunkSize, len(text))
+		lens := make([]int, 0, indexChunkSize/64)
+
+		for pos < end {
+			nl := strings.IndexByte(text[pos:end], '\n')
+			if nl < 0 {
+				pos = end
+
+				break
+			}
+
+			pos += nl + 1
+			lens = append(lens, pos-lineStart)
+			lineStart = pos
+		}
+
+		x.mu.Lock()
+		x.lengths = append(x.lengths, lens...)
+		x.done = pos == len(text)
+		x.last = len(text) - lineStart
+		x.mu.Unlock()
+		x.found.Broadcast()
+
+		if progress != nil {
+			progress(pos, len(text))
+		}
+	}
+}
+
+// take waits until new lines have been found or the whole text has been
+// searched, and returns the lengths of the lines like [lineLengths] does: the
+// lengths of the complete lines and the length of the rest of the line after
+// the last newline. The returned rest is only valid if done is true, else it
+// is zero.
+func (x *lineIndexer) take() (lens []int, done bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for len(x.lengths) == 0 && !x.done {
+		x.found.Wait()
+	}
+
+	lens = append(x.lengths, 0)
+	x.lengths = nil
+
+	if x.done {
+		lens[len(lens)-1] = x.last
+	}
+
+	return lens, x.done
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     line-index_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStrIndexed(t *testing.T) {
+	t.Parallel()
+
+	text := largeText()
+
+	var (
+		mu       sync.Mutex
+		progress []int
+	)
+
+	gb := gapbuffer.NewStrIndexed(text, func(indexed int, total int) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		assert.Equal(t, len(text), total)
+		progress = append(progress, indexed)
+	})
+	exp := gapbuffer.NewStr(text)
+	exp.MoveTo(0)
+
+	assert.Equal(t, 0, gb.Offset())
+	assert.Equal(t, 1, gb.Line())
+	gb.MoveTo(len(text) / 2)
+	exp.MoveTo(len(text) / 2)
+	requireSameState(t, exp, gb)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return len(progress) > 0 && progress[len(progress)-1] == len(text)
+	}, time.Second, time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.IsIncreasing(t, progress)
+}
+
+func TestNewStrIndexedQueries(t *testing.T) {
+	t.Parallel()
+
+	text := largeText()
+	exp := gapbuffer.NewStr(text)
+
+	gb := gapbuffer.NewStrIndexed(text, nil)
+	offset, err := gb.LineColToOffset(50_000, 3)
+	require.NoError(t, err)
+	expOffset, _ := exp.LineColToOffset(50_000, 3)
+	assert.Equal(t, expOffset, offset)
+
+	gb = gapbuffer.NewStrIndexed(text, nil)
+	line, col, err := gb.OffsetToLineCol(len(text) - 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{100_000, 63}, []int{line, col})
+
+	gb = gapbuffer.NewStrIndexed(text, nil)
+	block := gapbuffer.NewBlock(70_000, 2, 70_003, 5)
+	assert.Equal(t, exp.BlockText(block), gb.BlockText(block))
+
+	gb = gapbuffer.NewStrIndexed(text, nil)
+	assert.Equal(t, exp.LineCount(), gb.LineCount())
+
+	gb = gapbuffer.NewStrIndexed("", nil)
+	assert.Equal(t, 1, gb.LineCount())
+}
+
+func TestNewStrIndexedRandom(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(73)) //nolint:gosec // test
+	text := strings.Repeat("line ä\n\n"+strings.Repeat("long line ", 2_000)+"\n", 20)
+
+	for i := 0; i < 10; i++ {
+		gb := gapbuffer.NewStrIndexed(text, nil)
+		exp := gapbuffer.NewStr(text)
+		exp.MoveTo(0)
+
+		for j := 0; j < 20; j++ {
+			randomEdit(rnd, exp, gb)
+		}
+
+		requireSameState(t, exp, gb)
+	}
+}
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     marker.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+// Marker is a byte offset in the text of a gap buffer which moves along with
+// the text when the text is changed, like a bookmark.
+//
+// Text inserted at the offset of the marker is inserted after the marker, if
+// the text around the marker is deleted, the marker moves to the start of the
+// deleted text.
+type Marker struct {
+	// The byte offset of the marker.
+	offset int
+
+	// Removes the [EditFunc] of the marker from the gap buffer.
+	remove func()
+}
+
+// AddMarker returns a new marker at the given byte offset, the offset is
+// clamped to the text like [GapBuffer.MoveTo] does.
+//
+// See also [Marker.Remove].
+func (g *GapBuffer) AddMarker(offset int) *Marker {
+	m := &Marker{offset: g.clamp(offset), remove: nil}
+	m.remove = g.AddEditFunc(m.update)
+
+	return m
+}
+
+// Return the byte offset of the marker.
+func (m *Marker) Offset() int {
+	return m.offset
+}
+
+// Remove removes the marker from the gap buffer, the offset is not updated
+// anymore.
+func (m *Marker) Remove() {
+	m.remove()
+}
+
+// update moves the marker along with the edit, it is the [EditFunc] of the
+// marker.
+func (m *Marker) update(e Edit) {
+	if m.offset <= e.Offset {
+		return
+	}
+
+	m.offset = max(m.offset-len(e.Deleted), e.Offset) + len(e.Inserted)
+}
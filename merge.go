@@ -0,0 +1,419 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     merge.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import "strings"
+
+// ConflictStyle is the way [GapBuffer.Merge] marks conflicts in the text.
+type ConflictStyle int
+
+const (
+	// ConflictMarkers replaces each conflict by both versions between the
+	// conflict markers `<<<<<<<`, `=======` and `>>>>>>>`, like Git.
+	ConflictMarkers ConflictStyle = iota
+
+	// ConflictDiff3 is like [ConflictMarkers] but adds the lines of the base
+	// after a `|||||||` marker.
+	ConflictDiff3
+
+	// ConflictRegions keeps the text of the gap buffer in a conflict, the
+	// conflicts are only returned as [Conflict]s.
+	ConflictRegions
+)
+
+// MergeOptions are the options of [GapBuffer.Merge].
+type MergeOptions struct {
+	// How to mark conflicts.
+	Style ConflictStyle
+
+	// The label of the text of the gap buffer after the `<<<<<<<` marker.
+	OursLabel string
+
+	// The label of the base after the `|||||||` marker.
+	BaseLabel string
+
+	// The label of the other text after the `>>>>>>>` marker.
+	TheirsLabel string
+}
+
+// DefaultMergeOptions are the options to mark conflicts with Git's conflict
+// markers.
+var DefaultMergeOptions = MergeOptions{ //nolint:gochecknoglobals // default
+	Style:       ConflictMarkers,
+	OursLabel:   "buffer",
+	BaseLabel:   "base",
+	TheirsLabel: "file",
+}
+
+// Conflict is a part of the text which has been changed differently in the gap
+// buffer and in the other text, relative to the base.
+type Conflict struct {
+	// The byte offset of the start of the conflict in the merged text, the
+	// start of the `<<<<<<<` line if the conflict is marked by conflict
+	// markers.
+	Start int
+
+	// The byte offset of the end of the conflict (exclusive) in the merged
+	// text.
+	End int
+
+	// The lines of the base.
+	Base string
+
+	// The lines of the gap buffer.
+	Ours string
+
+	// The lines of the other text.
+	Theirs string
+}
+
+// Merge merges the changes from `base` to `theirs` into the gap buffer, which
+// contains changes from `base` to its text, like `diff3` or `git merge-file`
+// do, line by line.
+//
+// Changes of only one side are taken, changes of both sides to the same or
+// adjacent lines are a conflict, unless both changes are the same. Conflicts
+// are marked depending on `opts.Style` and are returned in the order of their
+// position in the text.
+//
+// The cursor and all [Marker]s keep their position in the text, if their line
+// has not been changed by `theirs`.
+//
+// The inserted lines of `theirs`, the lines of the base of a [ConflictDiff3]
+// conflict and the labels are handled according to the [InvalidUTF8Policy].
+// Returns [ErrInvalidUTF8] if one of them is rejected, nothing is changed in
+// this case.
+//
+// See also [GapBuffer.MergeSaved].
+func (g *GapBuffer) Merge(base string, theirs string, opts MergeOptions) ([]Conflict, error) {
+	edits, err := g.mergeEdits(base, theirs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	g.beginOp()
+	defer g.endOp()
+
+	cursor := g.AddMarker(g.Offset())
+	defer cursor.Remove()
+
+	var conflicts []Conflict
+
+	// The difference of the byte offsets in the gap buffer to the offsets of
+	// the edits.
+	delta := 0
+
+	for _, edit := range edits {
+		from, to := edit.from+delta, edit.to+delta
+
+		if edit.replace {
+			g.replaceRange(from, to, edit.text)
+			delta += len(edit.text) - (to - from)
+			to = from + len(edit.text)
+		}
+
+		if edit.conflict != nil {
+			edit.conflict.Start, edit.conflict.End = from, to
+			conflicts = append(conflicts, *edit.conflict)
+		}
+	}
+
+	g.MoveTo(cursor.Offset())
+
+	return conflicts, nil
+}
+
+// MergeSaved merges the changes from the saved baseline to `theirs` into the
+// gap buffer, see [GapBuffer.Merge], and sets `theirs` as the new saved
+// baseline. Use this if the file has been changed by another program while
+// being edited.
+//
+// Returns [ErrInvalidUTF8] like [GapBuffer.Merge], the saved baseline is not
+// changed in this case.
+//
+// See also [GapBuffer.MarkSaved].
+func (g *GapBuffer) MergeSaved(theirs string, opts MergeOptions) ([]Conflict, error) {
+	conflicts, err := g.Merge(g.saved, theirs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	g.saved = theirs
+	g.resetChanges()
+
+	return conflicts, nil
+}
+
+// mergeEdit is a replacement of lines of the gap buffer by [GapBuffer.Merge].
+type mergeEdit struct {
+	// The byte offsets of the replaced lines in the gap buffer before the
+	// merge.
+	from int
+	to   int
+
+	// The text to insert, after applying the [InvalidUTF8Policy].
+	text string
+
+	// False, if the lines are kept, like a conflict of [ConflictRegions].
+	replace bool
+
+	// The conflict, nil if only `theirs` changed the lines.
+	conflict *Conflict
+}
+
+// mergeEdits returns the edits of [GapBuffer.Merge] in the order of their
+// position in the text, without changing the gap buffer. Returns
+// [ErrInvalidUTF8] if a text to insert is rejected by the [InvalidUTF8Policy].
+func (g *GapBuffer) mergeEdits(base string, theirs string, opts MergeOptions) ([]mergeEdit, error) {
+	baseLines, ourLines, theirLines := splitLines(base), g.lineStrings(), splitLines(theirs)
+
+	ids := make(map[string]int, len(baseLines))
+	b := internLines(ids, baseLines)
+	ours := diffRegions(b, internLines(ids, ourLines))
+	other := diffRegions(b, internLines(ids, theirLines))
+
+	// The byte offsets of the lines of the gap buffer.
+	offsets := make([]int, len(ourLines)+1)
+	for idx, line := range ourLines {
+		offsets[idx+1] = offsets[idx] + len(line)
+	}
+
+	var edits []mergeEdit
+
+	for _, chunk := range mergeChunks(ours, other) {
+		ourStart, ourEnd := chunk.ours.sideStart, chunk.ours.sideEnd
+		from, to := offsets[ourStart], offsets[ourEnd]
+		ourText := strings.Join(ourLines[ourStart:ourEnd], "")
+		theirText := strings.Join(theirLines[chunk.theirs.sideStart:chunk.theirs.sideEnd], "")
+
+		if !chunk.hasTheirs || ourText == theirText {
+			continue
+		}
+
+		theirText, ok := g.applyUTF8Policy(theirText)
+		if !ok {
+			return nil, ErrInvalidUTF8
+		}
+
+		if !chunk.hasOurs {
+			edits = append(edits, mergeEdit{from: from, to: to, text: theirText, replace: true, conflict: nil})
+
+			continue
+		}
+
+		conflict := &Conflict{
+			Start:  from,
+			End:    to,
+			Base:   strings.Join(baseLines[chunk.baseStart:chunk.baseEnd], ""),
+			Ours:   ourText,
+			Theirs: theirText,
+		}
+		edit := mergeEdit{from: from, to: to, text: "", replace: false, conflict: conflict}
+
+		if opts.Style != ConflictRegions {
+			text, err := g.markConflict(conflict, opts)
+			if err != nil {
+				return nil, err
+			}
+
+			edit.text, edit.replace = text, true
+		}
+
+		edits = append(edits, edit)
+	}
+
+	return edits, nil
+}
+
+// markConflict returns the conflict marked by conflict markers, after applying
+// the [InvalidUTF8Policy] to the base of a [ConflictDiff3] conflict and to the
+// labels. Our lines are already in the gap buffer and are kept unchanged.
+func (g *GapBuffer) markConflict(c *Conflict, opts MergeOptions) (string, error) {
+	texts := []*string{&opts.OursLabel, &opts.BaseLabel, &opts.TheirsLabel}
+	if opts.Style == ConflictDiff3 {
+		texts = append(texts, &c.Base)
+	}
+
+	for _, text := range texts {
+		replaced, ok := g.applyUTF8Policy(*text)
+		if !ok {
+			return "", ErrInvalidUTF8
+		}
+
+		*text = replaced
+	}
+
+	return conflictText(*c, opts), nil
+}
+
+// conflictText returns the conflict marked by conflict markers.
+func conflictText(c Conflict, opts MergeOptions) string {
+	var builder strings.Builder
+
+	writeSide := func(marker string, label string, text string) {
+		builder.WriteString(marker)
+
+		if label != "" {
+			builder.WriteString(" " + label)
+		}
+
+		builder.WriteString("\n" + text)
+
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			builder.WriteByte('\n')
+		}
+	}
+
+	writeSide("<<<<<<<", opts.OursLabel, c.Ours)
+
+	if opts.Style == ConflictDiff3 {
+		writeSide("|||||||", opts.BaseLabel, c.Base)
+	}
+
+	writeSide("=======", "", c.Theirs)
+	builder.WriteString(">>>>>>>")
+
+	if opts.TheirsLabel != "" {
+		builder.WriteString(" " + opts.TheirsLabel)
+	}
+
+	builder.WriteByte('\n')
+
+	return builder.String()
+}
+
+// diffRegion is a changed part of a diff: the lines `baseStart` to `baseEnd`
+// (exclusive) of the base have been replaced by the lines `sideStart` to
+// `sideEnd` of the changed text.
+type diffRegion struct {
+	baseStart int
+	baseEnd   int
+	sideStart int
+	sideEnd   int
+}
+
+// diffRegions returns the changed regions of the diff from `base` to `side`.
+func diffRegions(base []int, side []int) []diffRegion {
+	var regions []diffRegion
+
+	ops := myersDiff(base, side, make([]DiffOp, 0, len(base)+len(side)))
+	baseIdx, sideIdx := 0, 0
+
+	for pos := 0; pos < len(ops); {
+		if ops[pos] == DiffEqual {
+			baseIdx++
+			sideIdx++
+			pos++
+
+			continue
+		}
+
+		region := diffRegion{baseStart: baseIdx, baseEnd: baseIdx, sideStart: sideIdx, sideEnd: sideIdx}
+
+		for ; pos < len(ops) && ops[pos] != DiffEqual; pos++ {
+			if ops[pos] == DiffDelete {
+				region.baseEnd++
+			} else {
+				region.sideEnd++
+			}
+		}
+
+		baseIdx, sideIdx = region.baseEnd, region.sideEnd
+		regions = append(regions, region)
+	}
+
+	return regions
+}
+
+// mergeChunk is a part of the base that has been changed by at least one
+// side, with the corresponding lines of both sides.
+type mergeChunk struct {
+	baseStart int
+	baseEnd   int
+	ours      diffRegion
+	theirs    diffRegion
+	hasOurs   bool
+	hasTheirs bool
+}
+
+// mergeChunks returns the parts of the base changed by one or both sides,
+// overlapping or adjacent changes are in the same chunk.
+func mergeChunks(ours []diffRegion, theirs []diffRegion) []mergeChunk {
+	var chunks []mergeChunk
+
+	// The offsets of the line numbers of the sides to the base, before the
+	// current chunk.
+	ourDelta, theirDelta := 0, 0
+
+	for len(ours) > 0 || len(theirs) > 0 {
+		start := 0
+
+		switch {
+		case len(theirs) == 0 || (len(ours) > 0 && ours[0].baseStart < theirs[0].baseStart):
+			start = ours[0].baseStart
+		default:
+			start = theirs[0].baseStart
+		}
+
+		end := start
+		nOurs, nTheirs := 0, 0
+
+		for {
+			switch {
+			case nOurs < len(ours) && ours[nOurs].baseStart <= end:
+				end = max(end, ours[nOurs].baseEnd)
+				nOurs++
+
+				continue
+			case nTheirs < len(theirs) && theirs[nTheirs].baseStart <= end:
+				end = max(end, theirs[nTheirs].baseEnd)
+				nTheirs++
+
+				continue
+			}
+
+			break
+		}
+
+		chunk := mergeChunk{
+			baseStart: start,
+			baseEnd:   end,
+			ours:      chunkSide(ours[:nOurs], start, end, ourDelta),
+			theirs:    chunkSide(theirs[:nTheirs], start, end, theirDelta),
+			hasOurs:   nOurs > 0,
+			hasTheirs: nTheirs > 0,
+		}
+		chunks = append(chunks, chunk)
+
+		ourDelta = chunk.ours.sideEnd - end
+		theirDelta = chunk.theirs.sideEnd - end
+		ours, theirs = ours[nOurs:], theirs[nTheirs:]
+	}
+
+	return chunks
+}
+
+// chunkSide returns the region of a side corresponding to the lines `start` to
+// `end` of the base, given the regions of the side in the chunk and the offset
+// of the line numbers of the side before the chunk.
+func chunkSide(regions []diffRegion, start int, end int, delta int) diffRegion {
+	if len(regions) == 0 {
+		return diffRegion{baseStart: start, baseEnd: end, sideStart: start + delta, sideEnd: end + delta}
+	}
+
+	first, last := regions[0], regions[len(regions)-1]
+
+	return diffRegion{
+		baseStart: start,
+		baseEnd:   end,
+		sideStart: first.sideStart - (first.baseStart - start),
+		sideEnd:   last.sideEnd + (end - last.baseEnd),
+	}
+}
@@ -0,0 +1,205 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     merge_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mergeBase = "one\ntwo\nthree\nfour\nfive\nsix\nseven\n"
+
+func TestMarker(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("Hello World!")
+	marker := gb.AddMarker(6)
+
+	gb.MoveTo(0)
+	gb.Insert("Oh, ")
+	assert.Equal(t, 10, marker.Offset(), "Insert before")
+
+	gb.MoveTo(10)
+	gb.Insert("big ")
+	assert.Equal(t, 10, marker.Offset(), "Insert at the marker")
+
+	gb.DeleteRange(8, 12)
+	assert.Equal(t, 8, marker.Offset(), "Delete around")
+
+	gb.DeleteRange(0, 4)
+	assert.Equal(t, 4, marker.Offset(), "Delete before")
+	assert.Equal(t, "Hell", gb.String()[:marker.Offset()])
+
+	marker.Remove()
+	gb.MoveTo(0)
+	gb.Insert("!")
+	assert.Equal(t, 4, marker.Offset(), "Removed")
+
+	assert.Equal(t, 0, gb.AddMarker(-5).Offset(), "Clamped")
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(strings.Replace(mergeBase, "two", "TWO", 1))
+	gb.MoveTo(strings.Index(gb.String(), "five"))
+	marker := gb.AddMarker(strings.Index(gb.String(), "seven"))
+
+	theirs := "zero\n" + strings.Replace(mergeBase, "six\n", "", 1)
+	conflicts, err := gb.Merge(mergeBase, theirs, gapbuffer.DefaultMergeOptions)
+	require.NoError(t, err)
+
+	assert.Empty(t, conflicts)
+	assert.Equal(t, "zero\none\nTWO\nthree\nfour\nfive\nseven\n", gb.String())
+	assert.Equal(t, strings.Index(gb.String(), "five"), gb.Offset(), "Cursor")
+	assert.Equal(t, strings.Index(gb.String(), "seven"), marker.Offset(), "Marker")
+}
+
+func TestMergeSameChange(t *testing.T) {
+	t.Parallel()
+
+	changed := strings.Replace(mergeBase, "four", "4", 1)
+	gb := gapbuffer.NewStr(changed)
+
+	conflicts, err := gb.Merge(mergeBase, changed, gapbuffer.DefaultMergeOptions)
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, changed, gb.String())
+}
+
+func TestMergeConflict(t *testing.T) {
+	t.Parallel()
+
+	ours := strings.Replace(mergeBase, "four", "FOUR", 1)
+	theirs := strings.Replace(strings.Replace(mergeBase, "four", "4", 1), "one", "1", 1)
+
+	gb := gapbuffer.NewStr(ours)
+	conflicts, err := gb.Merge(mergeBase, theirs, gapbuffer.DefaultMergeOptions)
+	require.NoError(t, err)
+
+	exp := "1\ntwo\nthree\n<<<<<<< buffer\nFOUR\n=======\n4\n>>>>>>> file\nfive\nsix\nseven\n"
+	assert.Equal(t, exp, gb.String())
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, gapbuffer.Conflict{
+		Start:  len("1\ntwo\nthree\n"),
+		End:    len(exp) - len("five\nsix\nseven\n"),
+		Base:   "four\n",
+		Ours:   "FOUR\n",
+		Theirs: "4\n",
+	}, conflicts[0])
+
+	opts := gapbuffer.DefaultMergeOptions
+	opts.Style = gapbuffer.ConflictDiff3
+	gb = gapbuffer.NewStr(ours)
+	_, err = gb.Merge(mergeBase, theirs, opts)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"1\ntwo\nthree\n<<<<<<< buffer\nFOUR\n||||||| base\nfour\n=======\n4\n>>>>>>> file\nfive\nsix\nseven\n",
+		gb.String())
+
+	opts.Style = gapbuffer.ConflictRegions
+	gb = gapbuffer.NewStr(ours)
+	conflicts, err = gb.Merge(mergeBase, theirs, opts)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Replace(ours, "one", "1", 1), gb.String())
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "FOUR\n", gb.String()[conflicts[0].Start:conflicts[0].End], "Region")
+}
+
+func TestMergeAdjacent(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(strings.Replace(mergeBase, "three", "3", 1))
+	conflicts, err := gb.Merge(mergeBase, strings.Replace(mergeBase, "four", "4", 1), gapbuffer.DefaultMergeOptions)
+	require.NoError(t, err)
+
+	require.Len(t, conflicts, 1, "Changes of adjacent lines conflict")
+	assert.Equal(t, "three\nfour\n", conflicts[0].Base)
+	assert.Equal(t, "3\nfour\n", conflicts[0].Ours)
+	assert.Equal(t, "three\n4\n", conflicts[0].Theirs)
+}
+
+func TestMergeSaved(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(mergeBase)
+	gb.MarkSaved()
+	gb.Insert("eight")
+
+	theirs := strings.Replace(mergeBase, "one", "1", 1)
+	conflicts, err := gb.MergeSaved(theirs, gapbuffer.DefaultMergeOptions)
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, theirs+"eight", gb.String())
+	assert.Equal(t, theirs, gb.Saved(), "New baseline")
+	assert.Equal(t, gb.StringLength(), gb.Offset(), "Cursor")
+}
+
+func TestMergeRandom(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(23)) //nolint:gosec // test
+
+	for i := 0; i < 200; i++ {
+		baseLines := randomLines(rnd)
+		base, changed := strings.Join(baseLines, ""), strings.Join(mutateLines(rnd, baseLines), "")
+
+		gb := gapbuffer.NewStr(base)
+		conflicts, err := gb.Merge(base, changed, gapbuffer.DefaultMergeOptions)
+		require.NoError(t, err)
+		require.Empty(t, conflicts)
+		require.Equal(t, changed, gb.String(), "Only theirs changed")
+
+		gb = gapbuffer.NewStr(changed)
+		conflicts, err = gb.Merge(base, base, gapbuffer.DefaultMergeOptions)
+		require.NoError(t, err)
+		require.Empty(t, conflicts)
+		require.Equal(t, changed, gb.String(), "Only ours changed")
+	}
+}
+
+func TestMergeInvalidUTF8(t *testing.T) {
+	t.Parallel()
+
+	base := "a\nb\nc\nd\ne\n"
+	ours := "a\nb\nc\nd\nE\n"
+	theirs := "A\xff\xfe\nb\nc\nD\ne\n"
+
+	gb := gapbuffer.NewStr(ours)
+	gb.SetInvalidUTF8Policy(gapbuffer.RejectInvalidUTF8)
+	gb.MarkSaved()
+	gb.MoveTo(2)
+
+	conflicts, err := gb.Merge(base, theirs, gapbuffer.DefaultMergeOptions)
+	require.ErrorIs(t, err, gapbuffer.ErrInvalidUTF8)
+	assert.Nil(t, conflicts)
+	assert.Equal(t, ours, gb.String(), "Nothing changed")
+	assert.Equal(t, 2, gb.Offset(), "Cursor")
+
+	_, err = gb.MergeSaved(theirs, gapbuffer.DefaultMergeOptions)
+	require.ErrorIs(t, err, gapbuffer.ErrInvalidUTF8)
+	assert.Equal(t, ours, gb.Saved(), "Baseline not changed")
+
+	gb.SetInvalidUTF8Policy(gapbuffer.ReplaceInvalidUTF8)
+	conflicts, err = gb.Merge(base, theirs, gapbuffer.DefaultMergeOptions)
+	require.NoError(t, err)
+
+	exp := "A\uFFFD\nb\nc\n<<<<<<< buffer\nd\nE\n=======\nD\ne\n>>>>>>> file\n"
+	assert.Equal(t, exp, gb.String())
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "D\ne\n", conflicts[0].Theirs)
+	assert.Equal(t, len("A\uFFFD\nb\nc\n"), conflicts[0].Start)
+	assert.Equal(t, len(exp), conflicts[0].End)
+}
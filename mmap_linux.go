@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     mmap_linux.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mapFile maps the file with the given path read-only into memory. Returns
+// the mapped bytes and the function to unmap them, which is nil for empty
+// files.
+func mapFile(path string) (data []byte, unmap func() error, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info.Size() == 0 {
+		return nil, nil, nil
+	}
+
+	data, err = syscall.Mmap(int(file.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mapping %s: %w", path, err)
+	}
+
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
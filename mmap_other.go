@@ -0,0 +1,25 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     mmap_other.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+//go:build !linux
+
+package gapbuffer
+
+import "os"
+
+// mapFile reads the file with the given path into memory, memory mapping is
+// only supported on Linux. The returned function to unmap the file is nil.
+func mapFile(path string) (data []byte, unmap func() error, err error) {
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, nil, nil
+}
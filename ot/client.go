@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     client.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package ot
+
+import (
+	"errors"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+)
+
+// ErrNothingOutstanding is returned by [Client.Ack] if the client has no
+// operation waiting for an acknowledgement of the server.
+var ErrNothingOutstanding = errors.New("no outstanding operation to acknowledge")
+
+// Client is a participant of a collaborative editing session. It holds the
+// local copy of the document in a [gapbuffer.GapBuffer].
+//
+// Local operations are applied immediately. Only one operation at a time is
+// sent to the server, local operations done while waiting for the server's
+// acknowledgement are composed into a single buffered operation. Remote
+// operations are transformed against the outstanding and the buffered
+// operations before being applied to the local gap buffer.
+//
+// The transport is not part of the client:
+//   - call [Client.Send] to get the operation to send to the server,
+//   - call [Client.Ack] when the server acknowledges the sent operation,
+//   - call [Client.ApplyRemote] with every operation of other clients the
+//     server broadcasts.
+type Client struct {
+	// The local copy of the document.
+	buf *gapbuffer.GapBuffer
+
+	// The revision of the server's document the client has seen.
+	revision int
+
+	// The operation sent to the server and waiting for an acknowledgement, if
+	// any.
+	outstanding *Operation
+
+	// The local operations not sent to the server yet, if any.
+	buffered *Operation
+}
+
+// NewClient returns a new client editing the given gap buffer, which must hold
+// the server's document at the given revision.
+func NewClient(buf *gapbuffer.GapBuffer, revision int) *Client {
+	return &Client{buf: buf, revision: revision, outstanding: nil, buffered: nil}
+}
+
+// Return the local gap buffer of the client.
+//
+// Warning: do not change the text of the gap buffer directly, use
+// [Client.ApplyLocal] or [Client.Replace]. Moving the cursor is fine.
+func (c *Client) Buffer() *gapbuffer.GapBuffer {
+	return c.buf
+}
+
+// Return the revision of the server's document the client has seen.
+func (c *Client) Revision() int {
+	return c.revision
+}
+
+// Return true if the client has local operations that the server hasn't
+// acknowledged yet.
+func (c *Client) HasPending() bool {
+	return c.outstanding != nil || c.buffered != nil
+}
+
+// ApplyLocal applies the local operation to the gap buffer and queues it to be
+// sent to the server.
+//
+// Returns [ErrLengthMismatch] if the operation can't be applied to the gap
+// buffer.
+func (c *Client) ApplyLocal(op *Operation) error {
+	if err := op.Apply(c.buf); err != nil {
+		return err
+	}
+
+	if c.buffered == nil {
+		c.buffered = op
+
+		return nil
+	}
+
+	composed, err := Compose(c.buffered, op)
+	if err != nil {
+		return err
+	}
+
+	c.buffered = composed
+
+	return nil
+}
+
+// Replace replaces the bytes between `from` (inclusive) and `to` (exclusive)
+// with the given text, like [gapbuffer.GapBuffer.ReplaceRange], as a local
+// operation.
+func (c *Client) Replace(from int, to int, text string) error {
+	return c.ApplyLocal(Replace(c.buf.StringLength(), from, to, text))
+}
+
+// Send returns the operation to send to the server together with the revision
+// it is based on. Returns false if there is nothing to send or an operation is
+// still waiting for its acknowledgement.
+func (c *Client) Send() (revision int, op *Operation, ok bool) {
+	if c.outstanding != nil || c.buffered == nil {
+		return c.revision, nil, false
+	}
+
+	c.outstanding, c.buffered = c.buffered, nil
+
+	return c.revision, c.outstanding, true
+}
+
+// Ack handles the acknowledgement of the outstanding operation by the server.
+//
+// Returns [ErrNothingOutstanding] if no operation has been sent.
+func (c *Client) Ack() error {
+	if c.outstanding == nil {
+		return ErrNothingOutstanding
+	}
+
+	c.outstanding = nil
+	c.revision++
+
+	return nil
+}
+
+// ApplyRemote rebases the pending local operations over the given operation of
+// another client and applies the transformed operation to the gap buffer. The
+// cursor of the gap buffer is moved along with the remote change.
+//
+// Returns the transformed operation as it has been applied to the gap buffer,
+// use it to move other positions - like the cursors of other clients - along.
+// Returns [ErrLengthMismatch] if the operation does not fit the document, the
+// client and its gap buffer are not changed in this case.
+func (c *Client) ApplyRemote(op *Operation) (*Operation, error) {
+	outstanding, buffered := c.outstanding, c.buffered
+
+	var err error
+
+	if outstanding != nil {
+		if outstanding, op, err = Transform(outstanding, op); err != nil {
+			return nil, err
+		}
+	}
+
+	if buffered != nil {
+		if buffered, op, err = Transform(buffered, op); err != nil {
+			return nil, err
+		}
+	}
+
+	cursor := c.buf.Offset()
+
+	if err = op.Apply(c.buf); err != nil {
+		return nil, err
+	}
+
+	c.outstanding, c.buffered = outstanding, buffered
+	c.buf.MoveTo(op.TransformIndex(cursor))
+	c.revision++
+
+	return op, nil
+}
+
+// TransformIndex maps a byte offset in the document as the server knows it at
+// the client's revision - like a remote cursor position - to the local gap
+// buffer, which includes the pending local operations.
+func (c *Client) TransformIndex(idx int) int {
+	if c.outstanding != nil {
+		idx = c.outstanding.TransformIndex(idx)
+	}
+
+	if c.buffered != nil {
+		idx = c.buffered.TransformIndex(idx)
+	}
+
+	return idx
+}
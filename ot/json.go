@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     json.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package ot
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidComponent is returned when decoding a JSON component that is not a
+// non-zero integer or a non-empty string.
+var ErrInvalidComponent = errors.New("invalid operation component")
+
+// MarshalJSON encodes the operation as a JSON array, like ot.js does: a retain
+// is a positive integer, a delete a negative integer and an insert a string.
+//
+//	ot.New().Retain(5).Insert(", dear").Delete(1).Retain(6)
+//
+// is encoded as
+//
+//	[5,", dear",-1,6]
+func (o *Operation) MarshalJSON() ([]byte, error) {
+	arr := make([]any, 0, len(o.components))
+
+	for _, c := range o.components {
+		switch c.Kind {
+		case Retain:
+			arr = append(arr, c.N)
+
+		case Insert:
+			arr = append(arr, c.Text)
+
+		case Delete:
+			arr = append(arr, -c.N)
+		}
+	}
+
+	return json.Marshal(arr)
+}
+
+// UnmarshalJSON decodes an operation encoded by [Operation.MarshalJSON].
+//
+// Returns [ErrInvalidComponent] if a component is zero or empty.
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var arr []json.RawMessage
+	if err := json.Unmarshal(data, &arr); err != nil {
+		return err
+	}
+
+	op := New()
+
+	for _, raw := range arr {
+		var text string
+		if err := json.Unmarshal(raw, &text); err == nil {
+			if text == "" {
+				return ErrInvalidComponent
+			}
+
+			op.Insert(text)
+
+			continue
+		}
+
+		var n int
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return err
+		}
+
+		switch {
+		case n > 0:
+			op.Retain(n)
+		case n < 0:
+			op.Delete(-n)
+		default:
+			return ErrInvalidComponent
+		}
+	}
+
+	*o = *op
+
+	return nil
+}
@@ -0,0 +1,358 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     operation.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+// Package ot implements operational transformation (OT) for collaborative
+// editing of a [gapbuffer.GapBuffer].
+//
+// An edit is an [Operation], a sequence of retain, insert and delete
+// components which walks over the whole text:
+//
+//	"Hello World!" -> "Hello, dear World!"
+//
+//	retain 5, insert ", dear", retain 7
+//
+// Lengths and counts are in bytes, like the offsets of [gapbuffer.GapBuffer].
+//
+// Two concurrent operations - both applicable to the same text - are
+// transformed against each other by [Transform], so that applying them in
+// either order yields the same text. [Compose] joins two consecutive
+// operations into a single one.
+//
+// A [Server] holds the authoritative history of a document, every [Client]
+// keeps a local [gapbuffer.GapBuffer], sends its local operations one at a time
+// and rebases pending local operations over the remote ones it receives.
+//
+// This is an implementation of the same algorithm as ot.js, see
+// https://github.com/Operational-Transformation/ot.js
+package ot
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+)
+
+// Kind is the kind of a [Component] of an [Operation].
+type Kind int
+
+const (
+	// Retain skips over [Component.N] bytes, leaving them unchanged.
+	Retain Kind = iota
+
+	// Insert inserts [Component.Text] at the current position.
+	Insert
+
+	// Delete deletes [Component.N] bytes at the current position.
+	Delete
+)
+
+// Component is a single step of an [Operation].
+type Component struct {
+	// The kind of the component.
+	Kind Kind
+
+	// The number of bytes to retain or delete, unused by [Insert].
+	N int
+
+	// The text to insert, unused by [Retain] and [Delete].
+	Text string
+}
+
+// Operation is an edit of a whole text, a sequence of [Component]s. The zero
+// value is an operation on the empty text which does nothing.
+//
+// An operation is built by chaining calls of [Operation.Retain],
+// [Operation.Insert] and [Operation.Delete]:
+//
+//	op := ot.New().Retain(5).Insert(", dear").Retain(7)
+type Operation struct {
+	// The components of the operation, adjacent components of the same kind
+	// are merged and an insert is always placed before a delete.
+	components []Component
+
+	// The length of the text the operation can be applied to.
+	baseLen int
+
+	// The length of the text after applying the operation.
+	targetLen int
+}
+
+var (
+	// ErrLengthMismatch is returned if an operation can't be applied to a text
+	// or can't be transformed or composed with another operation because the
+	// lengths don't match.
+	ErrLengthMismatch = errors.New("operation length does not match the text")
+
+	// ErrRevision is returned by [Server.Receive] if the revision of an
+	// operation is not part of the history of the document.
+	ErrRevision = errors.New("invalid revision")
+)
+
+// New returns a new, empty operation.
+func New() *Operation {
+	return &Operation{components: nil, baseLen: 0, targetLen: 0}
+}
+
+// Replace returns an operation on a text of length `docLen` which replaces the
+// bytes between `from` (inclusive) and `to` (exclusive) with `text`.
+//
+// This is the same edit as [gapbuffer.GapBuffer.ReplaceRange].
+func Replace(docLen int, from int, to int, text string) *Operation {
+	return New().Retain(from).Insert(text).Delete(to - from).Retain(docLen - to)
+}
+
+// Return the length of the text the operation can be applied to.
+func (o *Operation) BaseLength() int {
+	return o.baseLen
+}
+
+// Return the length of the text after applying the operation.
+func (o *Operation) TargetLength() int {
+	return o.targetLen
+}
+
+// Return a copy of the components of the operation.
+func (o *Operation) Components() []Component {
+	return append([]Component(nil), o.components...)
+}
+
+// Return true if the operation does not change the text.
+func (o *Operation) IsNoop() bool {
+	for _, c := range o.components {
+		if c.Kind != Retain {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Retain skips over `n` bytes. Returns the operation to be able to chain
+// calls. Nothing happens if `n` is not positive.
+func (o *Operation) Retain(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+
+	o.baseLen += n
+	o.targetLen += n
+
+	if last := o.last(); last != nil && last.Kind == Retain {
+		last.N += n
+	} else {
+		o.components = append(o.components, Component{Kind: Retain, N: n, Text: ""})
+	}
+
+	return o
+}
+
+// Insert inserts the given text. Returns the operation to be able to chain
+// calls. Nothing happens if the text is empty.
+func (o *Operation) Insert(text string) *Operation {
+	if text == "" {
+		return o
+	}
+
+	o.targetLen += len(text)
+	last := o.last()
+
+	switch {
+	case last != nil && last.Kind == Insert:
+		last.Text += text
+
+	case last != nil && last.Kind == Delete:
+		// Always put the insert before the delete, so equal operations have
+		// equal components.
+		if prev := o.beforeLast(); prev != nil && prev.Kind == Insert {
+			prev.Text += text
+		} else {
+			del := *last
+			*last = Component{Kind: Insert, N: 0, Text: text}
+			o.components = append(o.components, del)
+		}
+
+	default:
+		o.components = append(o.components, Component{Kind: Insert, N: 0, Text: text})
+	}
+
+	return o
+}
+
+// Delete deletes `n` bytes. Returns the operation to be able to chain calls.
+// Nothing happens if `n` is not positive.
+func (o *Operation) Delete(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+
+	o.baseLen += n
+
+	if last := o.last(); last != nil && last.Kind == Delete {
+		last.N += n
+	} else {
+		o.components = append(o.components, Component{Kind: Delete, N: n, Text: ""})
+	}
+
+	return o
+}
+
+// Apply applies the operation to the given string and returns the result.
+//
+// Returns [ErrLengthMismatch] if the length of the string isn't the base length
+// of the operation.
+func (o *Operation) ApplyString(str string) (string, error) {
+	if len(str) != o.baseLen {
+		return "", ErrLengthMismatch
+	}
+
+	var builder strings.Builder
+
+	builder.Grow(o.targetLen)
+
+	pos := 0
+
+	for _, c := range o.components {
+		switch c.Kind {
+		case Retain:
+			builder.WriteString(str[pos : pos+c.N])
+			pos += c.N
+
+		case Insert:
+			builder.WriteString(c.Text)
+
+		case Delete:
+			pos += c.N
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// Apply applies the operation to the given gap buffer. The cursor of the gap
+// buffer is moved to the end of the last change, use [Operation.TransformIndex]
+// to keep the cursor position instead.
+//
+// Returns [ErrLengthMismatch] if the length of the text of the gap buffer isn't
+// the base length of the operation and [gapbuffer.ErrInvalidUTF8] if an
+// inserted text is not valid UTF-8 and the [gapbuffer.InvalidUTF8Policy] of
+// the gap buffer isn't [gapbuffer.KeepInvalidUTF8], the gap buffer is not
+// changed then. Replacing invalid UTF-8 would change the text to another one
+// than that of the other clients.
+func (o *Operation) Apply(buf *gapbuffer.GapBuffer) error {
+	if buf.StringLength() != o.baseLen {
+		return ErrLengthMismatch
+	}
+
+	for _, c := range o.components {
+		if c.Kind == Insert && buf.InvalidUTF8Policy() != gapbuffer.KeepInvalidUTF8 && !utf8.ValidString(c.Text) {
+			return gapbuffer.ErrInvalidUTF8
+		}
+	}
+
+	pos := 0
+
+	for _, c := range o.components {
+		switch c.Kind {
+		case Retain:
+			pos += c.N
+
+		case Insert:
+			buf.ReplaceRange(pos, pos, c.Text)
+			pos += len(c.Text)
+
+		case Delete:
+			buf.DeleteRange(pos, pos+c.N)
+		}
+	}
+
+	return nil
+}
+
+// TransformIndex returns the position of the given byte offset in the text
+// after the operation has been applied. Use this to move cursors and markers
+// over remote changes.
+//
+// Text inserted at the offset is placed before the offset, a deleted offset is
+// moved to the start of the deletion.
+func (o *Operation) TransformIndex(idx int) int {
+	newIdx := idx
+	pos := 0
+
+	for _, c := range o.components {
+		if pos > idx {
+			break
+		}
+
+		switch c.Kind {
+		case Retain:
+			pos += c.N
+
+		case Insert:
+			newIdx += len(c.Text)
+
+		case Delete:
+			newIdx -= min(c.N, idx-pos)
+			pos += c.N
+		}
+	}
+
+	return newIdx
+}
+
+// Invert returns the operation that undoes this operation, given the text the
+// operation has been applied to.
+//
+// Returns [ErrLengthMismatch] if the length of the string isn't the base length
+// of the operation.
+func (o *Operation) Invert(str string) (*Operation, error) {
+	if len(str) != o.baseLen {
+		return nil, ErrLengthMismatch
+	}
+
+	inv := New()
+	pos := 0
+
+	for _, c := range o.components {
+		switch c.Kind {
+		case Retain:
+			inv.Retain(c.N)
+			pos += c.N
+
+		case Insert:
+			inv.Delete(len(c.Text))
+
+		case Delete:
+			inv.Insert(str[pos : pos+c.N])
+			pos += c.N
+		}
+	}
+
+	return inv, nil
+}
+
+// last returns a pointer to the last component or nil, if there is none.
+func (o *Operation) last() *Component {
+	if len(o.components) == 0 {
+		return nil
+	}
+
+	return &o.components[len(o.components)-1]
+}
+
+// beforeLast returns a pointer to the component before the last one or nil, if
+// there is none.
+func (o *Operation) beforeLast() *Component {
+	if len(o.components) < 2 { //nolint:gomnd // the second to last
+		return nil
+	}
+
+	return &o.components[len(o.components)-2]
+}
@@ -0,0 +1,381 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     ot_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package ot_test
+
+import (
+	"math/rand"
+	"testing"
+	"unicode/utf8"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/Release-Candidate/go-gap-buffer/ot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The runes used to generate random texts.
+var alphabet = []rune("abc xyz\nä😀")
+
+func randomText(rnd *rand.Rand, maxLen int) string {
+	runes := make([]rune, rnd.Intn(maxLen+1))
+	for idx := range runes {
+		runes[idx] = alphabet[rnd.Intn(len(alphabet))]
+	}
+
+	return string(runes)
+}
+
+// randomOffset returns a random byte offset at the start of a rune in `text`.
+func randomOffset(rnd *rand.Rand, text string) int {
+	n := rnd.Intn(utf8.RuneCountInString(text) + 1)
+	offset := 0
+
+	for ; n > 0; n-- {
+		_, d := utf8.DecodeRuneInString(text[offset:])
+		offset += d
+	}
+
+	return offset
+}
+
+// randomOp returns a random replacement on `text`.
+func randomOp(rnd *rand.Rand, text string) *ot.Operation {
+	from, to := randomOffset(rnd, text), randomOffset(rnd, text)
+	if to < from {
+		from, to = to, from
+	}
+
+	return ot.Replace(len(text), from, to, randomText(rnd, 4))
+}
+
+func TestBuilderNormalizes(t *testing.T) {
+	t.Parallel()
+
+	op1 := ot.New().Retain(2).Retain(1).Delete(2).Insert("ab").Insert("c")
+	op2 := ot.New().Retain(3).Insert("abc").Delete(1).Delete(1)
+
+	assert.Equal(t, op2.Components(), op1.Components())
+	assert.Equal(t, 5, op1.BaseLength(), "Base length")
+	assert.Equal(t, 6, op1.TargetLength(), "Target length")
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	op := ot.New().Retain(5).Insert(", dear").Retain(1).Delete(5).Insert("Joe").Retain(1)
+	gb := gapbuffer.NewStr("Hello World!")
+
+	require.NoError(t, op.Apply(gb))
+	str, err := op.ApplyString("Hello World!")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Hello, dear Joe!", gb.String())
+	assert.Equal(t, "Hello, dear Joe!", str)
+	require.ErrorIs(t, op.Apply(gb), ot.ErrLengthMismatch)
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	op := ot.New().Retain(5).Insert(", dear").Delete(1).Retain(6)
+
+	data, err := op.MarshalJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `[5,", dear",-1,6]`, string(data))
+
+	decoded := ot.New()
+	require.NoError(t, decoded.UnmarshalJSON(data))
+	assert.Equal(t, op, decoded)
+	require.ErrorIs(t, decoded.UnmarshalJSON([]byte(`[1,0]`)), ot.ErrInvalidComponent)
+}
+
+func TestTransformIndex(t *testing.T) {
+	t.Parallel()
+
+	op := ot.New().Retain(2).Insert("xx").Retain(2).Delete(3).Retain(1)
+
+	assert.Equal(t, 1, op.TransformIndex(1), "Before the insert")
+	assert.Equal(t, 4, op.TransformIndex(2), "At the insert")
+	assert.Equal(t, 6, op.TransformIndex(5), "Inside the delete")
+	assert.Equal(t, 7, op.TransformIndex(8), "After the delete")
+}
+
+func TestInvert(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(26)) //nolint:gosec // deterministic tests
+
+	for i := 0; i < 200; i++ {
+		text := randomText(rnd, 20)
+		op := randomOp(rnd, text)
+
+		inv, err := op.Invert(text)
+		require.NoError(t, err)
+
+		changed, err := op.ApplyString(text)
+		require.NoError(t, err)
+		undone, err := inv.ApplyString(changed)
+		require.NoError(t, err)
+
+		assert.Equal(t, text, undone)
+	}
+}
+
+func TestTransformConverges(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(27)) //nolint:gosec // deterministic tests
+
+	for i := 0; i < 1000; i++ {
+		text := randomText(rnd, 20)
+		opA, opB := randomOp(rnd, text), randomOp(rnd, text)
+
+		aPrime, bPrime, err := ot.Transform(opA, opB)
+		require.NoError(t, err)
+
+		afterA, err := opA.ApplyString(text)
+		require.NoError(t, err)
+		afterAB, err := bPrime.ApplyString(afterA)
+		require.NoError(t, err)
+
+		afterB, err := opB.ApplyString(text)
+		require.NoError(t, err)
+		afterBA, err := aPrime.ApplyString(afterB)
+		require.NoError(t, err)
+
+		require.Equal(t, afterAB, afterBA, "Text %q", text)
+	}
+}
+
+func TestCompose(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(28)) //nolint:gosec // deterministic tests
+
+	for i := 0; i < 1000; i++ {
+		text := randomText(rnd, 20)
+		opA := randomOp(rnd, text)
+		afterA, err := opA.ApplyString(text)
+		require.NoError(t, err)
+
+		opB := randomOp(rnd, afterA)
+		afterB, err := opB.ApplyString(afterA)
+		require.NoError(t, err)
+
+		composed, err := ot.Compose(opA, opB)
+		require.NoError(t, err)
+		afterAB, err := composed.ApplyString(text)
+		require.NoError(t, err)
+
+		require.Equal(t, afterB, afterAB, "Text %q", text)
+	}
+}
+
+// ==============================================================================
+//                       Simulated Clients
+
+// message is a message from the server to a client.
+type message struct {
+	ack bool
+	op  *ot.Operation
+}
+
+// request is a message from a client to the server.
+type request struct {
+	revision int
+	op       *ot.Operation
+}
+
+// simulation is an in process editing session with a server and clients
+// connected by FIFO queues.
+type simulation struct {
+	server    *ot.Server
+	clients   []*ot.Client
+	toServer  [][]request
+	toClients [][]message
+}
+
+func newSimulation(text string, numClients int) *simulation {
+	sim := &simulation{
+		server:    ot.NewServer(text),
+		clients:   make([]*ot.Client, numClients),
+		toServer:  make([][]request, numClients),
+		toClients: make([][]message, numClients),
+	}
+
+	for idx := range sim.clients {
+		sim.clients[idx] = ot.NewClient(gapbuffer.NewStr(text), 0)
+	}
+
+	return sim
+}
+
+func (s *simulation) edit(t *testing.T, rnd *rand.Rand, idx int) {
+	t.Helper()
+
+	client := s.clients[idx]
+	text := client.Buffer().String()
+	require.NoError(t, client.ApplyLocal(randomOp(rnd, text)))
+}
+
+func (s *simulation) send(idx int) {
+	if rev, op, ok := s.clients[idx].Send(); ok {
+		s.toServer[idx] = append(s.toServer[idx], request{revision: rev, op: op})
+	}
+}
+
+func (s *simulation) serve(t *testing.T, idx int) {
+	t.Helper()
+
+	if len(s.toServer[idx]) == 0 {
+		return
+	}
+
+	req := s.toServer[idx][0]
+	s.toServer[idx] = s.toServer[idx][1:]
+
+	op, err := s.server.Receive(req.revision, req.op)
+	require.NoError(t, err)
+
+	for other := range s.clients {
+		s.toClients[other] = append(s.toClients[other], message{ack: other == idx, op: op})
+	}
+}
+
+func (s *simulation) deliver(t *testing.T, idx int) {
+	t.Helper()
+
+	if len(s.toClients[idx]) == 0 {
+		return
+	}
+
+	msg := s.toClients[idx][0]
+	s.toClients[idx] = s.toClients[idx][1:]
+
+	if msg.ack {
+		require.NoError(t, s.clients[idx].Ack())
+	} else {
+		_, err := s.clients[idx].ApplyRemote(msg.op)
+		require.NoError(t, err)
+	}
+}
+
+func (s *simulation) idle() bool {
+	for idx, client := range s.clients {
+		if client.HasPending() || len(s.toServer[idx]) > 0 || len(s.toClients[idx]) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestClientsConverge(t *testing.T) {
+	t.Parallel()
+
+	for seed := int64(0); seed < 50; seed++ {
+		rnd := rand.New(rand.NewSource(seed)) //nolint:gosec // deterministic tests
+		sim := newSimulation(randomText(rnd, 30), 2+rnd.Intn(3))
+
+		for i := 0; i < 300; i++ {
+			idx := rnd.Intn(len(sim.clients))
+
+			switch rnd.Intn(4) {
+			case 0:
+				sim.edit(t, rnd, idx)
+			case 1:
+				sim.send(idx)
+			case 2:
+				sim.serve(t, idx)
+			default:
+				sim.deliver(t, idx)
+			}
+		}
+
+		for !sim.idle() {
+			for idx := range sim.clients {
+				sim.send(idx)
+				sim.serve(t, idx)
+				sim.deliver(t, idx)
+			}
+		}
+
+		for idx, client := range sim.clients {
+			require.Equal(t, sim.server.String(), client.Buffer().String(), "Seed %d, client %d", seed, idx)
+			require.Equal(t, sim.server.Revision(), client.Revision(), "Seed %d, client %d", seed, idx)
+		}
+	}
+}
+
+func TestRemoteEditMovesCursor(t *testing.T) {
+	t.Parallel()
+
+	server := ot.NewServer("Hello World!")
+	alice := ot.NewClient(gapbuffer.NewStr("Hello World!"), 0)
+	alice.Buffer().MoveTo(6)
+
+	op, err := server.Receive(0, ot.Replace(12, 0, 0, ">> "))
+	require.NoError(t, err)
+	_, err = alice.ApplyRemote(op)
+	require.NoError(t, err)
+
+	l, r := alice.Buffer().StringPair()
+	assert.Equal(t, ">> Hello ", l)
+	assert.Equal(t, "World!", r)
+}
+
+func TestApplyRemoteMismatch(t *testing.T) {
+	t.Parallel()
+
+	alice := ot.NewClient(gapbuffer.NewStr("abc"), 0)
+	require.NoError(t, alice.Replace(0, 0, "x"))
+
+	_, _, ok := alice.Send()
+	require.True(t, ok)
+	require.NoError(t, alice.Replace(4, 4, "y"))
+
+	_, err := alice.ApplyRemote(ot.Replace(5, 0, 0, "!"))
+	require.ErrorIs(t, err, ot.ErrLengthMismatch)
+	assert.Equal(t, "xabcy", alice.Buffer().String(), "Nothing applied")
+	assert.Equal(t, 5, alice.TransformIndex(3), "Pending operations kept")
+
+	op, err := alice.ApplyRemote(ot.Replace(3, 3, 3, "!"))
+	require.NoError(t, err)
+	assert.Equal(t, "xabcy!", alice.Buffer().String())
+	assert.Equal(t, 5, op.BaseLength(), "Transformed operation")
+	require.NoError(t, alice.Ack(), "Still outstanding")
+}
+
+func TestApplyRejectsInvalidUTF8(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("abc")
+	gb.SetInvalidUTF8Policy(gapbuffer.RejectInvalidUTF8)
+
+	op := ot.New().Delete(1).Retain(2).Insert("\xfc")
+	require.ErrorIs(t, op.Apply(gb), gapbuffer.ErrInvalidUTF8)
+	assert.Equal(t, "abc", gb.String(), "Nothing changed")
+}
+
+func TestApplyReplaceInvalidUTF8(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("abcdef")
+	gb.SetInvalidUTF8Policy(gapbuffer.ReplaceInvalidUTF8)
+
+	op := ot.New().Retain(2).Insert("\xff").Retain(1).Delete(1).Retain(2)
+	require.ErrorIs(t, op.Apply(gb), gapbuffer.ErrInvalidUTF8)
+	assert.Equal(t, "abcdef", gb.String(), "Nothing changed")
+
+	gb.SetInvalidUTF8Policy(gapbuffer.KeepInvalidUTF8)
+	require.NoError(t, op.Apply(gb))
+	assert.Equal(t, "ab\xffcef", gb.String())
+	assert.Equal(t, op.TargetLength(), gb.StringLength())
+}
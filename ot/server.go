@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     server.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package ot
+
+import (
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+)
+
+// Server holds the authoritative version of a document and the history of all
+// operations applied to it. The revision of the document is the number of
+// operations in the history.
+//
+// Like [Client], the server does not include a transport.
+type Server struct {
+	// The document.
+	buf *gapbuffer.GapBuffer
+
+	// All operations applied to the document, in order.
+	history []*Operation
+}
+
+// NewServer returns a new server holding a document with the given text at
+// revision 0.
+func NewServer(text string) *Server {
+	return &Server{buf: gapbuffer.NewStr(text), history: nil}
+}
+
+// Return the current revision of the document.
+func (s *Server) Revision() int {
+	return len(s.history)
+}
+
+// Return the text of the document.
+func (s *Server) String() string {
+	return s.buf.String()
+}
+
+// Receive handles an operation of a client that is based on the given revision.
+// The operation is transformed against all operations applied since that
+// revision, applied to the document and returned. The returned operation is to
+// be broadcast to all other clients, the sending client gets an
+// acknowledgement.
+//
+// Returns [ErrRevision] if the revision is not part of the history and
+// [ErrLengthMismatch] if the operation does not fit the document.
+func (s *Server) Receive(revision int, op *Operation) (*Operation, error) {
+	if revision < 0 || revision > len(s.history) {
+		return nil, ErrRevision
+	}
+
+	var err error
+
+	for _, concurrent := range s.history[revision:] {
+		if op, _, err = Transform(op, concurrent); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = op.Apply(s.buf); err != nil {
+		return nil, err
+	}
+
+	s.history = append(s.history, op)
+
+	return op, nil
+}
+
+// History returns the operations applied since the given revision, to bring a
+// client at that revision up to date.
+//
+// Returns [ErrRevision] if the revision is not part of the history.
+func (s *Server) History(revision int) ([]*Operation, error) {
+	if revision < 0 || revision > len(s.history) {
+		return nil, ErrRevision
+	}
+
+	return append([]*Operation(nil), s.history[revision:]...), nil
+}
@@ -0,0 +1,188 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     transform.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package ot
+
+// iterator walks over the components of an operation, splitting retains and
+// deletes into smaller pieces if needed.
+type iterator struct {
+	// The components to walk over.
+	components []Component
+
+	// The index of the current component.
+	idx int
+
+	// The part of the current component that has not been consumed yet.
+	cur Component
+}
+
+// newIterator returns an iterator over the components of the given operation.
+func newIterator(o *Operation) *iterator {
+	it := &iterator{components: o.components, idx: -1, cur: Component{Kind: Retain, N: 0, Text: ""}}
+	it.next()
+
+	return it
+}
+
+// done returns true if all components have been consumed.
+func (it *iterator) done() bool {
+	return it.idx >= len(it.components)
+}
+
+// next advances to the next component.
+func (it *iterator) next() {
+	it.idx++
+	if !it.done() {
+		it.cur = it.components[it.idx]
+	}
+}
+
+// consume consumes `n` bytes of the current retain or delete component.
+func (it *iterator) consume(n int) {
+	it.cur.N -= n
+	if it.cur.N == 0 {
+		it.next()
+	}
+}
+
+// Transform transforms the two concurrent operations `a` and `b`, which have
+// both been applied to the same text, and returns `aPrime` and `bPrime`, so
+// that applying `a` and then `bPrime` yields the same text as applying `b` and
+// then `aPrime`.
+//
+// If both operations insert at the same position, the text of `a` is placed
+// before the text of `b`.
+//
+// Returns [ErrLengthMismatch] if the base lengths of the operations differ.
+func Transform(a *Operation, b *Operation) (aPrime *Operation, bPrime *Operation, err error) {
+	if a.baseLen != b.baseLen {
+		return nil, nil, ErrLengthMismatch
+	}
+
+	aPrime, bPrime = New(), New()
+	itA, itB := newIterator(a), newIterator(b)
+
+	for !itA.done() || !itB.done() {
+		// Inserts are retained by the other operation.
+		if !itA.done() && itA.cur.Kind == Insert {
+			aPrime.Insert(itA.cur.Text)
+			bPrime.Retain(len(itA.cur.Text))
+			itA.next()
+
+			continue
+		}
+
+		if !itB.done() && itB.cur.Kind == Insert {
+			aPrime.Retain(len(itB.cur.Text))
+			bPrime.Insert(itB.cur.Text)
+			itB.next()
+
+			continue
+		}
+
+		if itA.done() || itB.done() {
+			return nil, nil, ErrLengthMismatch
+		}
+
+		n := min(itA.cur.N, itB.cur.N)
+
+		switch {
+		case itA.cur.Kind == Retain && itB.cur.Kind == Retain:
+			aPrime.Retain(n)
+			bPrime.Retain(n)
+
+		case itA.cur.Kind == Delete && itB.cur.Kind == Retain:
+			aPrime.Delete(n)
+
+		case itA.cur.Kind == Retain && itB.cur.Kind == Delete:
+			bPrime.Delete(n)
+		}
+
+		// If both delete the same text, there is nothing left to do.
+		itA.consume(n)
+		itB.consume(n)
+	}
+
+	return aPrime, bPrime, nil
+}
+
+// Compose returns a single operation that has the same effect as applying `a`
+// and then `b`.
+//
+// Returns [ErrLengthMismatch] if the base length of `b` is not the target
+// length of `a`.
+func Compose(a *Operation, b *Operation) (*Operation, error) {
+	if a.targetLen != b.baseLen {
+		return nil, ErrLengthMismatch
+	}
+
+	composed := New()
+	itA, itB := newIterator(a), newIterator(b)
+
+	for !itA.done() || !itB.done() {
+		// Deletes of `a` are not seen by `b`.
+		if !itA.done() && itA.cur.Kind == Delete {
+			composed.Delete(itA.cur.N)
+			itA.next()
+
+			continue
+		}
+
+		// Inserts of `b` are not seen by `a`.
+		if !itB.done() && itB.cur.Kind == Insert {
+			composed.Insert(itB.cur.Text)
+			itB.next()
+
+			continue
+		}
+
+		if itA.done() || itB.done() {
+			return nil, ErrLengthMismatch
+		}
+
+		composeStep(composed, itA, itB)
+	}
+
+	return composed, nil
+}
+
+// composeStep composes a retain or insert of `itA` with a retain or delete of
+// `itB`.
+func composeStep(composed *Operation, itA *iterator, itB *iterator) {
+	if itA.cur.Kind == Insert {
+		text := itA.cur.Text
+		n := min(len(text), itB.cur.N)
+
+		if itB.cur.Kind == Retain {
+			composed.Insert(text[:n])
+		}
+
+		// An insert of `a` deleted by `b` vanishes.
+		if n == len(text) {
+			itA.next()
+		} else {
+			itA.cur.Text = text[n:]
+		}
+
+		itB.consume(n)
+
+		return
+	}
+
+	n := min(itA.cur.N, itB.cur.N)
+
+	if itB.cur.Kind == Retain {
+		composed.Retain(n)
+	} else {
+		composed.Delete(n)
+	}
+
+	itA.consume(n)
+	itB.consume(n)
+}
@@ -0,0 +1,309 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     patch.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPatch is returned by [ParseUnifiedDiff] and [GapBuffer.ApplyPatch]
+// if the patch is not a valid unified diff of a single file.
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// The header of a hunk, like `@@ -1,3 +1,4 @@`.
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParseUnifiedDiff reads a unified diff of a single file, like generated by
+// [UnifiedDiff] or `diff -u`, and returns its hunks. Lines before the first
+// hunk, like the file names, are ignored.
+//
+// Returns [ErrInvalidPatch] if a hunk does not match its header, a line of a
+// hunk does not start with ' ', '-', '+' or '\' or the patch contains more than
+// one file.
+func ParseUnifiedDiff(r io.Reader) ([]Hunk, error) {
+	var hunks []Hunk
+
+	reader := bufio.NewReader(r)
+	oldLeft, newLeft := 0, 0
+
+	for {
+		line, err := reader.ReadString('\n')
+		if line == "" && errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		switch {
+		case strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file" belongs to the line before it.
+			if len(hunks) == 0 || len(hunks[len(hunks)-1].Lines) == 0 {
+				return nil, ErrInvalidPatch
+			}
+
+			last := &hunks[len(hunks)-1].Lines[len(hunks[len(hunks)-1].Lines)-1]
+			last.Text = strings.TrimSuffix(last.Text, "\n")
+
+		case oldLeft > 0 || newLeft > 0:
+			diffLine, ok := parseHunkLine(line)
+			if !ok {
+				return nil, ErrInvalidPatch
+			}
+
+			if diffLine.Op != DiffInsert {
+				oldLeft--
+			}
+
+			if diffLine.Op != DiffDelete {
+				newLeft--
+			}
+
+			if oldLeft < 0 || newLeft < 0 {
+				return nil, ErrInvalidPatch
+			}
+
+			hunks[len(hunks)-1].Lines = append(hunks[len(hunks)-1].Lines, diffLine)
+
+		case strings.HasPrefix(line, "@@"):
+			hunk, ok := parseHunkHeader(line)
+			if !ok {
+				return nil, ErrInvalidPatch
+			}
+
+			hunks = append(hunks, hunk)
+			oldLeft, newLeft = hunk.OldLines, hunk.NewLines
+
+		case strings.HasPrefix(line, "--- ") && len(hunks) > 0:
+			return nil, ErrInvalidPatch
+		}
+	}
+
+	if oldLeft > 0 || newLeft > 0 {
+		return nil, ErrInvalidPatch
+	}
+
+	return hunks, nil
+}
+
+// parseHunkHeader returns an empty hunk with the line numbers of the header.
+func parseHunkHeader(line string) (Hunk, bool) {
+	match := hunkHeaderRegex.FindStringSubmatch(line)
+	if match == nil {
+		return Hunk{}, false //nolint:exhaustruct // invalid
+	}
+
+	numbers := make([]int, 0, len(match)-1)
+
+	for _, str := range match[1:] {
+		if str == "" {
+			// The number of lines is omitted, if it is 1.
+			str = "1"
+		}
+
+		n, err := strconv.Atoi(str)
+		if err != nil {
+			return Hunk{}, false //nolint:exhaustruct // invalid
+		}
+
+		numbers = append(numbers, n)
+	}
+
+	return Hunk{
+		OldStart: numbers[0],
+		OldLines: numbers[1],
+		NewStart: numbers[2],
+		NewLines: numbers[3],
+		Lines:    nil,
+	}, true
+}
+
+// parseHunkLine returns the line of a hunk. An empty line is an empty line of
+// context, as some tools strip trailing whitespace.
+func parseHunkLine(line string) (DiffLine, bool) {
+	if line == "\n" {
+		return DiffLine{Op: DiffEqual, Text: line}, true
+	}
+
+	if line == "" {
+		return DiffLine{}, false //nolint:exhaustruct // invalid
+	}
+
+	switch line[0] {
+	case ' ':
+		return DiffLine{Op: DiffEqual, Text: line[1:]}, true
+	case '-':
+		return DiffLine{Op: DiffDelete, Text: line[1:]}, true
+	case '+':
+		return DiffLine{Op: DiffInsert, Text: line[1:]}, true
+	}
+
+	return DiffLine{}, false //nolint:exhaustruct // invalid
+}
+
+// ApplyPatch reads a unified diff of a single file, see [ParseUnifiedDiff], and
+// applies its hunks to the text of the gap buffer.
+//
+// If the lines of a hunk are not at the position given by its header, because
+// lines have been added or removed before it, the nearest position after the
+// previous hunk with matching lines is used, like `patch` does. The following
+// hunks are moved by the same offset. Hunks whose context and deleted lines
+// can't be found are skipped and returned in `rejected`.
+//
+// Only the changed lines are replaced, the cursor stays at its position in the
+// text. Every changed part is a separate [Edit], but the whole patch is a
+// single undo step of a [History].
+//
+// Added lines are handled according to the [InvalidUTF8Policy], the returned
+// rejected hunks contain the added lines after applying it.
+//
+// Returns [ErrInvalidPatch] if the patch can't be parsed and [ErrInvalidUTF8]
+// if an added line is rejected by the [InvalidUTF8Policy], nothing is changed
+// in this case.
+func (g *GapBuffer) ApplyPatch(r io.Reader) (rejected []Hunk, err error) {
+	hunks, err := ParseUnifiedDiff(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hunk := range hunks {
+		for idx := range hunk.Lines {
+			if hunk.Lines[idx].Op != DiffInsert {
+				continue
+			}
+
+			text, ok := g.applyUTF8Policy(hunk.Lines[idx].Text)
+			if !ok {
+				return nil, ErrInvalidUTF8
+			}
+
+			hunk.Lines[idx].Text = text
+		}
+	}
+
+	g.beginOp()
+	defer g.endOp()
+
+	lines := g.lineStrings()
+	cursor := g.Offset()
+
+	// The offset of the line numbers of the following hunks.
+	shift := 0
+
+	// The first line a hunk may change, after the previous hunk.
+	minLine := 0
+
+	for _, hunk := range hunks {
+		oldLines := make([]string, 0, hunk.OldLines)
+
+		for _, line := range hunk.Lines {
+			if line.Op != DiffInsert {
+				oldLines = append(oldLines, line.Text)
+			}
+		}
+
+		want := hunk.OldStart + shift
+		if hunk.OldLines > 0 {
+			want--
+		}
+
+		pos, ok := findLines(lines, oldLines, want, minLine)
+		if !ok {
+			rejected = append(rejected, hunk)
+
+			continue
+		}
+
+		lines, minLine, cursor = g.applyHunk(lines, hunk, pos, cursor)
+		shift += pos - want + hunk.NewLines - hunk.OldLines
+	}
+
+	g.MoveTo(cursor)
+
+	return rejected, nil
+}
+
+// applyHunk replaces the changed lines of the hunk, starting at line index
+// `pos`, and returns the changed lines of the text, the index of the line
+// after the hunk and the moved cursor offset.
+func (g *GapBuffer) applyHunk(lines []string, hunk Hunk, pos int, cursor int) ([]string, int, int) {
+	offset := 0
+	for _, line := range lines[:pos] {
+		offset += len(line)
+	}
+
+	for idx := 0; idx < len(hunk.Lines); {
+		if hunk.Lines[idx].Op == DiffEqual {
+			offset += len(lines[pos])
+			pos++
+			idx++
+
+			continue
+		}
+
+		deleted, deletedLen := 0, 0
+		inserted := make([]string, 0)
+
+		for ; idx < len(hunk.Lines) && hunk.Lines[idx].Op != DiffEqual; idx++ {
+			if hunk.Lines[idx].Op == DiffDelete {
+				deletedLen += len(lines[pos+deleted])
+				deleted++
+			} else {
+				inserted = append(inserted, hunk.Lines[idx].Text)
+			}
+		}
+
+		text := strings.Join(inserted, "")
+		g.replaceRange(offset, offset+deletedLen, text)
+
+		switch {
+		case cursor >= offset+deletedLen:
+			cursor += len(text) - deletedLen
+		case cursor > offset:
+			cursor = offset
+		}
+
+		lines = slices.Replace(lines, pos, pos+deleted, inserted...)
+		offset += len(text)
+		pos += len(inserted)
+	}
+
+	return lines, pos, cursor
+}
+
+// findLines returns the index of the position nearest to `want` at or after
+// `minLine` where `lines` contains `find`. Returns false, if `find` is not
+// contained in `lines` at or after `minLine`.
+func findLines(lines []string, find []string, want int, minLine int) (int, bool) {
+	last := len(lines) - len(find)
+
+	matches := func(pos int) bool {
+		return pos >= minLine && pos <= last && slices.Equal(lines[pos:pos+len(find)], find)
+	}
+
+	for dist := 0; want-dist >= minLine || want+dist <= last; dist++ {
+		if matches(want - dist) {
+			return want - dist, true
+		}
+
+		if matches(want + dist) {
+			return want + dist, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     patch_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const patchOld = "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n"
+
+const patchTwoHunks = `diff --git a/numbers b/numbers
+--- a/numbers
++++ b/numbers
+@@ -1,3 +1,3 @@
+ one
+-two
++2
+ three
+@@ -8,3 +8,4 @@
+ eight
+ nine
+ ten
++eleven
+\ No newline at end of file
+`
+
+func TestApplyPatch(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(patchOld)
+	gb.MoveTo(strings.Index(patchOld, "six"))
+
+	rejected, err := gb.ApplyPatch(strings.NewReader(patchTwoHunks))
+	require.NoError(t, err)
+	assert.Empty(t, rejected)
+	assert.Equal(t, strings.Replace(patchOld, "two", "2", 1)+"eleven", gb.String())
+	assert.Equal(t, strings.Index(gb.String(), "six"), gb.Offset(), "Cursor")
+}
+
+func TestApplyPatchOffset(t *testing.T) {
+	t.Parallel()
+
+	// Two lines have been added at the start and one removed before the
+	// second hunk.
+	text := "zero\nzero\n" + strings.Replace(patchOld, "five\n", "", 1)
+	gb := gapbuffer.NewStr(text)
+
+	rejected, err := gb.ApplyPatch(strings.NewReader(patchTwoHunks))
+	require.NoError(t, err)
+	assert.Empty(t, rejected)
+	assert.Equal(t, "zero\nzero\n"+strings.Replace(strings.Replace(patchOld, "five\n", "", 1), "two", "2", 1)+"eleven",
+		gb.String())
+}
+
+func TestApplyPatchRejected(t *testing.T) {
+	t.Parallel()
+
+	text := strings.Replace(patchOld, "nine", "NINE", 1)
+	gb := gapbuffer.NewStr(text)
+	history := gapbuffer.NewHistory(gb)
+
+	rejected, err := history.ApplyPatch(strings.NewReader(patchTwoHunks))
+	require.NoError(t, err)
+	require.Len(t, rejected, 1)
+	assert.Equal(t, 8, rejected[0].OldStart, "Rejected hunk")
+	assert.Equal(t, strings.Replace(text, "two", "2", 1), gb.String())
+
+	require.True(t, history.Undo())
+	assert.Equal(t, text, gb.String(), "Undo")
+}
+
+func TestApplyPatchUndo(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(patchOld)
+	history := gapbuffer.NewHistory(gb)
+
+	_, err := history.ApplyPatch(strings.NewReader(patchTwoHunks))
+	require.NoError(t, err)
+	require.True(t, history.Undo())
+	assert.Equal(t, patchOld, gb.String(), "One undo step")
+	assert.False(t, history.CanUndo(), "Nothing left to undo")
+}
+
+func TestApplyPatchInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"too short", "@@ -1,3 +1,3 @@\n one\n-two\n+2\n"},
+		{"no line", "\\ No newline at end of file\n"},
+		{"bad line", "@@ -1,2 +1,2 @@\n one\n*two\n+2\n"},
+		{"bad header", "@@ -a,1 +1 @@\n one\n"},
+		{"two files", patchTwoHunks + "--- a/other\n+++ b/other\n"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gb := gapbuffer.NewStr(patchOld)
+			_, err := gb.ApplyPatch(strings.NewReader(tt.patch))
+			require.ErrorIs(t, err, gapbuffer.ErrInvalidPatch)
+			assert.Equal(t, patchOld, gb.String(), "Unchanged")
+		})
+	}
+}
+
+func TestApplyPatchRandom(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(4711)) //nolint:gosec // test
+
+	for i := 0; i < 200; i++ {
+		oldLines := randomLines(rnd)
+		oldText, newText := strings.Join(oldLines, ""), strings.Join(mutateLines(rnd, oldLines), "")
+		opts := gapbuffer.DiffOptions{Algorithm: gapbuffer.DiffAlgorithm(i % 2), Context: i % 4}
+		patch := gapbuffer.UnifiedDiff("a", "b", gapbuffer.DiffStrings(oldText, newText, opts))
+
+		gb := gapbuffer.NewStr(oldText)
+		rejected, err := gb.ApplyPatch(strings.NewReader(patch))
+		require.NoError(t, err, patch)
+		require.Empty(t, rejected, patch)
+		require.Equal(t, newText, gb.String(), patch)
+	}
+}
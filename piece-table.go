@@ -0,0 +1,614 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     piece-table.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// PieceTable is a [Buffer] which keeps the original text unchanged and appends
+// all inserted text to an "add" buffer. The text is a list of pieces, each
+// piece is a part of either the original text or the add buffer:
+//
+//	original: "Hello world!"
+//	add:      "big "
+//	pieces:   {original, 0, 6} {add, 0, 4} {original, 6, 6}
+//	text:     "Hello big world!"
+//
+// Loading a text does not copy it, and editing only copies the inserted text,
+// but accessing the text at an offset has to search the list of pieces, so
+// every operation takes time proportional to the number of pieces. Typing at
+// the same position extends the last piece instead of adding new pieces.
+//
+// The line lengths are stored in a line buffer like in [GapBuffer]. The lines
+// of the original text are added to the line buffer when they are needed, so
+// a piece table of a huge file returned by [OpenPieceTable] is ready before
+// all of the file has been read. Only [PieceTable.LineCount] and moving the
+// cursor to the end of the text need all lines.
+type PieceTable struct {
+	// The original text, which is never changed.
+	original []byte
+
+	// The add buffer, all inserted text is appended to it.
+	add []byte
+
+	// The pieces of the text, in order.
+	pieces []piece
+
+	// The length of the text in bytes.
+	length int
+
+	// The position of the cursor, the byte offset in the text.
+	cursor int
+
+	// `wantsCol` is the rune column (not byte column!) the cursor wants to hold
+	// when going up or down.
+	wantsCol int
+
+	// The lineBuffer that stores the line length information of the text.
+	//
+	// See [lineBuffer].
+	lines lineBuffer
+
+	// The number of bytes at the end of the text which are not in the line
+	// buffer yet. These are always the last bytes of the original text.
+	unindexed int
+
+	// Unmaps the memory mapped file of the original text, nil if the original
+	// text is not mapped.
+	unmap func() error
+}
+
+// The minimum number of bytes to add to the line buffer of a [PieceTable] at
+// once, 64 KB. This is more than the first screen of text needs.
+const indexChunkSize = 64 * 1024
+
+// piece is a part of the text of a [PieceTable].
+type piece struct {
+	// True, if the piece is a part of the add buffer, false for a part of the
+	// original text.
+	added bool
+
+	// The index of the first byte of the piece in its buffer.
+	start int
+
+	// The length of the piece in bytes.
+	length int
+}
+
+// Construct a new PieceTable from a string. The cursor position is set to the
+// end of the string.
+//
+// See also [NewPieceTableBytes], [NewStr].
+func NewPieceTable(str string) *PieceTable {
+	return NewPieceTableBytes([]byte(str))
+}
+
+// Construct a new PieceTable with the given bytes as original text, without
+// copying it. The bytes must not be changed afterwards. The cursor position is
+// set to the end of the text.
+//
+// See also [NewPieceTable], [OpenPieceTable].
+func NewPieceTableBytes(data []byte) *PieceTable {
+	p := newPieceTable(data, nil)
+	p.MoveTo(len(data))
+
+	return p
+}
+
+// Construct a new PieceTable of the file with the given path, with the cursor
+// at the start of the text. On Linux, the file is memory mapped read-only and
+// not read into memory, only the edited parts are, see [PieceTable]. On other
+// systems, the file is read into memory.
+//
+// The file must not be changed while the piece table is in use, call
+// [PieceTable.Close] to unmap the file.
+func OpenPieceTable(path string) (*PieceTable, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newPieceTable(data, unmap)
+	p.index(0)
+
+	return p, nil
+}
+
+// Close unmaps the memory mapped file of a piece table returned by
+// [OpenPieceTable], the piece table must not be used afterwards. Does nothing
+// for other piece tables.
+func (p *PieceTable) Close() error {
+	if p.unmap == nil {
+		return nil
+	}
+
+	err := p.unmap()
+	p.unmap = nil
+
+	return err
+}
+
+// newPieceTable returns a new piece table of the original text with the
+// cursor at the start of the text and no lines in the line buffer.
+func newPieceTable(data []byte, unmap func() error) *PieceTable {
+	var pieces []piece
+	if len(data) > 0 {
+		pieces = []piece{{added: false, start: 0, length: len(data)}}
+	}
+
+	return &PieceTable{
+		original:  data,
+		add:       nil,
+		pieces:    pieces,
+		length:    len(data),
+		cursor:    0,
+		wantsCol:  0,
+		lines:     *newLineBuf(defaultCapacity),
+		unindexed: len(data),
+		unmap:     unmap,
+	}
+}
+
+// Return the contents of the piece table as a string.
+func (p *PieceTable) String() string {
+	return p.slice(0, p.length)
+}
+
+// Return the contents of the piece table as a pair of strings. The part to the
+// left of the cursor is returned in `left` and the part to the right of the
+// cursor is returned in `right`.
+func (p *PieceTable) StringPair() (left string, right string) {
+	return p.slice(0, p.cursor), p.slice(p.cursor, p.length)
+}
+
+// Return the length in bytes of the contents of the piece table.
+func (p *PieceTable) StringLength() int {
+	return p.length
+}
+
+// Return the byte column of the cursor, see [GapBuffer.Col].
+func (p *PieceTable) Col() int {
+	return p.cursor - p.lines.curLineStart()
+}
+
+// Return the rune column of the cursor, see [GapBuffer.RuneCol].
+func (p *PieceTable) RuneCol() int {
+	return p.runeCount(p.lines.curLineStart(), p.cursor)
+}
+
+// Return the length of the current line the cursor is in, in bytes, see
+// [GapBuffer.LineLength].
+func (p *PieceTable) LineLength() int {
+	if p.lines.isLastLine() {
+		return p.lines.curLineLength()
+	}
+
+	return p.lines.curLineLength() - 1
+}
+
+// Return the line number of the current line the cursor is in, see
+// [GapBuffer.Line].
+func (p *PieceTable) Line() int {
+	return p.lines.curLine()
+}
+
+// Return the line and byte column of the cursor, see [GapBuffer.LineCol].
+func (p *PieceTable) LineCol() (line int, col int) {
+	return p.lines.curLine(), p.Col()
+}
+
+// Return the line and rune column of the cursor, see
+// [GapBuffer.LineRuneCol].
+func (p *PieceTable) LineRuneCol() (line int, runeCol int) {
+	return p.lines.curLine(), p.RuneCol()
+}
+
+// Return the byte offset of the cursor from the start of the text, see
+// [GapBuffer.Offset].
+func (p *PieceTable) Offset() int {
+	return p.cursor
+}
+
+// Return the number of lines in the piece table, see [GapBuffer.LineCount].
+func (p *PieceTable) LineCount() int {
+	p.index(p.length)
+
+	return p.lines.lineCount()
+}
+
+// Delete the unicode rune to the left of the cursor, see [GapBuffer.LeftDel].
+func (p *PieceTable) LeftDel() {
+	if p.cursor < 1 {
+		return
+	}
+
+	r, rSize := p.runeBefore(p.cursor)
+	p.delete(p.cursor-rSize, p.cursor)
+	p.cursor -= rSize
+
+	if r == '\n' {
+		p.lines.upDel()
+	} else {
+		p.lines.del(rSize)
+	}
+
+	p.wantsCol = p.RuneCol()
+}
+
+// Delete the unicode rune to the right of the cursor, see
+// [GapBuffer.RightDel].
+func (p *PieceTable) RightDel() {
+	if p.cursor >= p.length {
+		return
+	}
+
+	r, rSize := p.runeAt(p.cursor)
+	p.index(p.cursor + rSize)
+	p.delete(p.cursor, p.cursor+rSize)
+
+	if r == '\n' {
+		p.lines.downDel()
+	} else {
+		p.lines.del(rSize)
+	}
+}
+
+// Move the cursor one unicode rune to the left, see [GapBuffer.LeftMv].
+func (p *PieceTable) LeftMv() {
+	if p.cursor < 1 {
+		return
+	}
+
+	r, d := p.runeBefore(p.cursor)
+	p.cursor -= d
+
+	if r == '\n' {
+		p.lines.up()
+	}
+
+	p.wantsCol = p.RuneCol()
+}
+
+// Move the cursor one unicode rune to the right, see [GapBuffer.RightMv].
+func (p *PieceTable) RightMv() {
+	if p.cursor >= p.length {
+		return
+	}
+
+	r, d := p.runeAt(p.cursor)
+	p.index(p.cursor + d)
+	p.cursor += d
+
+	if r == '\n' {
+		p.lines.down()
+	}
+
+	p.wantsCol = p.RuneCol()
+}
+
+// Move the cursor up one line, see [GapBuffer.UpMv].
+func (p *PieceTable) UpMv() {
+	if p.lines.curLine() == 1 {
+		return
+	}
+
+	p.lines.up()
+	p.cursor = p.wantedOffset()
+}
+
+// Move the cursor down one line, see [GapBuffer.DownMv].
+func (p *PieceTable) DownMv() {
+	p.index(p.lines.curLineStart() + p.lines.curLineLength())
+
+	if p.lines.isLastLine() {
+		return
+	}
+
+	p.lines.down()
+	p.cursor = p.wantedOffset()
+}
+
+// Insert the given string at the cursor position, see [GapBuffer.Insert].
+//
+// The cursor is moved to the end of the inserted text.
+func (p *PieceTable) Insert(str string) {
+	if str != "" {
+		p.lines.insert(str, p.cursor)
+		p.insert(p.cursor, str)
+		p.cursor += len(str)
+	}
+
+	p.wantsCol = p.RuneCol()
+}
+
+// Move the cursor to the given byte offset from the start of the text, see
+// [GapBuffer.MoveTo].
+func (p *PieceTable) MoveTo(offset int) {
+	p.setCursor(min(max(offset, 0), p.length))
+	p.wantsCol = p.RuneCol()
+}
+
+// Delete the text between the byte offsets `from` (inclusive) and `to`
+// (exclusive), see [GapBuffer.DeleteRange]. The cursor is moved to `from`.
+func (p *PieceTable) DeleteRange(from int, to int) {
+	from, to = min(max(from, 0), p.length), min(max(to, 0), p.length)
+	if to < from {
+		from, to = to, from
+	}
+
+	p.setCursor(from)
+	p.index(to)
+
+	if to > from {
+		p.lines.delRight(to-from, p.countNewlines(from, to))
+		p.delete(from, to)
+	}
+
+	p.wantsCol = p.RuneCol()
+}
+
+// Replace the text between the byte offsets `from` (inclusive) and `to`
+// (exclusive) with the given string, see [GapBuffer.ReplaceRange]. The cursor
+// is moved to the end of the inserted text.
+func (p *PieceTable) ReplaceRange(from int, to int, str string) {
+	p.DeleteRange(from, to)
+	p.Insert(str)
+}
+
+// wantedOffset returns the offset of the rune column `wantsCol` in the current
+// line, or the end of the line, if the line is shorter.
+func (p *PieceTable) wantedOffset() int {
+	pos := p.lines.curLineStart()
+	end := pos + p.lines.curLineLength()
+
+	if !p.lines.isLastLine() {
+		end--
+	}
+
+	for runeCnt := 0; runeCnt < p.wantsCol && pos < end; runeCnt++ {
+		_, d := p.runeAt(pos)
+		pos += d
+	}
+
+	return pos
+}
+
+// setCursor moves the cursor to the given offset in the text. The offset must
+// be valid.
+func (p *PieceTable) setCursor(offset int) {
+	p.index(offset)
+
+	switch {
+	case offset < p.cursor:
+		for nl := p.countNewlines(offset, p.cursor); nl > 0; nl-- {
+			p.lines.up()
+		}
+
+	case offset > p.cursor:
+		for nl := p.countNewlines(p.cursor, offset); nl > 0; nl-- {
+			p.lines.down()
+		}
+	}
+
+	p.cursor = offset
+}
+
+// index adds the lines of the not yet indexed text to the line buffer, until
+// the line containing the given offset is complete. Adds at least
+// [indexChunkSize] bytes at once.
+func (p *PieceTable) index(offset int) {
+	for p.unindexed > 0 && p.length-p.unindexed <= offset {
+		tail := p.original[len(p.original)-p.unindexed:]
+		n := min(len(tail), indexChunkSize)
+
+		if nl := bytes.IndexByte(tail[n:], '\n'); nl >= 0 {
+			n += nl + 1
+		} else {
+			n = len(tail)
+		}
+
+		p.lines.appendText(tail[:n])
+		p.unindexed -= n
+	}
+}
+
+// insert inserts the string at the given offset into the pieces. Text
+// inserted at the end of the last inserted text extends its piece.
+func (p *PieceTable) insert(offset int, str string) {
+	start := len(p.add)
+	p.add = append(p.add, str...)
+	p.length += len(str)
+
+	idx, within := p.locate(offset)
+
+	if within == 0 && idx > 0 {
+		prev := &p.pieces[idx-1]
+		if prev.added && prev.start+prev.length == start {
+			prev.length += len(str)
+
+			return
+		}
+	}
+
+	added := piece{added: true, start: start, length: len(str)}
+
+	if within == 0 {
+		p.pieces = slices.Insert(p.pieces, idx, added)
+
+		return
+	}
+
+	left, right := p.pieces[idx], p.pieces[idx]
+	left.length = within
+	right.start += within
+	right.length -= within
+	p.pieces = slices.Replace(p.pieces, idx, idx+1, left, added, right)
+}
+
+// delete deletes the text between the byte offsets `from` (inclusive) and
+// `to` (exclusive) from the pieces. The offsets must be valid.
+func (p *PieceTable) delete(from int, to int) {
+	first := p.split(from)
+	last := p.split(to)
+	p.pieces = slices.Delete(p.pieces, first, last)
+	p.length -= to - from
+}
+
+// split splits the piece containing the given offset at the offset, and
+// returns the index of the piece starting at the offset.
+func (p *PieceTable) split(offset int) int {
+	idx, within := p.locate(offset)
+	if within == 0 {
+		return idx
+	}
+
+	left, right := p.pieces[idx], p.pieces[idx]
+	left.length = within
+	right.start += within
+	right.length -= within
+	p.pieces = slices.Replace(p.pieces, idx, idx+1, left, right)
+
+	return idx + 1
+}
+
+// locate returns the index of the piece containing the given offset and the
+// offset inside of the piece. The offset of the end of the text is at index
+// `len(pieces)`.
+func (p *PieceTable) locate(offset int) (idx int, within int) {
+	for idx, pc := range p.pieces {
+		if offset < pc.length {
+			return idx, offset
+		}
+
+		offset -= pc.length
+	}
+
+	return len(p.pieces), 0
+}
+
+// bytes returns the bytes of the piece.
+func (p *PieceTable) bytes(pc piece) []byte {
+	if pc.added {
+		return p.add[pc.start : pc.start+pc.length]
+	}
+
+	return p.original[pc.start : pc.start+pc.length]
+}
+
+// each calls `f` with the parts of the pieces between the byte offsets `from`
+// (inclusive) and `to` (exclusive), in order. The offsets must be valid.
+func (p *PieceTable) each(from int, to int, f func(b []byte)) {
+	idx, within := p.locate(from)
+
+	for n := to - from; n > 0 && idx < len(p.pieces); idx++ {
+		b := p.bytes(p.pieces[idx])[within:]
+		b = b[:min(len(b), n)]
+		f(b)
+		n -= len(b)
+		within = 0
+	}
+}
+
+// runeAt returns the unicode rune starting at the given offset in the text and
+// its size in bytes. The offset must be valid.
+func (p *PieceTable) runeAt(offset int) (rune, int) {
+	var buf [utf8.UTFMax]byte
+
+	n := 0
+	p.each(offset, min(offset+utf8.UTFMax, p.length), func(b []byte) { n += copy(buf[n:], b) })
+
+	return utf8.DecodeRune(buf[:n])
+}
+
+// runeBefore returns the unicode rune ending at the given offset in the text
+// and its size in bytes. The offset must be valid.
+func (p *PieceTable) runeBefore(offset int) (rune, int) {
+	var buf [utf8.UTFMax]byte
+
+	n := 0
+	p.each(max(offset-utf8.UTFMax, 0), offset, func(b []byte) { n += copy(buf[n:], b) })
+
+	return utf8.DecodeLastRune(buf[:n])
+}
+
+// runeCount returns the number of unicode runes between the byte offsets
+// `from` (inclusive) and `to` (exclusive). The offsets must be valid. Every
+// byte of invalid UTF-8 counts as one rune.
+func (p *PieceTable) runeCount(from int, to int) int {
+	count := 0
+
+	// The offset of the current piece and the end of the last rune split by
+	// pieces.
+	pos, end := from, from
+
+	p.each(from, to, func(b []byte) {
+		if pos > end {
+			// The bytes of a rune split by pieces have been counted as invalid
+			// bytes.
+			if start, size := p.splitRune(max(end, pos-utf8.UTFMax+1), pos, to); size > 0 {
+				count -= size - 1
+				end = start + size
+			}
+		}
+
+		count += utf8.RuneCount(b)
+		pos += len(b)
+	})
+
+	return count
+}
+
+// splitRune returns the byte offset and size of a valid unicode rune which
+// starts between `from` and the start of a piece at `offset` and ends before
+// `to`. The size is 0 if the offset isn't in the middle of a valid rune.
+func (p *PieceTable) splitRune(from int, offset int, to int) (start int, size int) {
+	var buf [2 * utf8.UTFMax]byte
+
+	n := 0
+	p.each(from, min(offset+utf8.UTFMax-1, to), func(b []byte) { n += copy(buf[n:], b) })
+
+	for k := 1; k <= offset-from; k++ {
+		idx := offset - from - k
+
+		if utf8.RuneStart(buf[idx]) {
+			if _, size = utf8.DecodeRune(buf[idx:n]); size > k {
+				return offset - k, size
+			}
+
+			return 0, 0
+		}
+	}
+
+	return 0, 0
+}
+
+// countNewlines returns the number of newline characters between the byte
+// offsets `from` (inclusive) and `to` (exclusive). The offsets must be valid.
+func (p *PieceTable) countNewlines(from int, to int) int {
+	count := 0
+	p.each(from, to, func(b []byte) { count += bytes.Count(b, []byte{'\n'}) })
+
+	return count
+}
+
+// slice returns the text between the byte offsets `from` (inclusive) and `to`
+// (exclusive) as a string. The offsets must be valid.
+func (p *PieceTable) slice(from int, to int) string {
+	var builder strings.Builder
+
+	builder.Grow(to - from)
+	p.each(from, to, func(b []byte) { builder.Write(b) })
+
+	return builder.String()
+}
@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     piece-table_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomEdit applies the same random movement or edit to all buffers. The
+// offsets are at the start of runes.
+func randomEdit(rnd *rand.Rand, buffers ...gapbuffer.Buffer) {
+	text := buffers[0].String()
+	from := rnd.Intn(len(text) + 1)
+	to := from + rnd.Intn(len(text)-from+1)
+
+	for from < len(text) && !utf8.RuneStart(text[from]) {
+		from--
+	}
+
+	for to < len(text) && !utf8.RuneStart(text[to]) {
+		to--
+	}
+	str := []string{"a", "ä", "\n", "xy\nz", "😀\n\n", ""}[rnd.Intn(6)]
+	op := rnd.Intn(10)
+
+	for _, b := range buffers {
+		switch op {
+		case 0:
+			b.LeftMv()
+		case 1:
+			b.RightMv()
+		case 2:
+			b.UpMv()
+		case 3:
+			b.DownMv()
+		case 4:
+			b.LeftDel()
+		case 5:
+			b.RightDel()
+		case 6:
+			b.Insert(str)
+		case 7:
+			b.MoveTo(from)
+		case 8:
+			b.DeleteRange(from, to)
+		default:
+			b.ReplaceRange(from, to, str)
+		}
+	}
+}
+
+// requireSameState checks that both buffers have the same text and cursor.
+func requireSameState(t *testing.T, exp gapbuffer.Buffer, act gapbuffer.Buffer) {
+	t.Helper()
+
+	expL, expR := exp.StringPair()
+	actL, actR := act.StringPair()
+	require.Equal(t, expL, actL, "Left of the cursor")
+	require.Equal(t, expR, actR, "Right of the cursor")
+	require.Equal(t, exp.String(), act.String())
+	require.Equal(t, exp.StringLength(), act.StringLength())
+	require.Equal(t, exp.Offset(), act.Offset())
+	require.Equal(t, exp.LineCount(), act.LineCount())
+	require.Equal(t, exp.LineLength(), act.LineLength())
+
+	expLine, expCol := exp.LineRuneCol()
+	actLine, actCol := act.LineRuneCol()
+	require.Equal(t, expLine, actLine, "Line")
+	require.Equal(t, expCol, actCol, "Rune column")
+	require.Equal(t, exp.Col(), act.Col(), "Byte column")
+}
+
+func TestPieceTable(t *testing.T) {
+	t.Parallel()
+
+	pt := gapbuffer.NewPieceTable("Hello world!")
+	pt.MoveTo(6)
+	pt.Insert("big ")
+	pt.Insert("new ")
+	pt.MoveTo(0)
+	pt.RightDel()
+	pt.Insert("h")
+
+	assert.Equal(t, "hello big new world!", pt.String())
+	assert.Equal(t, 1, pt.Offset())
+
+	pt.DeleteRange(6, 14)
+	assert.Equal(t, "hello world!", pt.String())
+
+	pt.ReplaceRange(0, pt.StringLength(), "")
+	assert.Equal(t, "", pt.String())
+	assert.Equal(t, 1, pt.LineCount())
+}
+
+func TestPieceTableBytes(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("one\ntwo\nthree")
+	pt := gapbuffer.NewPieceTableBytes(data)
+	pt.UpMv()
+	pt.Insert("2")
+	pt.DeleteRange(0, 4)
+
+	assert.Equal(t, "two2\nthree", pt.String())
+	assert.Equal(t, "one\ntwo\nthree", string(data), "The original is not changed")
+}
+
+func TestPieceTableRandom(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(71)) //nolint:gosec // test
+
+	for i := 0; i < 100; i++ {
+		text := strings.Join(randomLines(rnd), "")
+		gb, pt := gapbuffer.NewStr(text), gapbuffer.NewPieceTable(text)
+		requireSameState(t, gb, pt)
+
+		for j := 0; j < 100; j++ {
+			randomEdit(rnd, gb, pt)
+			requireSameState(t, gb, pt)
+		}
+	}
+}
+
+func TestOpenPieceTable(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(72)) //nolint:gosec // test
+	text := strings.Repeat("line ä\n\n"+strings.Repeat("long line ", 2_000)+"\n", 20)
+	path := filepath.Join(t.TempDir(), "huge.txt")
+	require.NoError(t, os.WriteFile(path, []byte(text), 0o600))
+
+	pt, err := gapbuffer.OpenPieceTable(path)
+	require.NoError(t, err)
+
+	gb := gapbuffer.NewStr(text)
+	gb.MoveTo(0)
+	requireSameState(t, gb, pt)
+
+	for j := 0; j < 200; j++ {
+		randomEdit(rnd, gb, pt)
+		requireSameState(t, gb, pt)
+	}
+
+	require.NoError(t, pt.Close())
+	require.NoError(t, pt.Close(), "Closing twice")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, text, string(data), "The file is not changed")
+}
+
+func TestOpenPieceTableEmpty(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "empty.txt")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	pt, err := gapbuffer.OpenPieceTable(path)
+	require.NoError(t, err)
+	pt.Insert("new")
+	assert.Equal(t, "new", pt.String())
+	assert.Equal(t, 1, pt.LineCount())
+	require.NoError(t, pt.Close())
+
+	_, err = gapbuffer.OpenPieceTable(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,355 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     position.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+// Position is a position in the text like the Language Server Protocol (LSP)
+// defines it: a zero based line and a zero based character offset in the line.
+// The unit of the character offset depends on the [PositionEncodingKind] in use,
+// the LSP default is UTF-16 code units.
+//
+// Note: unlike [GapBuffer.Line], the line numbering of a Position starts from 0.
+type Position struct {
+	// Line is the zero based line number.
+	Line int `json:"line"`
+
+	// Character is the zero based offset in the line, in units of the position
+	// encoding.
+	Character int `json:"character"`
+}
+
+// Range is a range in the text between two [Position]s, like the LSP defines
+// it. The end position is exclusive.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// TextDocumentContentChangeEvent is the LSP event describing a change to a text
+// document. If `Range` is nil, `Text` is the new content of the whole document.
+//
+// See [GapBuffer.ApplyContentChange].
+type TextDocumentContentChangeEvent struct {
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
+}
+
+// PositionEncodingKind is the unit of the character offset of a [Position], as
+// negotiated between LSP client and server.
+type PositionEncodingKind string
+
+const (
+	// Character offsets count bytes of the UTF-8 encoding.
+	PositionEncodingUTF8 PositionEncodingKind = "utf-8"
+
+	// Character offsets count UTF-16 code units, this is the LSP default.
+	PositionEncodingUTF16 PositionEncodingKind = "utf-16"
+
+	// Character offsets count unicode runes.
+	PositionEncodingUTF32 PositionEncodingKind = "utf-32"
+)
+
+var (
+	// ErrInvalidPosition is returned if a position or offset is not inside the
+	// text of the gap buffer.
+	ErrInvalidPosition = errors.New("position is not inside the text")
+
+	// ErrUnknownEncoding is returned if the position encoding is not one of
+	// [PositionEncodingUTF8], [PositionEncodingUTF16] or
+	// [PositionEncodingUTF32].
+	ErrUnknownEncoding = errors.New("unknown position encoding")
+)
+
+// The number of UTF-16 code units of runes outside of the basic multilingual
+// plane, which are encoded as a surrogate pair.
+const surrogatePairLen = 2
+
+// NegotiatePositionEncoding returns the position encoding to use, given the
+// encodings the client supports - `general.positionEncodings` of the LSP client
+// capabilities - in the order of the client's preference.
+//
+// The first supported encoding in the list is returned. If the list is empty or
+// contains no supported encoding, the LSP default [PositionEncodingUTF16] is
+// returned.
+func NegotiatePositionEncoding(clientEncodings []PositionEncodingKind) PositionEncodingKind {
+	for _, enc := range clientEncodings {
+		if isKnownEncoding(enc) {
+			return enc
+		}
+	}
+
+	return PositionEncodingUTF16
+}
+
+// Return the line and byte column of the given byte offset. This is the same as
+// [GapBuffer.LineCol] would return, if the cursor would be at the offset.
+//
+// Numbering starts from 1 for the line, the column is the number of bytes from
+// the start of the line to the offset.
+//
+// Returns [ErrInvalidPosition] if the offset is not inside the text.
+//
+// See also [GapBuffer.LineColToOffset], [GapBuffer.OffsetToLineRuneCol].
+func (g *GapBuffer) OffsetToLineCol(offset int) (line int, col int, err error) {
+	if offset < 0 || offset > g.StringLength() {
+		return 0, 0, ErrInvalidPosition
+	}
+
+	g.index(offset)
+	idx := g.lines.lineIndex(offset)
+
+	return idx + 1, offset - g.lines.lineStart(idx), nil
+}
+
+// Return the byte offset of the given line and byte column.
+//
+// Numbering starts from 1 for the line, the column is the number of bytes from
+// the start of the line, like the result of [GapBuffer.LineCol].
+//
+// Returns [ErrInvalidPosition] if the line does not exist or the column is
+// greater than the length of the line.
+//
+// See also [GapBuffer.OffsetToLineCol], [GapBuffer.LineRuneColToOffset].
+func (g *GapBuffer) LineColToOffset(line int, col int) (int, error) {
+	start, text, err := g.lineText(line - 1)
+	if err != nil {
+		return 0, err
+	}
+
+	if col < 0 || col > len(text) {
+		return 0, ErrInvalidPosition
+	}
+
+	return start + col, nil
+}
+
+// Return the line and rune column of the given byte offset. This is the same as
+// [GapBuffer.LineRuneCol] would return, if the cursor would be at the offset.
+//
+// Numbering starts from 1 for the line, the column is the number of unicode
+// runes from the start of the line to the offset.
+//
+// Returns [ErrInvalidPosition] if the offset is not inside the text.
+//
+// See also [GapBuffer.LineRuneColToOffset], [GapBuffer.OffsetToLineCol].
+func (g *GapBuffer) OffsetToLineRuneCol(offset int) (line int, runeCol int, err error) {
+	line, col, err := g.OffsetToLineCol(offset)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return line, utf8.RuneCountInString(g.slice(offset-col, offset)), nil
+}
+
+// Return the byte offset of the given line and rune column.
+//
+// Numbering starts from 1 for the line, the column is the number of unicode
+// runes from the start of the line, like the result of [GapBuffer.RuneCol].
+//
+// Returns [ErrInvalidPosition] if the line does not exist or the column is
+// greater than the number of runes in the line.
+//
+// See also [GapBuffer.OffsetToLineRuneCol], [GapBuffer.LineColToOffset].
+func (g *GapBuffer) LineRuneColToOffset(line int, runeCol int) (int, error) {
+	start, text, err := g.lineText(line - 1)
+	if err != nil {
+		return 0, err
+	}
+
+	if runeCol < 0 {
+		return 0, ErrInvalidPosition
+	}
+
+	col := 0
+	for ; runeCol > 0; runeCol-- {
+		if col == len(text) {
+			return 0, ErrInvalidPosition
+		}
+
+		_, d := utf8.DecodeRuneInString(text[col:])
+		col += d
+	}
+
+	return start + col, nil
+}
+
+// Return the LSP [Position] of the given byte offset, with the character offset
+// in units of the given encoding.
+//
+// Returns [ErrInvalidPosition] if the offset is not inside the text and
+// [ErrUnknownEncoding] if the encoding isn't supported.
+//
+// See also [GapBuffer.PositionToOffset], [NegotiatePositionEncoding].
+func (g *GapBuffer) OffsetToPosition(offset int, enc PositionEncodingKind) (Position, error) {
+	line, col, err := g.OffsetToLineCol(offset)
+	if err != nil {
+		return Position{}, err
+	}
+
+	text := g.slice(offset-col, offset)
+	units := 0
+
+	switch enc {
+	case PositionEncodingUTF8:
+		units = len(text)
+
+	case PositionEncodingUTF16:
+		for _, r := range text {
+			units += utf16Len(r)
+		}
+
+	case PositionEncodingUTF32:
+		units = utf8.RuneCountInString(text)
+
+	default:
+		return Position{}, ErrUnknownEncoding
+	}
+
+	return Position{Line: line - 1, Character: units}, nil
+}
+
+// Return the byte offset of the given LSP [Position], with the character offset
+// in units of the given encoding.
+//
+// Like the LSP specifies, a character offset greater than the line length
+// defaults back to the line length. A character offset in the middle of a
+// unicode rune - of a UTF-8 sequence or a UTF-16 surrogate pair - is moved back
+// to the start of the rune.
+//
+// Returns [ErrInvalidPosition] if the line does not exist or the character
+// offset is negative, and [ErrUnknownEncoding] if the encoding isn't supported.
+//
+// See also [GapBuffer.OffsetToPosition], [NegotiatePositionEncoding].
+func (g *GapBuffer) PositionToOffset(pos Position, enc PositionEncodingKind) (int, error) {
+	if !isKnownEncoding(enc) {
+		return 0, ErrUnknownEncoding
+	}
+
+	start, text, err := g.lineText(pos.Line)
+	if err != nil {
+		return 0, err
+	}
+
+	if pos.Character < 0 {
+		return 0, ErrInvalidPosition
+	}
+
+	col := 0
+	units := 0
+
+	for col < len(text) {
+		r, d := utf8.DecodeRuneInString(text[col:])
+
+		switch enc {
+		case PositionEncodingUTF8:
+			units += d
+
+		case PositionEncodingUTF16:
+			units += utf16Len(r)
+
+		default:
+			units++
+		}
+
+		if units > pos.Character {
+			break
+		}
+
+		col += d
+	}
+
+	return start + col, nil
+}
+
+// Apply the given LSP change event to the gap buffer, using the given position
+// encoding for the range. If the range of the change is nil, the whole content
+// of the gap buffer is replaced by the text of the change.
+//
+// The cursor is moved to the end of the inserted text.
+//
+// Returns [ErrInvalidPosition] if the range is not inside the text,
+// [ErrUnknownEncoding] if the encoding isn't supported and [ErrInvalidUTF8] if
+// the text is rejected by the [InvalidUTF8Policy]. The gap buffer is not
+// changed if an error is returned.
+//
+// See also [GapBuffer.ReplaceRange], [GapBuffer.PositionToOffset].
+func (g *GapBuffer) ApplyContentChange(change TextDocumentContentChangeEvent, enc PositionEncodingKind) error {
+	if !g.CanInsert(change.Text) {
+		return ErrInvalidUTF8
+	}
+
+	if change.Range == nil {
+		g.ReplaceRange(0, g.StringLength(), change.Text)
+
+		return nil
+	}
+
+	from, err := g.PositionToOffset(change.Range.Start, enc)
+	if err != nil {
+		return err
+	}
+
+	to, err := g.PositionToOffset(change.Range.End, enc)
+	if err != nil {
+		return err
+	}
+
+	if to < from {
+		return ErrInvalidPosition
+	}
+
+	g.ReplaceRange(from, to, change.Text)
+
+	return nil
+}
+
+// lineText returns the byte offset of the start of the line with the given
+// zero based index and the text of the line without the newline character.
+// Returns [ErrInvalidPosition] if the line does not exist.
+func (g *GapBuffer) lineText(idx int) (start int, text string, err error) {
+	g.indexLine(idx)
+
+	if idx < 0 || idx >= g.lines.lineCount() {
+		return 0, "", ErrInvalidPosition
+	}
+
+	start = g.lines.lineStart(idx)
+	end := start + g.lines.lineLength(idx)
+
+	if idx < g.lines.lineCount()-1 {
+		end--
+	}
+
+	return start, g.slice(start, end), nil
+}
+
+// isKnownEncoding returns true if the given position encoding is supported.
+func isKnownEncoding(enc PositionEncodingKind) bool {
+	switch enc {
+	case PositionEncodingUTF8, PositionEncodingUTF16, PositionEncodingUTF32:
+		return true
+	default:
+		return false
+	}
+}
+
+// utf16Len returns the number of UTF-16 code units needed to encode the given
+// rune.
+func utf16Len(r rune) int {
+	if r >= 0x10000 && r <= utf8.MaxRune {
+		return surrogatePairLen
+	}
+
+	return 1
+}
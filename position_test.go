@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     position_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// "a😀b" is 1 + 4 + 1 bytes, 1 + 2 + 1 UTF-16 code units and 3 runes long.
+const posText = "Hello\na😀b\n\nend"
+
+func TestNegotiatePositionEncoding(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, gapbuffer.PositionEncodingUTF16, gapbuffer.NegotiatePositionEncoding(nil))
+	assert.Equal(t, gapbuffer.PositionEncodingUTF8,
+		gapbuffer.NegotiatePositionEncoding([]gapbuffer.PositionEncodingKind{"utf-7", "utf-8", "utf-16"}))
+	assert.Equal(t, gapbuffer.PositionEncodingUTF16,
+		gapbuffer.NegotiatePositionEncoding([]gapbuffer.PositionEncodingKind{"ebcdic"}))
+}
+
+func TestOffsetToLineCol(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(posText)
+
+	line, col, err := gb.OffsetToLineCol(11)
+	require.NoError(t, err)
+	assert.Equal(t, 2, line, "Line")
+	assert.Equal(t, 5, col, "Col")
+
+	line, runeCol, err := gb.OffsetToLineRuneCol(11)
+	require.NoError(t, err)
+	assert.Equal(t, 2, line, "Line")
+	assert.Equal(t, 2, runeCol, "Rune col")
+
+	line, col, err = gb.OffsetToLineCol(gb.StringLength())
+	require.NoError(t, err)
+	assert.Equal(t, 4, line, "Last line")
+	assert.Equal(t, 3, col, "Last col")
+
+	_, _, err = gb.OffsetToLineCol(gb.StringLength() + 1)
+	require.ErrorIs(t, err, gapbuffer.ErrInvalidPosition)
+}
+
+func TestLineColToOffset(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(posText)
+
+	offset, err := gb.LineColToOffset(2, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 11, offset)
+
+	offset, err = gb.LineRuneColToOffset(2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 11, offset)
+
+	offset, err = gb.LineRuneColToOffset(3, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 13, offset)
+
+	_, err = gb.LineColToOffset(2, 7)
+	require.ErrorIs(t, err, gapbuffer.ErrInvalidPosition)
+
+	_, err = gb.LineRuneColToOffset(2, 4)
+	require.ErrorIs(t, err, gapbuffer.ErrInvalidPosition)
+
+	_, err = gb.LineColToOffset(5, 0)
+	require.ErrorIs(t, err, gapbuffer.ErrInvalidPosition)
+}
+
+func TestOffsetToPosition(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(posText)
+	gb.MoveTo(3)
+
+	pos8, err := gb.OffsetToPosition(11, gapbuffer.PositionEncodingUTF8)
+	require.NoError(t, err)
+	pos16, err := gb.OffsetToPosition(11, gapbuffer.PositionEncodingUTF16)
+	require.NoError(t, err)
+	pos32, err := gb.OffsetToPosition(11, gapbuffer.PositionEncodingUTF32)
+	require.NoError(t, err)
+
+	assert.Equal(t, gapbuffer.Position{Line: 1, Character: 5}, pos8, "UTF-8")
+	assert.Equal(t, gapbuffer.Position{Line: 1, Character: 3}, pos16, "UTF-16")
+	assert.Equal(t, gapbuffer.Position{Line: 1, Character: 2}, pos32, "UTF-32")
+
+	_, err = gb.OffsetToPosition(11, "utf-7")
+	require.ErrorIs(t, err, gapbuffer.ErrUnknownEncoding)
+}
+
+func TestPositionToOffset(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(posText)
+
+	offset, err := gb.PositionToOffset(gapbuffer.Position{Line: 1, Character: 3}, gapbuffer.PositionEncodingUTF16)
+	require.NoError(t, err)
+	assert.Equal(t, 11, offset, "After surrogate pair")
+
+	offset, err = gb.PositionToOffset(gapbuffer.Position{Line: 1, Character: 2}, gapbuffer.PositionEncodingUTF16)
+	require.NoError(t, err)
+	assert.Equal(t, 7, offset, "Inside surrogate pair")
+
+	offset, err = gb.PositionToOffset(gapbuffer.Position{Line: 1, Character: 100}, gapbuffer.PositionEncodingUTF32)
+	require.NoError(t, err)
+	assert.Equal(t, 12, offset, "Past the line end")
+
+	_, err = gb.PositionToOffset(gapbuffer.Position{Line: 4, Character: 0}, gapbuffer.PositionEncodingUTF8)
+	require.ErrorIs(t, err, gapbuffer.ErrInvalidPosition)
+}
+
+func TestApplyContentChange(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr(posText)
+
+	err := gb.ApplyContentChange(gapbuffer.TextDocumentContentChangeEvent{
+		Range: &gapbuffer.Range{
+			Start: gapbuffer.Position{Line: 0, Character: 5},
+			End:   gapbuffer.Position{Line: 1, Character: 3},
+		},
+		Text: " 🙂\nx",
+	}, gapbuffer.PositionEncodingUTF16)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Hello 🙂\nxb\n\nend", gb.String())
+	line, col := gb.LineCol()
+	assert.Equal(t, 2, line, "Line")
+	assert.Equal(t, 1, col, "Col")
+	assert.Equal(t, 4, gb.LineCount(), "Line count")
+
+	err = gb.ApplyContentChange(gapbuffer.TextDocumentContentChangeEvent{Text: "new"}, gapbuffer.PositionEncodingUTF8)
+	require.NoError(t, err)
+	assert.Equal(t, "new", gb.String())
+	assert.Equal(t, 1, gb.LineCount(), "Line count")
+}
+
+func TestDeleteRangeJoinsLines(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("ab\ncd\nef")
+	gb.DeleteRange(4, 1)
+
+	assert.Equal(t, "ad\nef", gb.String())
+	assert.Equal(t, 1, gb.Offset(), "Offset")
+	assert.Equal(t, 2, gb.LineLength(), "Line length")
+	gb.DownMv()
+	assert.Equal(t, 2, gb.Line(), "Line")
+}
+
+func TestLeftDelNewlineJoinsLines(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("ab\ncd")
+	gb.MoveTo(3)
+	gb.LeftDel()
+
+	assert.Equal(t, 4, gb.LineLength(), "Line length")
+	assert.Equal(t, 1, gb.LineCount(), "Line count")
+}
@@ -0,0 +1,613 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     rope.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rope is a [Buffer] for very large texts. The text is stored in chunks of at
+// most [ropeChunkSize] bytes, which are the leaves of a balanced tree - a
+// B-tree - with at most [ropeMaxChildren] children per node. Every node caches
+// the number of bytes, runes and newlines of its subtree:
+//
+//	              {bytes: 14, runes: 14, newlines: 2}
+//	               /                             \
+//	"Hello\nWor" {9, 9, 1}               "ld!\nFoo" {5, 5, 1}
+//
+// So inserting and deleting anywhere, converting between byte offsets, rune
+// offsets and lines takes O(log n) time, independent of the position of the
+// cursor. Unlike [GapBuffer] and [PieceTable], the line of the cursor is not
+// tracked but looked up in the tree.
+type Rope struct {
+	// The root of the tree, a leaf with an empty chunk for the empty text.
+	root *ropeNode
+
+	// The position of the cursor, the byte offset in the text.
+	cursor int
+
+	// `wantsCol` is the rune column (not byte column!) the cursor wants to hold
+	// when going up or down.
+	wantsCol int
+}
+
+// ropeNode is a node of the tree of a [Rope]. Leaves have a chunk of the text
+// and no children, inner nodes have children and no chunk. All leaves are at
+// the same depth.
+type ropeNode struct {
+	// The children of an inner node, nil for leaves.
+	children []*ropeNode
+
+	// The text of a leaf.
+	chunk []byte
+
+	// The number of bytes in the subtree.
+	bytes int
+
+	// The number of unicode runes in the subtree.
+	runes int
+
+	// The number of newline characters in the subtree.
+	newlines int
+}
+
+const (
+	// The maximum size of the chunk of a leaf of a [Rope], in bytes.
+	ropeChunkSize = 1024
+
+	// The maximum number of children of an inner node of a [Rope].
+	ropeMaxChildren = 16
+)
+
+// Construct a new Rope from a string. The cursor position is set to the end
+// of the string.
+//
+// See also [NewStr], [NewPieceTable].
+func NewRope(str string) *Rope {
+	nodes := ropeLeaves([]byte(str))
+	if len(nodes) == 0 {
+		nodes = []*ropeNode{newRopeLeaf(nil)}
+	}
+
+	for len(nodes) > 1 {
+		nodes = ropeGroup(nodes)
+	}
+
+	r := &Rope{root: nodes[0], cursor: len(str), wantsCol: 0}
+	r.wantsCol = r.RuneCol()
+
+	return r
+}
+
+// Return the contents of the rope as a string.
+func (r *Rope) String() string {
+	return r.slice(0, r.root.bytes)
+}
+
+// Return the contents of the rope as a pair of strings. The part to the left
+// of the cursor is returned in `left` and the part to the right of the cursor
+// is returned in `right`.
+func (r *Rope) StringPair() (left string, right string) {
+	return r.slice(0, r.cursor), r.slice(r.cursor, r.root.bytes)
+}
+
+// Return the length in bytes of the contents of the rope.
+func (r *Rope) StringLength() int {
+	return r.root.bytes
+}
+
+// Return the byte column of the cursor, see [GapBuffer.Col].
+func (r *Rope) Col() int {
+	return r.cursor - r.root.lineStart(r.root.newlinesBefore(r.cursor))
+}
+
+// Return the rune column of the cursor, see [GapBuffer.RuneCol].
+func (r *Rope) RuneCol() int {
+	start := r.root.lineStart(r.root.newlinesBefore(r.cursor))
+
+	return r.root.runesBefore(r.cursor) - r.root.runesBefore(start)
+}
+
+// Return the length of the current line the cursor is in, in bytes, see
+// [GapBuffer.LineLength].
+func (r *Rope) LineLength() int {
+	start, end := r.lineBounds(r.root.newlinesBefore(r.cursor))
+
+	return end - start
+}
+
+// Return the line number of the current line the cursor is in, see
+// [GapBuffer.Line].
+func (r *Rope) Line() int {
+	return r.root.newlinesBefore(r.cursor) + 1
+}
+
+// Return the line and byte column of the cursor, see [GapBuffer.LineCol].
+func (r *Rope) LineCol() (line int, col int) {
+	return r.Line(), r.Col()
+}
+
+// Return the line and rune column of the cursor, see
+// [GapBuffer.LineRuneCol].
+func (r *Rope) LineRuneCol() (line int, runeCol int) {
+	return r.Line(), r.RuneCol()
+}
+
+// Return the byte offset of the cursor from the start of the text, see
+// [GapBuffer.Offset].
+func (r *Rope) Offset() int {
+	return r.cursor
+}
+
+// Return the number of lines in the rope, see [GapBuffer.LineCount].
+func (r *Rope) LineCount() int {
+	return r.root.newlines + 1
+}
+
+// Delete the unicode rune to the left of the cursor, see [GapBuffer.LeftDel].
+func (r *Rope) LeftDel() {
+	if r.cursor < 1 {
+		return
+	}
+
+	_, d := r.runeBefore(r.cursor)
+	r.delete(r.cursor-d, r.cursor)
+	r.cursor -= d
+	r.wantsCol = r.RuneCol()
+}
+
+// Delete the unicode rune to the right of the cursor, see
+// [GapBuffer.RightDel].
+func (r *Rope) RightDel() {
+	if r.cursor >= r.root.bytes {
+		return
+	}
+
+	_, d := r.runeAt(r.cursor)
+	r.delete(r.cursor, r.cursor+d)
+}
+
+// Move the cursor one unicode rune to the left, see [GapBuffer.LeftMv].
+func (r *Rope) LeftMv() {
+	if r.cursor < 1 {
+		return
+	}
+
+	_, d := r.runeBefore(r.cursor)
+	r.cursor -= d
+	r.wantsCol = r.RuneCol()
+}
+
+// Move the cursor one unicode rune to the right, see [GapBuffer.RightMv].
+func (r *Rope) RightMv() {
+	if r.cursor >= r.root.bytes {
+		return
+	}
+
+	_, d := r.runeAt(r.cursor)
+	r.cursor += d
+	r.wantsCol = r.RuneCol()
+}
+
+// Move the cursor up one line, see [GapBuffer.UpMv].
+func (r *Rope) UpMv() {
+	line := r.root.newlinesBefore(r.cursor)
+	if line == 0 {
+		return
+	}
+
+	r.cursor = r.wantedOffset(line - 1)
+}
+
+// Move the cursor down one line, see [GapBuffer.DownMv].
+func (r *Rope) DownMv() {
+	line := r.root.newlinesBefore(r.cursor)
+	if line == r.root.newlines {
+		return
+	}
+
+	r.cursor = r.wantedOffset(line + 1)
+}
+
+// Insert the given string at the cursor position, see [GapBuffer.Insert].
+//
+// The cursor is moved to the end of the inserted text.
+func (r *Rope) Insert(str string) {
+	if str != "" {
+		if nodes := r.root.insert(r.cursor, []byte(str)); nodes != nil {
+			for len(nodes) > 1 {
+				nodes = ropeGroup(nodes)
+			}
+
+			r.root = nodes[0]
+		}
+
+		r.cursor += len(str)
+	}
+
+	r.wantsCol = r.RuneCol()
+}
+
+// Move the cursor to the given byte offset from the start of the text, see
+// [GapBuffer.MoveTo].
+func (r *Rope) MoveTo(offset int) {
+	r.cursor = min(max(offset, 0), r.root.bytes)
+	r.wantsCol = r.RuneCol()
+}
+
+// Delete the text between the byte offsets `from` (inclusive) and `to`
+// (exclusive), see [GapBuffer.DeleteRange]. The cursor is moved to `from`.
+func (r *Rope) DeleteRange(from int, to int) {
+	from, to = min(max(from, 0), r.root.bytes), min(max(to, 0), r.root.bytes)
+	if to < from {
+		from, to = to, from
+	}
+
+	r.cursor = from
+
+	if to > from {
+		r.delete(from, to)
+	}
+
+	r.wantsCol = r.RuneCol()
+}
+
+// Replace the text between the byte offsets `from` (inclusive) and `to`
+// (exclusive) with the given string, see [GapBuffer.ReplaceRange]. The cursor
+// is moved to the end of the inserted text.
+func (r *Rope) ReplaceRange(from int, to int, str string) {
+	r.DeleteRange(from, to)
+	r.Insert(str)
+}
+
+// lineBounds returns the byte offsets of the start and the end - without the
+// newline character - of the line with the given index, starting from 0.
+func (r *Rope) lineBounds(line int) (start int, end int) {
+	start = r.root.lineStart(line)
+
+	if line == r.root.newlines {
+		return start, r.root.bytes
+	}
+
+	return start, r.root.lineStart(line+1) - 1
+}
+
+// wantedOffset returns the offset of the rune column `wantsCol` in the line
+// with the given index, or the end of the line, if the line is shorter.
+func (r *Rope) wantedOffset(line int) int {
+	start, end := r.lineBounds(line)
+
+	return min(r.root.runeOffset(r.root.runesBefore(start)+r.wantsCol), end)
+}
+
+// delete deletes the text between the byte offsets `from` (inclusive) and `to`
+// (exclusive) from the tree. The offsets must be valid.
+func (r *Rope) delete(from int, to int) {
+	r.root.delete(from, to)
+
+	for len(r.root.children) == 1 {
+		r.root = r.root.children[0]
+	}
+
+	if r.root.children != nil && len(r.root.children) == 0 {
+		r.root = newRopeLeaf(nil)
+	}
+}
+
+// each calls `f` with the parts of the chunks between the byte offsets `from`
+// (inclusive) and `to` (exclusive), in order. The offsets must be valid.
+func (r *Rope) each(from int, to int, f func(b []byte)) {
+	if from < to {
+		r.root.each(from, to, f)
+	}
+}
+
+// runeAt returns the unicode rune starting at the given offset in the text and
+// its size in bytes. The offset must be valid.
+func (r *Rope) runeAt(offset int) (rune, int) {
+	var buf [utf8.UTFMax]byte
+
+	n := 0
+	r.each(offset, min(offset+utf8.UTFMax, r.root.bytes), func(b []byte) { n += copy(buf[n:], b) })
+
+	return utf8.DecodeRune(buf[:n])
+}
+
+// runeBefore returns the unicode rune ending at the given offset in the text
+// and its size in bytes. The offset must be valid.
+func (r *Rope) runeBefore(offset int) (rune, int) {
+	var buf [utf8.UTFMax]byte
+
+	n := 0
+	r.each(max(offset-utf8.UTFMax, 0), offset, func(b []byte) { n += copy(buf[n:], b) })
+
+	return utf8.DecodeLastRune(buf[:n])
+}
+
+// slice returns the text between the byte offsets `from` (inclusive) and `to`
+// (exclusive) as a string. The offsets must be valid.
+func (r *Rope) slice(from int, to int) string {
+	var builder strings.Builder
+
+	builder.Grow(to - from)
+	r.each(from, to, func(b []byte) { builder.Write(b) })
+
+	return builder.String()
+}
+
+// newRopeLeaf returns a new leaf with the given chunk, which is not copied.
+func newRopeLeaf(chunk []byte) *ropeNode {
+	return &ropeNode{
+		children: nil,
+		chunk:    chunk,
+		bytes:    len(chunk),
+		runes:    utf8.RuneCount(chunk),
+		newlines: bytes.Count(chunk, []byte{'\n'}),
+	}
+}
+
+// newRopeInner returns a new inner node with the given children.
+func newRopeInner(children []*ropeNode) *ropeNode {
+	n := &ropeNode{children: children, chunk: nil, bytes: 0, runes: 0, newlines: 0}
+	n.update()
+
+	return n
+}
+
+// ropeLeaves returns the text split into leaves of at most [ropeChunkSize]
+// bytes, the chunks are copied. Chunks are split at the start of a rune.
+func ropeLeaves(text []byte) []*ropeNode {
+	leaves := make([]*ropeNode, 0, (len(text)+ropeChunkSize-1)/ropeChunkSize)
+
+	for len(text) > 0 {
+		n := min(len(text), ropeChunkSize)
+
+		for n < len(text) && n > ropeChunkSize/2 && !utf8.RuneStart(text[n]) {
+			n--
+		}
+
+		leaves = append(leaves, newRopeLeaf(slices.Clone(text[:n])))
+		text = text[n:]
+	}
+
+	return leaves
+}
+
+// ropeGroup returns the nodes grouped into inner nodes with at most
+// [ropeMaxChildren] children, of about the same size.
+func ropeGroup(nodes []*ropeNode) []*ropeNode {
+	count := (len(nodes) + ropeMaxChildren - 1) / ropeMaxChildren
+	groups := make([]*ropeNode, 0, count)
+
+	for idx := 0; idx < count; idx++ {
+		from, to := idx*len(nodes)/count, (idx+1)*len(nodes)/count
+		groups = append(groups, newRopeInner(slices.Clone(nodes[from:to])))
+	}
+
+	return groups
+}
+
+// update sets the cached counts of an inner node from its children.
+func (n *ropeNode) update() {
+	n.bytes, n.runes, n.newlines = 0, 0, 0
+
+	for _, c := range n.children {
+		n.bytes += c.bytes
+		n.runes += c.runes
+		n.newlines += c.newlines
+	}
+}
+
+// insert inserts the text at the byte offset in the subtree. Returns nil if
+// the node has been changed in place, else the nodes - of the same height -
+// replacing the node.
+func (n *ropeNode) insert(offset int, text []byte) []*ropeNode {
+	if n.children == nil {
+		chunk := make([]byte, 0, len(n.chunk)+len(text))
+		chunk = append(chunk, n.chunk[:offset]...)
+		chunk = append(chunk, text...)
+		chunk = append(chunk, n.chunk[offset:]...)
+
+		if len(chunk) > ropeChunkSize {
+			return ropeLeaves(chunk)
+		}
+
+		*n = *newRopeLeaf(chunk)
+
+		return nil
+	}
+
+	idx := 0
+	for ; idx < len(n.children)-1 && offset > n.children[idx].bytes; idx++ {
+		offset -= n.children[idx].bytes
+	}
+
+	if nodes := n.children[idx].insert(offset, text); nodes != nil {
+		n.children = slices.Replace(n.children, idx, idx+1, nodes...)
+	}
+
+	if len(n.children) > ropeMaxChildren {
+		return ropeGroup(n.children)
+	}
+
+	n.update()
+
+	return nil
+}
+
+// delete deletes the text between the byte offsets `from` (inclusive) and `to`
+// (exclusive) of the subtree. Empty children are removed, small neighbors are
+// merged.
+func (n *ropeNode) delete(from int, to int) {
+	if n.children == nil {
+		n.chunk = append(n.chunk[:from], n.chunk[to:]...)
+		*n = *newRopeLeaf(n.chunk)
+
+		return
+	}
+
+	children := n.children[:0]
+	start := 0
+
+	for _, c := range n.children {
+		end := start + c.bytes
+
+		if from < end && to > start {
+			c.delete(max(from, start)-start, min(to, end)-start)
+		}
+
+		start = end
+
+		if c.bytes > 0 {
+			children = append(children, c)
+		}
+	}
+
+	n.children = ropeMerge(children)
+	n.update()
+}
+
+// ropeMerge merges neighboring nodes, if the merged node is not too big.
+func ropeMerge(nodes []*ropeNode) []*ropeNode {
+	merged := nodes[:0]
+
+	for _, c := range nodes {
+		if len(merged) == 0 {
+			merged = append(merged, c)
+
+			continue
+		}
+
+		prev := merged[len(merged)-1]
+
+		switch {
+		case c.children == nil && prev.bytes+c.bytes <= ropeChunkSize:
+			*prev = *newRopeLeaf(append(prev.chunk, c.chunk...))
+		case c.children != nil && len(prev.children)+len(c.children) <= ropeMaxChildren:
+			prev.children = append(prev.children, c.children...)
+			prev.update()
+		default:
+			merged = append(merged, c)
+		}
+	}
+
+	return merged
+}
+
+// each calls `f` with the parts of the chunks of the subtree between the byte
+// offsets `from` (inclusive) and `to` (exclusive), in order.
+func (n *ropeNode) each(from int, to int, f func(b []byte)) {
+	if n.children == nil {
+		f(n.chunk[from:to])
+
+		return
+	}
+
+	start := 0
+
+	for _, c := range n.children {
+		end := start + c.bytes
+
+		if from < end && to > start {
+			c.each(max(from, start)-start, min(to, end)-start, f)
+		}
+
+		if end >= to {
+			return
+		}
+
+		start = end
+	}
+}
+
+// newlinesBefore returns the number of newline characters before the byte
+// offset, which is the index of the line containing the offset.
+func (n *ropeNode) newlinesBefore(offset int) int {
+	count := 0
+
+	for n.children != nil {
+		idx := 0
+		for ; idx < len(n.children)-1 && offset >= n.children[idx].bytes; idx++ {
+			offset -= n.children[idx].bytes
+			count += n.children[idx].newlines
+		}
+
+		n = n.children[idx]
+	}
+
+	return count + bytes.Count(n.chunk[:offset], []byte{'\n'})
+}
+
+// runesBefore returns the number of unicode runes before the byte offset.
+func (n *ropeNode) runesBefore(offset int) int {
+	count := 0
+
+	for n.children != nil {
+		idx := 0
+		for ; idx < len(n.children)-1 && offset >= n.children[idx].bytes; idx++ {
+			offset -= n.children[idx].bytes
+			count += n.children[idx].runes
+		}
+
+		n = n.children[idx]
+	}
+
+	return count + utf8.RuneCount(n.chunk[:offset])
+}
+
+// runeOffset returns the byte offset of the rune with the given index, the
+// length of the text if the index is too big.
+func (n *ropeNode) runeOffset(runes int) int {
+	offset := 0
+
+	for n.children != nil {
+		idx := 0
+		for ; idx < len(n.children)-1 && runes >= n.children[idx].runes; idx++ {
+			runes -= n.children[idx].runes
+			offset += n.children[idx].bytes
+		}
+
+		n = n.children[idx]
+	}
+
+	pos := 0
+	for ; runes > 0 && pos < len(n.chunk); runes-- {
+		_, d := utf8.DecodeRune(n.chunk[pos:])
+		pos += d
+	}
+
+	return offset + pos
+}
+
+// lineStart returns the byte offset of the start of the line with the given
+// index, the offset after the `line`th newline character. The line must exist.
+func (n *ropeNode) lineStart(line int) int {
+	offset := 0
+
+	for n.children != nil {
+		idx := 0
+		for ; idx < len(n.children)-1 && line > n.children[idx].newlines; idx++ {
+			line -= n.children[idx].newlines
+			offset += n.children[idx].bytes
+		}
+
+		n = n.children[idx]
+	}
+
+	pos := 0
+	for ; line > 0; line-- {
+		pos += bytes.IndexByte(n.chunk[pos:], '\n') + 1
+	}
+
+	return offset + pos
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     rope_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRope(t *testing.T) {
+	t.Parallel()
+
+	line := "The quick brown fox jumps over the lazy dog. äöü 😀\n"
+	rope := gapbuffer.NewRope(strings.Repeat(line, 1_000))
+
+	assert.Equal(t, 1_001, rope.LineCount())
+	assert.Equal(t, 1_001, rope.Line())
+
+	rope.MoveTo(500*len(line) + 10)
+	rope.Insert("very ")
+	assert.Equal(t, 501, rope.Line())
+	assert.Equal(t, 15, rope.Col())
+	assert.Equal(t, "The quick very brown fox", rope.String()[500*len(line):500*len(line)+24])
+
+	rope.DownMv()
+	assert.Equal(t, 502, rope.Line())
+	assert.Equal(t, 15, rope.RuneCol())
+
+	rope.DeleteRange(len(line), 999*len(line)+len("very "))
+	assert.Equal(t, 3, rope.LineCount())
+	assert.Equal(t, strings.Repeat(line, 2), rope.String())
+	assert.Equal(t, 2, rope.Line())
+
+	rope.ReplaceRange(0, rope.StringLength(), "")
+	assert.Equal(t, "", rope.String())
+	assert.Equal(t, 1, rope.LineCount())
+	assert.Equal(t, 0, rope.LineLength())
+}
+
+func TestRopeRandom(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(73)) //nolint:gosec // test
+
+	for i := 0; i < 20; i++ {
+		text := strings.Repeat(strings.Join(randomLines(rnd), ""), 1+rnd.Intn(50))
+		gb, rope := gapbuffer.NewStr(text), gapbuffer.NewRope(text)
+		requireSameState(t, gb, rope)
+
+		for j := 0; j < 200; j++ {
+			if rnd.Intn(20) == 0 {
+				big := strings.Repeat("big ä\n", rnd.Intn(1_000))
+				gb.Insert(big)
+				rope.Insert(big)
+			}
+
+			randomEdit(rnd, gb, rope)
+			requireSameState(t, gb, rope)
+		}
+	}
+}
@@ -0,0 +1,393 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     rune-gap-buffer.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// RuneGapBuffer is a gap buffer of unicode runes instead of bytes, all offsets,
+// columns and lengths are in runes. The rune offset and rune column of the
+// cursor are constant time, as is moving the cursor inside the current line
+// and up and down a line. Moving the cursor to an offset in another line is
+// linear in the number of runes moved over, like [GapBuffer.MoveTo].
+//
+// A rune takes 4 bytes, so a RuneGapBuffer needs up to 4 times the memory of
+// a [GapBuffer] for the same text.
+//
+// Every byte of invalid UTF-8 is a rune of its own, like in a [GapBuffer], and
+// is kept exactly: [RuneGapBuffer.String] returns the same bytes and converting
+// from and to a [GapBuffer] does not change the text. Invalid bytes stay
+// separate runes even if inserted text completes them to a valid rune.
+//
+// See also [NewRuneStr], [NewRuneGapBuffer], [RuneGapBuffer.GapBuffer].
+type RuneGapBuffer struct {
+	// The text, the gap is at the position of the cursor after the last change
+	// of the text.
+	text Gap[rune]
+
+	// The position of the cursor, the rune offset in the text.
+	cursor int
+
+	// `wantsCol` is the rune column the cursor wants to hold when going up or
+	// down.
+	wantsCol int
+
+	// The line lengths in runes, including the newline character.
+	//
+	// See [lineBuffer].
+	lines lineBuffer
+}
+
+// Construct a new RuneGapBuffer from a string. The cursor position is set to
+// the end of the string.
+//
+// See also [NewRuneGapBuffer].
+func NewRuneStr(str string) *RuneGapBuffer {
+	runes := decodeRunes(str)
+	count := len(runes)
+	size := max(defaultCapacity, count*growFactor)
+	data := make([]rune, size)
+	copy(data, runes)
+
+	lines := newLineBuf(size)
+	lines.insertLengths(runeLineLengths(data[:count]), 0)
+
+	return &RuneGapBuffer{
+		text:     Gap[rune]{start: count, end: size, data: data},
+		cursor:   count,
+		wantsCol: count - lines.curLineStart(),
+		lines:    *lines,
+	}
+}
+
+// Construct a new RuneGapBuffer with the text of the given gap buffer, with the
+// cursor at the same position.
+//
+// See also [NewRuneStr], [RuneGapBuffer.GapBuffer].
+func NewRuneGapBuffer(g *GapBuffer) *RuneGapBuffer {
+	r := NewRuneStr(g.String())
+	r.MoveToRune(g.runeCount(0, g.cursor))
+
+	return r
+}
+
+// Return a new [GapBuffer] with the text of the rune gap buffer, with the
+// cursor at the same position.
+//
+// See also [NewRuneGapBuffer].
+func (r *RuneGapBuffer) GapBuffer() *GapBuffer {
+	left, right := r.StringPair()
+	g := NewStr(left + right)
+	g.MoveTo(len(left))
+
+	return g
+}
+
+// Return the contents of the rune gap buffer as a string.
+func (r *RuneGapBuffer) String() string {
+	return r.slice(0, r.text.Len())
+}
+
+// Return the contents of the rune gap buffer as two strings, the part to the
+// left of the cursor in `left` and the part to the right of the cursor in
+// `right`.
+func (r *RuneGapBuffer) StringPair() (left string, right string) {
+	return r.slice(0, r.cursor), r.slice(r.cursor, r.text.Len())
+}
+
+// Return the length of the text in runes.
+func (r *RuneGapBuffer) RuneLength() int {
+	return r.text.Len()
+}
+
+// Return the rune offset of the cursor from the start of the text.
+//
+// Numbering starts from 0.
+//
+// See also [RuneGapBuffer.MoveToRune], [RuneGapBuffer.RuneCol].
+func (r *RuneGapBuffer) RuneOffset() int {
+	return r.cursor
+}
+
+// Return the rune column of the cursor, the number of unicode runes from the
+// start of the line to the cursor.
+//
+// See also [GapBuffer.RuneCol].
+func (r *RuneGapBuffer) RuneCol() int {
+	return r.cursor - r.lines.curLineStart()
+}
+
+// Return the line number of the current line the cursor is in.
+//
+// Numbering starts from 1.
+func (r *RuneGapBuffer) Line() int {
+	return r.lines.curLine()
+}
+
+// Return the line and rune column of the cursor.
+//
+// See also [RuneGapBuffer.Line], [RuneGapBuffer.RuneCol].
+func (r *RuneGapBuffer) LineRuneCol() (line int, runeCol int) {
+	return r.lines.curLine(), r.RuneCol()
+}
+
+// Return the length of the current line in runes, without the newline
+// character at the end of the line.
+func (r *RuneGapBuffer) LineLength() int {
+	if r.lines.isLastLine() {
+		return r.lines.curLineLength()
+	}
+
+	return r.lines.curLineLength() - 1
+}
+
+// Return the number of lines in the rune gap buffer, see
+// [GapBuffer.LineCount].
+func (r *RuneGapBuffer) LineCount() int {
+	return r.lines.lineCount()
+}
+
+// Delete the unicode rune to the left of the cursor, see [GapBuffer.LeftDel].
+func (r *RuneGapBuffer) LeftDel() {
+	if r.cursor < 1 {
+		return
+	}
+
+	r.cursor--
+	ch := r.text.At(r.cursor)
+	r.text.Delete(r.cursor, r.cursor+1)
+
+	if ch == '\n' {
+		r.lines.upDel()
+	} else {
+		r.lines.del(1)
+	}
+
+	r.wantsCol = r.RuneCol()
+}
+
+// Delete the unicode rune to the right of the cursor, see
+// [GapBuffer.RightDel].
+func (r *RuneGapBuffer) RightDel() {
+	if r.cursor >= r.text.Len() {
+		return
+	}
+
+	ch := r.text.At(r.cursor)
+	r.text.Delete(r.cursor, r.cursor+1)
+
+	if ch == '\n' {
+		r.lines.downDel()
+	} else {
+		r.lines.del(1)
+	}
+}
+
+// Move the cursor one unicode rune to the left, see [GapBuffer.LeftMv].
+func (r *RuneGapBuffer) LeftMv() {
+	if r.cursor < 1 {
+		return
+	}
+
+	r.cursor--
+
+	if r.text.At(r.cursor) == '\n' {
+		r.lines.up()
+	}
+
+	r.wantsCol = r.RuneCol()
+}
+
+// Move the cursor one unicode rune to the right, see [GapBuffer.RightMv].
+func (r *RuneGapBuffer) RightMv() {
+	if r.cursor >= r.text.Len() {
+		return
+	}
+
+	if r.text.At(r.cursor) == '\n' {
+		r.lines.down()
+	}
+
+	r.cursor++
+	r.wantsCol = r.RuneCol()
+}
+
+// Move the cursor up one line, see [GapBuffer.UpMv].
+func (r *RuneGapBuffer) UpMv() {
+	if r.lines.curLine() == 1 {
+		return
+	}
+
+	r.lines.up()
+	r.cursor = r.wantedOffset()
+}
+
+// Move the cursor down one line, see [GapBuffer.DownMv].
+func (r *RuneGapBuffer) DownMv() {
+	if r.lines.isLastLine() {
+		return
+	}
+
+	r.lines.down()
+	r.cursor = r.wantedOffset()
+}
+
+// Insert the given string at the cursor and move the cursor to the end of the
+// inserted text, see [GapBuffer.Insert].
+func (r *RuneGapBuffer) Insert(str string) {
+	if str != "" {
+		runes := decodeRunes(str)
+		r.lines.insertLengths(runeLineLengths(runes), r.cursor)
+		r.text.Insert(r.cursor, runes...)
+		r.cursor += len(runes)
+	}
+
+	r.wantsCol = r.RuneCol()
+}
+
+// Move the cursor to the given rune offset from the start of the text. Offsets
+// are clamped to the text.
+//
+// See also [RuneGapBuffer.RuneOffset], [GapBuffer.MoveTo].
+func (r *RuneGapBuffer) MoveToRune(offset int) {
+	r.setCursor(r.clamp(offset))
+	r.wantsCol = r.RuneCol()
+}
+
+// Delete the text between the rune offsets `from` (inclusive) and `to`
+// (exclusive) and move the cursor to `from`, see [GapBuffer.DeleteRange].
+func (r *RuneGapBuffer) DeleteRange(from int, to int) {
+	from, to = r.clamp(from), r.clamp(to)
+	if to < from {
+		from, to = to, from
+	}
+
+	r.setCursor(from)
+
+	if to > from {
+		r.lines.delRight(to-from, r.countNewlines(from, to))
+		r.text.Delete(from, to)
+	}
+
+	r.wantsCol = r.RuneCol()
+}
+
+// Replace the text between the rune offsets `from` (inclusive) and `to`
+// (exclusive) with the given string, see [GapBuffer.ReplaceRange].
+func (r *RuneGapBuffer) ReplaceRange(from int, to int, str string) {
+	r.DeleteRange(from, to)
+	r.Insert(str)
+}
+
+// wantedOffset returns the offset of the rune column `wantsCol` in the current
+// line, or the end of the line, if the line is shorter.
+func (r *RuneGapBuffer) wantedOffset() int {
+	return r.lines.curLineStart() + min(r.wantsCol, r.LineLength())
+}
+
+// clamp returns the given offset clamped to the range of valid offsets,
+// 0 to [RuneGapBuffer.RuneLength].
+func (r *RuneGapBuffer) clamp(offset int) int {
+	return min(max(offset, 0), r.text.Len())
+}
+
+// setCursor moves the cursor to the given rune offset in the text. The offset
+// must be valid.
+func (r *RuneGapBuffer) setCursor(offset int) {
+	switch {
+	case offset < r.cursor:
+		for nl := r.countNewlines(offset, r.cursor); nl > 0; nl-- {
+			r.lines.up()
+		}
+
+	case offset > r.cursor:
+		for nl := r.countNewlines(r.cursor, offset); nl > 0; nl-- {
+			r.lines.down()
+		}
+	}
+
+	r.cursor = offset
+}
+
+// countNewlines returns the number of newline characters between the rune
+// offsets `from` (inclusive) and `to` (exclusive). The offsets must be valid.
+func (r *RuneGapBuffer) countNewlines(from int, to int) int {
+	count := 0
+
+	r.text.Each(from, to, func(_ int, ch rune) bool {
+		if ch == '\n' {
+			count++
+		}
+
+		return true
+	})
+
+	return count
+}
+
+// slice returns the text between the rune offsets `from` (inclusive) and `to`
+// (exclusive) as a string. The offsets must be valid.
+func (r *RuneGapBuffer) slice(from int, to int) string {
+	var builder strings.Builder
+
+	builder.Grow(to - from)
+	r.text.Each(from, to, func(_ int, ch rune) bool {
+		if ch >= invalidByteRune && ch <= invalidByteRune+0xff {
+			builder.WriteByte(byte(ch - invalidByteRune))
+		} else {
+			builder.WriteRune(ch)
+		}
+
+		return true
+	})
+
+	return builder.String()
+}
+
+// invalidByteRune is the rune a byte of invalid UTF-8 is stored as in a
+// [RuneGapBuffer], plus the value of the byte. These are low surrogates, which
+// are never the result of decoding UTF-8.
+const invalidByteRune = 0xdc00
+
+// decodeRunes returns the runes of the string, every byte of invalid UTF-8 is
+// returned as [invalidByteRune] plus the byte, see [RuneGapBuffer.slice].
+func decodeRunes(str string) []rune {
+	runes := make([]rune, 0, utf8.RuneCountInString(str))
+
+	for idx := 0; idx < len(str); {
+		ch, size := utf8.DecodeRuneInString(str[idx:])
+		if ch == utf8.RuneError && size == 1 {
+			ch = invalidByteRune + rune(str[idx])
+		}
+
+		runes = append(runes, ch)
+		idx += size
+	}
+
+	return runes
+}
+
+// runeLineLengths returns the lengths of the lines in runes in the given
+// runes, like [lineLengths].
+func runeLineLengths(runes []rune) []int {
+	lens := []int{0}
+
+	for _, ch := range runes {
+		lens[len(lens)-1]++
+
+		if ch == '\n' {
+			lens = append(lens, 0)
+		}
+	}
+
+	return lens
+}
@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     rune-gap-buffer_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+)
+
+// byteRuneGapBuffer implements [gapbuffer.Buffer] with byte offsets using a
+// [gapbuffer.RuneGapBuffer], to compare it with the other buffers.
+type byteRuneGapBuffer struct {
+	*gapbuffer.RuneGapBuffer
+}
+
+func (b byteRuneGapBuffer) StringLength() int { return len(b.String()) }
+
+func (b byteRuneGapBuffer) Offset() int {
+	left, _ := b.StringPair()
+
+	return len(left)
+}
+
+func (b byteRuneGapBuffer) Col() int {
+	left, _ := b.StringPair()
+
+	return len(left) - strings.LastIndexByte(left, '\n') - 1
+}
+
+func (b byteRuneGapBuffer) LineLength() int {
+	left, right := b.StringPair()
+	left = left[strings.LastIndexByte(left, '\n')+1:]
+	right, _, _ = strings.Cut(right, "\n")
+
+	return len(left) + len(right)
+}
+
+func (b byteRuneGapBuffer) LineCol() (line int, col int) { return b.Line(), b.Col() }
+
+func (b byteRuneGapBuffer) MoveTo(offset int) { b.MoveToRune(b.runeOffset(offset)) }
+
+func (b byteRuneGapBuffer) DeleteRange(from int, to int) {
+	b.RuneGapBuffer.DeleteRange(b.runeOffset(from), b.runeOffset(to))
+}
+
+func (b byteRuneGapBuffer) ReplaceRange(from int, to int, str string) {
+	b.RuneGapBuffer.ReplaceRange(b.runeOffset(from), b.runeOffset(to), str)
+}
+
+func (b byteRuneGapBuffer) runeOffset(offset int) int {
+	text := b.String()
+
+	return utf8.RuneCountInString(text[:min(max(offset, 0), len(text))])
+}
+
+func TestRuneGapBuffer(t *testing.T) {
+	t.Parallel()
+
+	rb := gapbuffer.NewRuneStr("Grüße\nаз😀\n")
+	assert.Equal(t, 10, rb.RuneLength())
+	assert.Equal(t, 10, rb.RuneOffset())
+	assert.Equal(t, 3, rb.LineCount())
+
+	rb.UpMv()
+	assert.Equal(t, 6, rb.RuneOffset())
+	rb.RightMv()
+	rb.RightMv()
+	rb.Insert("ы")
+	line, col := rb.LineRuneCol()
+	assert.Equal(t, []int{2, 3}, []int{line, col})
+	assert.Equal(t, 4, rb.LineLength())
+
+	rb.UpMv()
+	assert.Equal(t, 3, rb.RuneCol())
+	rb.MoveToRune(4)
+	rb.LeftDel()
+	rb.RightDel()
+	assert.Equal(t, "Grü\nазы😀\n", rb.String())
+
+	rb.DeleteRange(3, 4)
+	rb.ReplaceRange(0, 1, "g")
+	left, right := rb.StringPair()
+	assert.Equal(t, []string{"g", "rüазы😀\n"}, []string{left, right})
+}
+
+func TestRuneGapBufferConversion(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("äöü\n😀 smile")
+	gb.MoveTo(len("äöü\n😀"))
+
+	rb := gapbuffer.NewRuneGapBuffer(gb)
+	assert.Equal(t, 5, rb.RuneOffset())
+	assert.Equal(t, 2, rb.Line())
+
+	rb.Insert("!")
+	gb = rb.GapBuffer()
+	assert.Equal(t, "äöü\n😀! smile", gb.String())
+	assert.Equal(t, len("äöü\n😀!"), gb.Offset())
+}
+
+func TestRuneGapBufferInvalidUTF8(t *testing.T) {
+	t.Parallel()
+
+	text := "a\xff\xe2\x82\n\xed\xb0\x80\xef\xbf\xbd"
+	gb := gapbuffer.NewStr(text)
+	gb.MoveTo(3)
+
+	rb := gapbuffer.NewRuneGapBuffer(gb)
+	assert.Equal(t, text, rb.String())
+	assert.Equal(t, 3, rb.RuneOffset(), "Every invalid byte is a rune")
+	assert.Equal(t, 9, rb.RuneLength())
+
+	rb.RightDel()
+	rb.Insert("\xfe")
+	assert.Equal(t, "a\xff\xe2\xfe\n\xed\xb0\x80\xef\xbf\xbd", rb.String())
+
+	back := rb.GapBuffer()
+	assert.Equal(t, rb.String(), back.String(), "Round trip")
+	assert.Equal(t, 4, back.Offset())
+	assert.Equal(t, text, gapbuffer.NewRuneStr(text).GapBuffer().String())
+}
+
+func TestRuneGapBufferRandom(t *testing.T) {
+	t.Parallel()
+
+	rnd := rand.New(rand.NewSource(75)) //nolint:gosec // test
+
+	for i := 0; i < 100; i++ {
+		text := strings.Join(randomLines(rnd), "ä")
+		gb, rb := gapbuffer.NewStr(text), byteRuneGapBuffer{gapbuffer.NewRuneStr(text)}
+		requireSameState(t, gb, rb)
+
+		for j := 0; j < 100; j++ {
+			randomEdit(rnd, gb, rb)
+			requireSameState(t, gb, rb)
+		}
+	}
+}
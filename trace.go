@@ -0,0 +1,410 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     trace.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// TraceOpKind is the kind of a [TraceOp], the name of the operation in a trace
+// file.
+type TraceOpKind string
+
+const (
+	// Insert `Text` at the cursor, [GapBuffer.Insert].
+	TraceInsert TraceOpKind = "i"
+
+	// Move the cursor `Count` runes to the left, [GapBuffer.LeftMv].
+	TraceLeftMv TraceOpKind = "l"
+
+	// Move the cursor `Count` runes to the right, [GapBuffer.RightMv].
+	TraceRightMv TraceOpKind = "r"
+
+	// Move the cursor `Count` lines up, [GapBuffer.UpMv].
+	TraceUpMv TraceOpKind = "u"
+
+	// Move the cursor `Count` lines down, [GapBuffer.DownMv].
+	TraceDownMv TraceOpKind = "d"
+
+	// Delete `Count` runes to the left of the cursor, [GapBuffer.LeftDel].
+	TraceLeftDel TraceOpKind = "b"
+
+	// Delete `Count` runes to the right of the cursor, [GapBuffer.RightDel].
+	TraceRightDel TraceOpKind = "x"
+
+	// Move the cursor to the byte offset `From`, [GapBuffer.MoveTo].
+	TraceMoveTo TraceOpKind = "m"
+
+	// Delete the text from `From` to `To`, [GapBuffer.DeleteRange].
+	TraceDeleteRange TraceOpKind = "D"
+
+	// Replace the text from `From` to `To` by `Text`,
+	// [GapBuffer.ReplaceRange].
+	TraceReplaceRange TraceOpKind = "R"
+)
+
+// The first line of a trace file written by [Trace.WriteTo].
+const traceHeader = "# go-gap-buffer trace v1"
+
+// ErrInvalidTrace is returned by [ParseTrace] if a line of a trace file is not
+// a valid operation.
+var ErrInvalidTrace = errors.New("invalid trace")
+
+// TraceOp is a single operation of a [Trace].
+type TraceOp struct {
+	// The operation.
+	Kind TraceOpKind
+
+	// How often to repeat a movement or deletion of a rune or line, at least
+	// 1.
+	Count int
+
+	// The byte offsets of [TraceMoveTo], [TraceDeleteRange] and
+	// [TraceReplaceRange].
+	From int
+	To   int
+
+	// The text of [TraceInsert] and [TraceReplaceRange].
+	Text string
+}
+
+// Trace is a recorded or generated editing session, the text at the start and
+// the operations applied to it, to replay it in benchmarks. The cursor is at
+// the start of the text before the first operation.
+//
+// A trace file is a text file with one operation per line. Empty lines and
+// lines starting with '#' are ignored. Strings are quoted like Go strings:
+//
+//	# go-gap-buffer trace v1
+//	text "line\n" 1000    the text at the start, "line\n" repeated 1000 times
+//	i "Hello"             insert "Hello"
+//	l 3                   3 runes to the left, "r" to the right
+//	u 2                   2 lines up, "d" down
+//	b                     delete 1 rune to the left, "x" to the right
+//	m 1234                move the cursor to the byte offset 1234
+//	D 10 20               delete the bytes from offset 10 to 20
+//	R 10 20 "new"         replace the bytes from offset 10 to 20 by "new"
+//
+// The count after "text", "l", "r", "u", "d", "b" and "x" is optional and
+// defaults to 1, all "text" lines are concatenated and must come before the
+// operations.
+//
+// See also [ParseTrace], [RecordTrace], [Trace.Replay].
+type Trace struct {
+	// The text at the start.
+	Text string
+
+	// The operations, in order.
+	Ops []TraceOp
+}
+
+// Return the trace read from a trace file, see [Trace] for the format. Returns
+// an error wrapping [ErrInvalidTrace] if a line can't be parsed.
+//
+// See also [Trace.WriteTo].
+func ParseTrace(r io.Reader) (*Trace, error) {
+	var text strings.Builder
+
+	trace := &Trace{Text: "", Ops: nil}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxInt)
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		kind, args, _ := strings.Cut(line, " ")
+
+		op, err := parseTraceOp(TraceOpKind(kind), strings.TrimSpace(args))
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidTrace, lineNum, err)
+		}
+
+		if kind == "text" {
+			if len(trace.Ops) > 0 {
+				return nil, fmt.Errorf("%w: line %d: text after the operations", ErrInvalidTrace, lineNum)
+			}
+
+			text.WriteString(strings.Repeat(op.Text, op.Count))
+
+			continue
+		}
+
+		trace.Ops = append(trace.Ops, op)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	trace.Text = text.String()
+
+	return trace, nil
+}
+
+// WriteTo writes the trace as a trace file to `w`, see [Trace] for the format.
+// Returns the number of bytes written.
+//
+// See also [ParseTrace].
+func (t *Trace) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	counter := &countingWriter{w: bw, n: 0, err: nil}
+
+	fmt.Fprintln(counter, traceHeader)
+
+	if t.Text != "" {
+		fmt.Fprintf(counter, "text %s\n", strconv.Quote(t.Text))
+	}
+
+	for _, op := range t.Ops {
+		switch op.Kind {
+		case TraceInsert:
+			fmt.Fprintf(counter, "%s %s\n", op.Kind, strconv.Quote(op.Text))
+		case TraceMoveTo:
+			fmt.Fprintf(counter, "%s %d\n", op.Kind, op.From)
+		case TraceDeleteRange:
+			fmt.Fprintf(counter, "%s %d %d\n", op.Kind, op.From, op.To)
+		case TraceReplaceRange:
+			fmt.Fprintf(counter, "%s %d %d %s\n", op.Kind, op.From, op.To, strconv.Quote(op.Text))
+		default:
+			fmt.Fprintf(counter, "%s %d\n", op.Kind, max(op.Count, 1))
+		}
+	}
+
+	if counter.err != nil {
+		return counter.n, counter.err
+	}
+
+	return counter.n, bw.Flush()
+}
+
+// Return a new gap buffer with the text of the trace and the cursor at the
+// start of the text, to replay the trace.
+//
+// See also [Trace.Replay].
+func (t *Trace) NewGapBuffer() *GapBuffer {
+	g := NewStr(t.Text)
+	g.MoveTo(0)
+
+	return g
+}
+
+// Replay applies the operations of the trace to the buffer, which should
+// contain the text of the trace with the cursor at the start, see
+// [Trace.NewGapBuffer].
+func (t *Trace) Replay(buf Buffer) {
+	for _, op := range t.Ops {
+		switch op.Kind {
+		case TraceInsert:
+			buf.Insert(op.Text)
+		case TraceMoveTo:
+			buf.MoveTo(op.From)
+		case TraceDeleteRange:
+			buf.DeleteRange(op.From, op.To)
+		case TraceReplaceRange:
+			buf.ReplaceRange(op.From, op.To, op.Text)
+		default:
+			if f := traceMoveFunc(buf, op.Kind); f != nil {
+				for n := max(op.Count, 1); n > 0; n-- {
+					f()
+				}
+			}
+		}
+	}
+}
+
+// TraceRecorder records the changes of the text of a gap buffer as a [Trace],
+// see [RecordTrace].
+type TraceRecorder struct {
+	// The recorded trace.
+	trace Trace
+
+	// The offset of the cursor after the last recorded operation, -1 if it is
+	// unknown.
+	cursor int
+
+	// Removes the [EditFunc] of the recorder from the gap buffer.
+	remove func()
+}
+
+// RecordTrace starts recording the changes of the text of the gap buffer as a
+// trace, starting with the current text and cursor. Every change is recorded
+// as [TraceInsert] or [TraceDeleteRange], preceded by a [TraceMoveTo] if the
+// cursor has been moved. Movements of the cursor are not changes of the text
+// and are not recorded, add them with [TraceRecorder.Add] to replay them.
+//
+// See also [TraceRecorder.Stop], [Trace.WriteTo].
+func RecordTrace(g *GapBuffer) *TraceRecorder {
+	rec := &TraceRecorder{
+		trace:  Trace{Text: g.String(), Ops: nil},
+		cursor: 0,
+		remove: nil,
+	}
+	rec.Add(TraceOp{Kind: TraceMoveTo, Count: 1, From: g.Offset(), To: 0, Text: ""})
+	rec.cursor = g.Offset()
+	rec.remove = g.AddEditFunc(rec.record)
+
+	return rec
+}
+
+// Add adds the operation to the trace, for example a movement of the cursor.
+// The operation is not applied to the gap buffer.
+func (r *TraceRecorder) Add(op TraceOp) {
+	r.trace.Ops = append(r.trace.Ops, op)
+	r.cursor = -1
+}
+
+// Stop stops recording and returns the recorded trace.
+func (r *TraceRecorder) Stop() *Trace {
+	if r.remove != nil {
+		r.remove()
+		r.remove = nil
+	}
+
+	return &r.trace
+}
+
+// record is the [EditFunc] of the recorder.
+func (r *TraceRecorder) record(e Edit) {
+	if e.Deleted != "" {
+		r.trace.Ops = append(r.trace.Ops, TraceOp{
+			Kind: TraceDeleteRange, Count: 1, From: e.Offset, To: e.Offset + len(e.Deleted), Text: "",
+		})
+		r.cursor = e.Offset
+
+		return
+	}
+
+	if e.Offset != r.cursor {
+		r.trace.Ops = append(r.trace.Ops, TraceOp{Kind: TraceMoveTo, Count: 1, From: e.Offset, To: 0, Text: ""})
+	}
+
+	r.trace.Ops = append(r.trace.Ops, TraceOp{Kind: TraceInsert, Count: 1, From: 0, To: 0, Text: e.Inserted})
+	r.cursor = e.Offset + len(e.Inserted)
+}
+
+// parseTraceOp parses the arguments of an operation or of the "text" line.
+func parseTraceOp(kind TraceOpKind, args string) (TraceOp, error) {
+	op := TraceOp{Kind: kind, Count: 1, From: 0, To: 0, Text: ""}
+
+	var err error
+
+	switch kind {
+	case "text", TraceInsert:
+		op.Text, args, err = parseTraceString(args)
+		if kind == "text" && err == nil && args != "" {
+			op.Count, err = parseTraceInt(args)
+			args = ""
+		}
+
+	case TraceMoveTo:
+		op.From, err = parseTraceInt(args)
+		args = ""
+
+	case TraceDeleteRange, TraceReplaceRange:
+		from, rest, _ := strings.Cut(args, " ")
+		to, rest, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		args = strings.TrimSpace(rest)
+
+		if op.From, err = parseTraceInt(from); err == nil {
+			op.To, err = parseTraceInt(to)
+		}
+
+		if kind == TraceReplaceRange && err == nil {
+			op.Text, args, err = parseTraceString(args)
+		}
+
+	case TraceLeftMv, TraceRightMv, TraceUpMv, TraceDownMv, TraceLeftDel, TraceRightDel:
+		if args != "" {
+			op.Count, err = parseTraceInt(args)
+			args = ""
+		}
+
+	default:
+		return op, fmt.Errorf("unknown operation %q", kind)
+	}
+
+	if err == nil && args != "" {
+		err = fmt.Errorf("unexpected %q", args)
+	}
+
+	return op, err
+}
+
+// parseTraceString parses the quoted string at the start of `args` and
+// returns it and the rest of `args`.
+func parseTraceString(args string) (str string, rest string, err error) {
+	quoted, err := strconv.QuotedPrefix(args)
+	if err != nil {
+		return "", "", err
+	}
+
+	str, err = strconv.Unquote(quoted)
+
+	return str, strings.TrimSpace(args[len(quoted):]), err
+}
+
+// parseTraceInt parses a non-negative integer.
+func parseTraceInt(arg string) (int, error) {
+	n, err := strconv.Atoi(arg)
+	if err == nil && n < 0 {
+		err = fmt.Errorf("negative number %d", n)
+	}
+
+	return n, err
+}
+
+// traceMoveFunc returns the method of the buffer for the movement or deletion
+// of a rune or a line, nil if the operation is not one of them.
+func traceMoveFunc(buf Buffer, kind TraceOpKind) func() {
+	switch kind {
+	case TraceLeftMv:
+		return buf.LeftMv
+	case TraceRightMv:
+		return buf.RightMv
+	case TraceUpMv:
+		return buf.UpMv
+	case TraceDownMv:
+		return buf.DownMv
+	case TraceLeftDel:
+		return buf.LeftDel
+	case TraceRightDel:
+		return buf.RightDel
+	default:
+		return nil
+	}
+}
+
+// countingWriter counts the bytes written and remembers the first error.
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+// Write writes to the underlying writer, if there hasn't been an error yet.
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+
+	return n, err
+}
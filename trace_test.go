@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     trace_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleTrace = `# go-gap-buffer trace v1
+text "ab\n" 2
+text "c"
+
+i "x\ty"
+l 3
+r
+u
+d 2
+b
+x 4
+m 12
+D 1 3
+R 0 1 "新\n"
+`
+
+func TestParseTrace(t *testing.T) {
+	t.Parallel()
+
+	trace, err := gapbuffer.ParseTrace(strings.NewReader(sampleTrace))
+	require.NoError(t, err)
+	assert.Equal(t, "ab\nab\nc", trace.Text)
+	assert.Equal(t, []gapbuffer.TraceOp{
+		{Kind: gapbuffer.TraceInsert, Count: 1, From: 0, To: 0, Text: "x\ty"},
+		{Kind: gapbuffer.TraceLeftMv, Count: 3, From: 0, To: 0, Text: ""},
+		{Kind: gapbuffer.TraceRightMv, Count: 1, From: 0, To: 0, Text: ""},
+		{Kind: gapbuffer.TraceUpMv, Count: 1, From: 0, To: 0, Text: ""},
+		{Kind: gapbuffer.TraceDownMv, Count: 2, From: 0, To: 0, Text: ""},
+		{Kind: gapbuffer.TraceLeftDel, Count: 1, From: 0, To: 0, Text: ""},
+		{Kind: gapbuffer.TraceRightDel, Count: 4, From: 0, To: 0, Text: ""},
+		{Kind: gapbuffer.TraceMoveTo, Count: 1, From: 12, To: 0, Text: ""},
+		{Kind: gapbuffer.TraceDeleteRange, Count: 1, From: 1, To: 3, Text: ""},
+		{Kind: gapbuffer.TraceReplaceRange, Count: 1, From: 0, To: 1, Text: "新\n"},
+	}, trace.Ops)
+
+	var builder strings.Builder
+
+	n, err := trace.WriteTo(&builder)
+	require.NoError(t, err)
+	assert.Equal(t, int64(builder.Len()), n)
+
+	again, err := gapbuffer.ParseTrace(strings.NewReader(builder.String()))
+	require.NoError(t, err)
+	assert.Equal(t, trace, again)
+}
+
+func TestParseTraceErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		"z",
+		"i hello",
+		`i "a" "b"`,
+		"l x",
+		"u -1",
+		"m",
+		"D 1",
+		`R 1 2`,
+		`text "a" 2 3`,
+		"l\ntext \"a\"",
+	}
+
+	for _, tt := range tests {
+		_, err := gapbuffer.ParseTrace(strings.NewReader(tt))
+		require.ErrorIs(t, err, gapbuffer.ErrInvalidTrace, tt)
+	}
+}
+
+func TestTraceReplay(t *testing.T) {
+	t.Parallel()
+
+	trace, err := gapbuffer.ParseTrace(strings.NewReader(sampleTrace))
+	require.NoError(t, err)
+
+	for _, buffer := range buffers {
+		buffer := buffer
+		t.Run(buffer.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := buffer.newStr(trace.Text)
+			buf.MoveTo(0)
+			trace.Replay(buf)
+			assert.Equal(t, "新\nab\nab\n", buf.String())
+			assert.Equal(t, 4, buf.Offset())
+		})
+	}
+
+	gb := trace.NewGapBuffer()
+	assert.Equal(t, 0, gb.CopiedBytes())
+
+	trace.Replay(gb)
+	assert.Equal(t, "新\nab\nab\n", gb.String())
+	assert.Positive(t, gb.CopiedBytes())
+}
+
+func TestRecordTrace(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("Hello\nworld")
+	rec := gapbuffer.RecordTrace(gb)
+
+	gb.Insert("!")
+	gb.UpMv()
+	gb.LeftDel()
+	gb.Insert("p!")
+	gb.MoveTo(0)
+	rec.Add(gapbuffer.TraceOp{Kind: gapbuffer.TraceRightMv, Count: 2, From: 0, To: 0, Text: ""})
+	gb.RightMv()
+	gb.RightMv()
+	gb.Insert("\n")
+	gb.ReplaceRange(0, 2, "J")
+
+	trace := rec.Stop()
+	gb.Insert("not recorded")
+
+	var builder strings.Builder
+
+	_, err := trace.WriteTo(&builder)
+	require.NoError(t, err)
+
+	parsed, err := gapbuffer.ParseTrace(strings.NewReader(builder.String()))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello\nworld", parsed.Text)
+
+	replayed := parsed.NewGapBuffer()
+	parsed.Replay(replayed)
+	assert.Equal(t, "J\nllp!\nworld!", replayed.String())
+	assert.Equal(t, 1, replayed.Offset())
+}
+
+// Every trace in testdata/traces, see BenchmarkTraceFiles.
+func TestTraceFiles(t *testing.T) {
+	t.Parallel()
+
+	paths, err := filepath.Glob(filepath.Join("testdata", "traces", "*.trace"))
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		trace, err := gapbuffer.ParseTrace(strings.NewReader(string(data)))
+		require.NoError(t, err, path)
+
+		gb := trace.NewGapBuffer()
+		trace.Replay(gb)
+		require.NoError(t, gb.Validate(), path)
+	}
+}
@@ -0,0 +1,219 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     undofile.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+)
+
+const (
+	// The magic bytes at the start of an undo file.
+	undoMagic = "GBU"
+
+	// The version of the undo file format.
+	undoVersion byte = 1
+
+	// The size of the trailing checksum of an undo file.
+	undoCRCSize = 4
+
+	// The biggest int.
+	maxInt = int(^uint(0) >> 1)
+)
+
+var (
+	// ErrCorruptUndoFile is returned by [LoadUndoFile] if the file is not an
+	// undo file or has been damaged.
+	ErrCorruptUndoFile = errors.New("corrupt undo file")
+
+	// ErrUndoVersion is returned by [LoadUndoFile] if the undo file has been
+	// written by an unsupported version of the file format.
+	ErrUndoVersion = errors.New("unsupported undo file version")
+
+	// ErrUndoFileMismatch is returned by [LoadUndoFile] if the text of the gap
+	// buffer is not the text the undo file has been saved for, for example
+	// because the file has been changed by another program.
+	ErrUndoFileMismatch = errors.New("undo file does not match the text")
+)
+
+// SaveUndoFile writes the undo and redo history to the file at the given path,
+// like Vim's `undofile`. The undo file is keyed by the SHA-256 hash of the
+// current text of the gap buffer, so call this right after saving the text.
+//
+// File format, all integers are unsigned varints, strings are prefixed by
+// their length:
+//
+//	"GBU" | version (1 byte) | SHA-256 of the text (32 bytes)
+//	number of undo steps | steps... | number of redo steps | steps...
+//	CRC-32 (IEEE) of everything before, uint32 little endian
+//
+// A step is the number of edits followed by the edits, an edit is the offset,
+// the deleted and the inserted text.
+//
+// See also [LoadUndoFile].
+func (h *History) SaveUndoFile(path string) error {
+	sum := sha256.Sum256([]byte(h.buf.String()))
+	data := append([]byte(undoMagic), undoVersion)
+	data = append(data, sum[:]...)
+	data = appendSteps(data, h.undo)
+	data = appendSteps(data, h.redo)
+	data = binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+
+	return os.WriteFile(path, data, journalPerm)
+}
+
+// LoadUndoFile reads the undo history written by [History.SaveUndoFile] and
+// returns it as the history of the gap buffer.
+//
+// Returns [ErrUndoFileMismatch] if the text of the gap buffer is not the text
+// the undo file has been saved for, [ErrUndoVersion] if the version of the file
+// is not supported and [ErrCorruptUndoFile] if the file is damaged.
+func LoadUndoFile(g *GapBuffer, path string) (*History, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	headerSize := len(undoMagic) + 1 + sha256.Size
+
+	if len(data) < headerSize+undoCRCSize || string(data[:len(undoMagic)]) != undoMagic {
+		return nil, ErrCorruptUndoFile
+	}
+
+	if data[len(undoMagic)] != undoVersion {
+		return nil, ErrUndoVersion
+	}
+
+	end := len(data) - undoCRCSize
+	if crc32.ChecksumIEEE(data[:end]) != binary.LittleEndian.Uint32(data[end:]) {
+		return nil, ErrCorruptUndoFile
+	}
+
+	sum := sha256.Sum256([]byte(g.String()))
+	if !bytes.Equal(sum[:], data[len(undoMagic)+1:headerSize]) {
+		return nil, ErrUndoFileMismatch
+	}
+
+	dec := &undoDecoder{data: data[headerSize:end], err: nil}
+	undo := dec.steps()
+	redo := dec.steps()
+
+	if dec.err != nil || len(dec.data) > 0 {
+		return nil, ErrCorruptUndoFile
+	}
+
+	h := NewHistory(g)
+	h.undo = undo
+	h.redo = redo
+
+	return h, nil
+}
+
+// appendSteps appends the encoded undo steps to `data`.
+func appendSteps(data []byte, steps [][]Edit) []byte {
+	data = binary.AppendUvarint(data, uint64(len(steps)))
+
+	for _, step := range steps {
+		data = binary.AppendUvarint(data, uint64(len(step)))
+
+		for _, e := range step {
+			data = binary.AppendUvarint(data, uint64(e.Offset))
+			data = binary.AppendUvarint(data, uint64(len(e.Deleted)))
+			data = append(data, e.Deleted...)
+			data = binary.AppendUvarint(data, uint64(len(e.Inserted)))
+			data = append(data, e.Inserted...)
+		}
+	}
+
+	return data
+}
+
+// undoDecoder decodes the undo steps of an undo file and remembers the first
+// error.
+type undoDecoder struct {
+	data []byte
+	err  error
+}
+
+// steps decodes a list of undo steps.
+func (d *undoDecoder) steps() [][]Edit {
+	n := d.count()
+	steps := make([][]Edit, 0, n)
+
+	for ; n > 0; n-- {
+		m := d.count()
+		step := make([]Edit, 0, m)
+
+		for ; m > 0; m-- {
+			offset := d.offset()
+			deleted := d.string()
+			inserted := d.string()
+			step = append(step, Edit{Offset: offset, Deleted: deleted, Inserted: inserted})
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps
+}
+
+// count decodes an unsigned varint that can't be larger than the remaining
+// data.
+func (d *undoDecoder) count() int {
+	if d.err != nil {
+		return 0
+	}
+
+	val, n := binary.Uvarint(d.data)
+	if n <= 0 || val > uint64(len(d.data)) {
+		d.err = ErrCorruptUndoFile
+
+		return 0
+	}
+
+	d.data = d.data[n:]
+
+	return int(val)
+}
+
+// offset decodes an unsigned varint offset.
+func (d *undoDecoder) offset() int {
+	if d.err != nil {
+		return 0
+	}
+
+	val, n := binary.Uvarint(d.data)
+	if n <= 0 || val > uint64(maxInt) {
+		d.err = ErrCorruptUndoFile
+
+		return 0
+	}
+
+	d.data = d.data[n:]
+
+	return int(val)
+}
+
+// string decodes a string prefixed by its length.
+func (d *undoDecoder) string() string {
+	n := d.count()
+	if d.err != nil {
+		return ""
+	}
+
+	s := string(d.data[:n])
+	d.data = d.data[n:]
+
+	return s
+}
@@ -0,0 +1,241 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     validate.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidState is returned by [GapBuffer.Validate] if the internal state of
+// the gap buffer is inconsistent, which is a bug of this package.
+var ErrInvalidState = errors.New("invalid gap buffer state")
+
+// Validate checks the internal state of the gap buffer and returns an error
+// wrapping [ErrInvalidState] describing the first inconsistency found:
+//
+//   - the start and end of the gaps of the text and the line lengths are
+//     inside their arrays
+//   - the line lengths sum up to the length of the text
+//   - every line but the last ends in a newline character and contains no
+//     other newline character, the last line contains no newline character
+//   - the start of the current line is the sum of the lengths of the lines
+//     before it
+//   - the cursor is inside the current line, not after its newline character
+//   - the rune column the cursor wants to hold is not less than the rune
+//     column of the cursor
+//   - the additional cursors are sorted and inside the text
+//
+// Validate takes time proportional to the length of the text. Build with the
+// tag `gapbuffer_validate` to validate the gap buffer after every change and
+// movement of the cursor, which panics with the error and
+// [GapBuffer.DebugString] if the gap buffer is invalid.
+func (g *GapBuffer) Validate() error {
+	if err := validateGap("text", &g.text); err != nil {
+		return err
+	}
+
+	if err := g.lines.validate(); err != nil {
+		return err
+	}
+
+	length := g.StringLength()
+	if g.cursor < 0 || g.cursor > length {
+		return fmt.Errorf("%w: cursor %d is outside of the text of length %d", ErrInvalidState, g.cursor, length)
+	}
+
+	if err := g.validateLines(); err != nil {
+		return err
+	}
+
+	if err := g.validateCursor(); err != nil {
+		return err
+	}
+
+	if g.cursors == nil || g.cursors.batch {
+		return nil
+	}
+
+	for idx, c := range g.cursors.cursors {
+		if c.offset < 0 || c.offset > length || (idx > 0 && c.offset <= g.cursors.cursors[idx-1].offset) {
+			return fmt.Errorf("%w: additional cursor %d at offset %d is out of order or outside of the text",
+				ErrInvalidState, idx, c.offset)
+		}
+	}
+
+	return nil
+}
+
+// Return a multi-line description of the internal state of the gap buffer for
+// debugging: the cursor, the text before and after the gap and the line
+// lengths before and after the gap, like this:
+//
+//	cursor: offset 3, line 1, wants col 3
+//	text:   "Hel" |< gap 5 >| "lo"
+//	lines:  [5] |< gap 9 >| []
+//	line offset 0, unindexed 0
+//
+// The current line is the last line before the gap of the line lengths. The
+// state is printed as is, so this works for an invalid gap buffer too.
+//
+// See also [GapBuffer.Validate].
+func (g *GapBuffer) DebugString() string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "cursor: offset %d, line %d, wants col %d\n",
+		g.cursor, g.lines.curLine(), g.wantsCol)
+
+	left, right := debugParts(&g.text)
+	fmt.Fprintf(&builder, "text:   %q |< gap %d >| %q\n", left, g.text.end-g.text.start, right)
+
+	before, after := debugParts(&g.lines.lengths)
+	fmt.Fprintf(&builder, "lines:  %v |< gap %d >| %v\n",
+		before, g.lines.lengths.end-g.lines.lengths.start, after)
+	fmt.Fprintf(&builder, "line offset %d, unindexed %d", g.lines.offset, g.unindexed)
+
+	return builder.String()
+}
+
+// validateLines checks that the line lengths match the newlines of the text.
+// The lines of the text which haven't been indexed yet, see [NewStrIndexed],
+// are ignored.
+func (g *GapBuffer) validateLines() error {
+	indexed := g.StringLength() - g.unindexed
+	if g.unindexed < 0 || (g.indexer == nil && g.unindexed != 0) {
+		return fmt.Errorf("%w: %d unindexed bytes", ErrInvalidState, g.unindexed)
+	}
+
+	last := g.lines.lineCount() - 1
+	start := 0
+
+	var err error
+
+	g.lines.lengths.Each(0, last+1, func(idx int, n int) bool {
+		if start+n > indexed {
+			err = fmt.Errorf("%w: line %d of length %d ends after the text of length %d",
+				ErrInvalidState, idx+1, n, indexed)
+
+			return false
+		}
+
+		nl := g.countNewlines(start, start+n)
+
+		switch {
+		case idx < last && (nl != 1 || g.byteAt(start+n-1) != '\n'):
+			err = fmt.Errorf("%w: line %d at offset %d of length %d does not end in the only newline",
+				ErrInvalidState, idx+1, start, n)
+		case idx == last && nl != 0:
+			err = fmt.Errorf("%w: last line %d at offset %d of length %d contains a newline",
+				ErrInvalidState, idx+1, start, n)
+		}
+
+		start += n
+
+		return err == nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if start != indexed {
+		return fmt.Errorf("%w: the line lengths sum up to %d, not to the length %d of the text",
+			ErrInvalidState, start, indexed)
+	}
+
+	return nil
+}
+
+// validateCursor checks that the cursor is inside the current line and the
+// wanted rune column.
+func (g *GapBuffer) validateCursor() error {
+	start := 0
+	g.lines.lengths.Each(0, g.lines.cur(), func(_ int, n int) bool {
+		start += n
+
+		return true
+	})
+
+	if g.lines.offset != start {
+		return fmt.Errorf("%w: the current line starts at %d, not at %d",
+			ErrInvalidState, start, g.lines.offset)
+	}
+
+	end := g.lines.curLineStart() + g.lines.curLineLength()
+	if !g.lines.isLastLine() {
+		end--
+	}
+
+	if g.cursor < g.lines.curLineStart() || g.cursor > end {
+		return fmt.Errorf("%w: cursor %d is outside of line %d from %d to %d",
+			ErrInvalidState, g.cursor, g.lines.curLine(), g.lines.curLineStart(), end)
+	}
+
+	if runeCol := g.RuneCol(); g.wantsCol < runeCol {
+		return fmt.Errorf("%w: wanted rune column %d is less than the rune column %d",
+			ErrInvalidState, g.wantsCol, runeCol)
+	}
+
+	return nil
+}
+
+// mustValidate panics if [GapBuffer.Validate] returns an error, see the build
+// tag `gapbuffer_validate`.
+func (g *GapBuffer) mustValidate() {
+	if err := g.Validate(); err != nil {
+		panic(fmt.Sprintf("%v\n%s", err, g.DebugString()))
+	}
+}
+
+// validate checks the gap of the line lengths, that there is a current line,
+// that all lengths are not negative and all lines but the last are not empty.
+func (l *lineBuffer) validate() error {
+	if err := validateGap("lines", &l.lengths); err != nil {
+		return err
+	}
+
+	if l.lengths.start < 1 {
+		return fmt.Errorf("%w: no current line", ErrInvalidState)
+	}
+
+	last := l.lineCount() - 1
+
+	var err error
+
+	l.lengths.Each(0, last+1, func(idx int, n int) bool {
+		if n < 0 || (n == 0 && idx < last) {
+			err = fmt.Errorf("%w: line %d has the length %d", ErrInvalidState, idx+1, n)
+		}
+
+		return err == nil
+	})
+
+	return err
+}
+
+// debugParts returns the elements before and after the gap, clamped to the
+// array if the gap is invalid.
+func debugParts[T any](gap *Gap[T]) (left []T, right []T) {
+	start := min(max(gap.start, 0), len(gap.data))
+	end := min(max(gap.end, start), len(gap.data))
+
+	return gap.data[:start], gap.data[end:]
+}
+
+// validateGap checks that the gap is inside the array of the [Gap].
+func validateGap[T any](name string, gap *Gap[T]) error {
+	if gap.start < 0 || gap.start > gap.end || gap.end > len(gap.data) {
+		return fmt.Errorf("%w: the gap of the %s from %d to %d is outside of the array of length %d",
+			ErrInvalidState, name, gap.start, gap.end, len(gap.data))
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     validate_on.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+//go:build gapbuffer_validate
+
+package gapbuffer
+
+// validateOps is true if the gap buffer is validated after every change and
+// movement of the cursor, see [GapBuffer.Validate].
+const validateOps = true
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText:  Copyright 2024 Roland Csaszar
+// SPDX-License-Identifier: MIT
+//
+// Project:  go-gap-buffer
+// File:     validate_test.go
+// Date:     18.Oct.2026
+//
+// =============================================================================
+
+package gapbuffer_test
+
+import (
+	"strings"
+	"testing"
+
+	gapbuffer "github.com/Release-Candidate/go-gap-buffer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStr("Grüße\n😀 smile\n\nlast")
+	require.NoError(t, gb.Validate())
+
+	steps := []func(){
+		gb.UpMv, gb.UpMv, gb.UpMv, gb.DownMv, gb.LeftDel, gb.RightDel,
+		gb.DownMv, gb.DownMv, gb.DownMv, gb.LeftMv, gb.RightMv,
+		func() { gb.Insert("new\nlines\n") },
+		func() { gb.MoveTo(3) },
+		func() { gb.DeleteRange(2, 12) },
+		func() { gb.ReplaceRange(0, 1, "\n\n") },
+		func() { gb.AddCursor(0) },
+		func() { gb.InsertAll("ab") },
+		gb.RightDelAll, gb.UpMvAll, gb.DownMvAll, gb.LeftDelAll,
+	}
+
+	for idx, step := range steps {
+		step()
+		require.NoError(t, gb.Validate(), "step %d\n%s", idx, gb.DebugString())
+	}
+}
+
+func TestValidateIndexed(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStrIndexed(strings.Repeat("line\n", 100_000), nil)
+	require.NoError(t, gb.Validate())
+
+	gb.DownMv()
+	gb.Insert("x")
+	require.NoError(t, gb.Validate())
+
+	assert.Equal(t, 100_001, gb.LineCount())
+	require.NoError(t, gb.Validate())
+}
+
+func TestDebugString(t *testing.T) {
+	t.Parallel()
+
+	gb := gapbuffer.NewStrCap("Hel\nlo", 8)
+	gb.LeftMv()
+	gb.LeftDel()
+	gb.Insert("L")
+
+	assert.Equal(t, `cursor: offset 5, line 2, wants col 1
+text:   "Hel\nL" |< gap 6 >| "o"
+lines:  [4 2] |< gap 8 >| []
+line offset 4, unindexed 0`, gb.DebugString())
+}